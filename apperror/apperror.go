@@ -0,0 +1,60 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is a machine-readable error code reported to API clients in the error extensions.
+type Code string
+
+const (
+	CodeRateLimited         Code = "RATE_LIMITED"
+	CodePolicyViolation     Code = "POLICY_VIOLATION"
+	CodeWalletFrozen        Code = "WALLET_FROZEN"
+	CodeWalletClosed        Code = "WALLET_CLOSED"
+	CodeWalletNotFound      Code = "WALLET_NOT_FOUND"
+	CodeUnauthenticated     Code = "UNAUTHENTICATED"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeInvalidInput        Code = "INVALID_INPUT"
+	CodeInsufficientBalance Code = "INSUFFICIENT_BALANCE"
+)
+
+// Error is an error that is reported to API clients together with its code and
+// optional additional extensions.
+type Error struct {
+	Code       Code
+	Message    string
+	Extensions map[string]any
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Newf(code Code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// WithExtension returns a copy of e with an additional extension set.
+func (e *Error) WithExtension(key string, value any) *Error {
+	extensions := make(map[string]any, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		extensions[k] = v
+	}
+	extensions[key] = value
+	return &Error{Code: e.Code, Message: e.Message, Extensions: extensions}
+}
+
+// CodeOf returns the code of the first *Error in err's chain, or an empty code.
+func CodeOf(err error) Code {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
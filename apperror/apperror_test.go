@@ -0,0 +1,24 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOf(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", New(CodeRateLimited, "slow down"))
+	require.Equal(t, CodeRateLimited, CodeOf(err))
+	require.Equal(t, Code(""), CodeOf(errors.New("plain error")))
+}
+
+func TestWithExtension_ShouldNotModifyOriginal(t *testing.T) {
+	original := New(CodeRateLimited, "slow down")
+	extended := original.WithExtension("retryAfter", 3)
+
+	require.Nil(t, original.Extensions)
+	require.Equal(t, map[string]any{"retryAfter": 3}, extended.Extensions)
+	require.Equal(t, original.Message, extended.Message)
+}
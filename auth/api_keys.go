@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// APIKeyAuthenticator authenticates callers by static API keys.
+type APIKeyAuthenticator struct {
+	// Principals indexed by the SHA-256 hash of their key, so that lookups don't leak
+	// the keys through timing.
+	principals map[[sha256.Size]byte]*Principal
+}
+
+// ParseAPIKeys reads API keys, one per line in the format "<key> <subject> <role>".
+// Empty lines and lines starting with # are ignored.
+func ParseAPIKeys(r io.Reader) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{
+		principals: make(map[[sha256.Size]byte]*Principal),
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected \"<key> <subject> <role>\"", lineNumber)
+		}
+		role, err := ParseRole(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		hash := sha256.Sum256([]byte(fields[0]))
+		if _, ok := a.principals[hash]; ok {
+			return nil, fmt.Errorf("line %d: duplicated key", lineNumber)
+		}
+		a.principals[hash] = &Principal{Subject: fields[1], Role: role}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(key string) (*Principal, error) {
+	principal, ok := a.principals[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, errors.New("unknown API key")
+	}
+	return principal, nil
+}
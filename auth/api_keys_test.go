@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	const keys = `
+# key subject role
+viewer-key   dashboard viewer
+operator-key payments  OPERATOR
+admin-key    alice     admin
+`
+
+	a, err := ParseAPIKeys(strings.NewReader(keys))
+	require.NoError(t, err)
+
+	principal, err := a.Authenticate("operator-key")
+	require.NoError(t, err)
+	require.Equal(t, &Principal{Subject: "payments", Role: RoleOperator}, principal)
+
+	principal, err = a.Authenticate("admin-key")
+	require.NoError(t, err)
+	require.Equal(t, RoleAdmin, principal.Role)
+
+	_, err = a.Authenticate("unknown-key")
+	require.Error(t, err)
+}
+
+func TestParseAPIKeys_InvalidFiles_ShouldReturnError(t *testing.T) {
+	files := []string{
+		"key subject",
+		"key subject superuser",
+		"key subject admin extra",
+		"key a admin\nkey b viewer",
+	}
+
+	for _, file := range files {
+		_, err := ParseAPIKeys(strings.NewReader(file))
+		require.Error(t, err, file)
+	}
+}
+
+func TestRole_Includes(t *testing.T) {
+	require.True(t, RoleAdmin.Includes(RoleViewer))
+	require.True(t, RoleOperator.Includes(RoleOperator))
+	require.False(t, RoleViewer.Includes(RoleOperator))
+	require.False(t, Role("").Includes(RoleViewer))
+}
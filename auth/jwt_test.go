@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuthenticator_HS256(t *testing.T) {
+	secret := []byte("test-secret")
+	j := &JWTAuthenticator{HMACSecret: secret, Issuer: "issuer"}
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		require.NoError(t, err)
+		return token
+	}
+	valid := jwt.MapClaims{
+		"sub":  "alice",
+		"role": "admin",
+		"iss":  "issuer",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+
+	principal, err := j.Authenticate(sign(valid))
+	require.NoError(t, err)
+	require.Equal(t, &Principal{Subject: "alice", Role: RoleAdmin}, principal)
+
+	invalid := map[string]func(jwt.MapClaims){
+		"expired":       func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiration": func(c jwt.MapClaims) { delete(c, "exp") },
+		"wrong issuer":  func(c jwt.MapClaims) { c["iss"] = "someone else" },
+		"unknown role":  func(c jwt.MapClaims) { c["role"] = "superuser" },
+		"missing sub":   func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+	for name, modify := range invalid {
+		t.Run(name, func(t *testing.T) {
+			claims := jwt.MapClaims{}
+			for k, v := range valid {
+				claims[k] = v
+			}
+			modify(claims)
+
+			_, err := j.Authenticate(sign(claims))
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid).SignedString([]byte("other-secret"))
+		require.NoError(t, err)
+
+		_, err = j.Authenticate(token)
+		require.Error(t, err)
+	})
+}
+
+func TestJWTAuthenticator_RS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	set := map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	setJSON, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	keys, err := ParseJWKS(strings.NewReader(string(setJSON)))
+	require.NoError(t, err)
+	require.Equal(t, key.PublicKey.N, keys["key-1"].N)
+
+	j := &JWTAuthenticator{RSAKeys: keys}
+	claims := jwt.MapClaims{
+		"sub":  "payments",
+		"role": "operator",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	principal, err := j.Authenticate(signed)
+	require.NoError(t, err)
+	require.Equal(t, &Principal{Subject: "payments", Role: RoleOperator}, principal)
+
+	t.Run("unknown key id", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key-2"
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+
+		_, err = j.Authenticate(signed)
+		require.Error(t, err)
+	})
+
+	t.Run("HS256 not accepted without secret", func(t *testing.T) {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(""))
+		require.NoError(t, err)
+
+		_, err = j.Authenticate(signed)
+		require.Error(t, err)
+	})
+}
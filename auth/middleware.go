@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+const anonymousSubject = "anonymous"
+
+// Authenticator identifies callers by an API key sent in the X-API-Key header, or by
+// a JWT or API key sent as a bearer token in the Authorization header.
+type Authenticator struct {
+	// Nil disables authentication by API keys.
+	APIKeys *APIKeyAuthenticator
+	// Nil disables authentication by JWTs.
+	JWT *JWTAuthenticator
+	// Role given to callers that send no credentials; empty means no role at all.
+	AnonymousRole Role
+}
+
+// Middleware stores the caller's principal in the request context. Requests with
+// invalid credentials are rejected, requests without credentials are anonymous.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		principal, err := a.Authenticate(r.Header.Get(APIKeyHeader), r.Header.Get("Authorization"))
+		if err != nil {
+			logging.FromContext(ctx).Warn("authentication failed", "error", err)
+			writeUnauthenticated(w)
+			return
+		}
+
+		if principal != nil {
+			ctx = NewContext(ctx, principal)
+			if !principal.Anonymous {
+				ctx = caller_helper.NewContext(ctx, principal.Subject)
+			}
+			ctx = logging.With(ctx, "principal", principal.Subject, "role", principal.Role)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authenticate identifies the caller by the values of the X-API-Key and Authorization
+// headers, either of which may be empty. It returns nil if there are no credentials and
+// anonymous callers have no role.
+func (a *Authenticator) Authenticate(apiKey string, authorization string) (*Principal, error) {
+	if apiKey != "" {
+		return a.authenticateAPIKey(apiKey)
+	}
+
+	if authorization != "" {
+		token, ok := strings.CutPrefix(authorization, "Bearer ")
+		if !ok {
+			return nil, errors.New("unsupported authorization scheme")
+		}
+		// JWTs consist of three dot-separated parts, API keys must not contain dots.
+		if strings.Count(token, ".") == 2 {
+			return a.authenticateJWT(token)
+		}
+		return a.authenticateAPIKey(token)
+	}
+
+	if a.AnonymousRole != "" {
+		return &Principal{Subject: anonymousSubject, Role: a.AnonymousRole, Anonymous: true}, nil
+	}
+	return nil, nil
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (*Principal, error) {
+	if a.APIKeys == nil {
+		return nil, errors.New("API keys are not accepted")
+	}
+	return a.APIKeys.Authenticate(key)
+}
+
+func (a *Authenticator) authenticateJWT(token string) (*Principal, error) {
+	if a.JWT == nil {
+		return nil, errors.New("JWTs are not accepted")
+	}
+	return a.JWT.Authenticate(token)
+}
+
+func writeUnauthenticated(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]any{{
+			"message":    "invalid credentials",
+			"extensions": map[string]any{"code": apperror.CodeUnauthenticated},
+		}},
+	})
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	apiKeys, err := ParseAPIKeys(strings.NewReader("secret-key payments operator"))
+	require.NoError(t, err)
+	secret := []byte("jwt-secret")
+
+	a := &Authenticator{
+		APIKeys: apiKeys,
+		JWT:     &JWTAuthenticator{HMACSecret: secret},
+	}
+
+	var principal *Principal
+	var caller string
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = FromContext(r.Context())
+		caller = caller_helper.FromContext(r.Context())
+	}))
+
+	serve := func(header string, value string) int {
+		principal, caller = nil, ""
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("api key header", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve(APIKeyHeader, "secret-key"))
+		require.Equal(t, &Principal{Subject: "payments", Role: RoleOperator}, principal)
+		require.Equal(t, "payments", caller)
+	})
+
+	t.Run("api key as bearer token", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve("Authorization", "Bearer secret-key"))
+		require.Equal(t, "payments", principal.Subject)
+	})
+
+	t.Run("jwt as bearer token", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":  "alice",
+			"role": "admin",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusOK, serve("Authorization", "Bearer "+token))
+		require.Equal(t, &Principal{Subject: "alice", Role: RoleAdmin}, principal)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve(APIKeyHeader, "wrong-key"))
+		require.Equal(t, http.StatusUnauthorized, serve("Authorization", "Bearer a.b.c"))
+		require.Equal(t, http.StatusUnauthorized, serve("Authorization", "Basic dXNlcjpwYXNz"))
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve("", ""))
+		require.Nil(t, principal)
+	})
+
+	t.Run("no credentials with anonymous role", func(t *testing.T) {
+		anonymous := &Authenticator{AnonymousRole: RoleViewer}
+		handler := caller_helper.Middleware(anonymous.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal = FromContext(r.Context())
+			caller = caller_helper.FromContext(r.Context())
+		})))
+
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		req.RemoteAddr = "192.0.2.7:4321"
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		require.Equal(t, &Principal{Subject: "anonymous", Role: RoleViewer, Anonymous: true}, principal)
+		// Anonymous callers are rate limited by their address, not all together.
+		require.Equal(t, "192.0.2.7", caller)
+	})
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleViewer   Role = "VIEWER"
+	RoleOperator Role = "OPERATOR"
+	RoleAdmin    Role = "ADMIN"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole accepts role names regardless of their case.
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToUpper(name))
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", name)
+	}
+	return role, nil
+}
+
+// Includes reports whether a principal with role r may do what the other role may do.
+// Roles are ordered: viewer < operator < admin.
+func (r Role) Includes(other Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[other]
+}
+
+// Principal is an authenticated caller of the API.
+type Principal struct {
+	Subject string
+	Role    Role
+	// Whether the caller sent no credentials and was given the anonymous role. Anonymous
+	// callers share the subject, so they are told apart by their address instead.
+	Anonymous bool
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal of the current request, or nil for anonymous requests.
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
+
+// HasRole reports whether the caller of the current request has at least the given role.
+func HasRole(ctx context.Context, role Role) bool {
+	principal := FromContext(ctx)
+	return principal != nil && principal.Role.Includes(role)
+}
@@ -0,0 +1,150 @@
+// Package client is a Go client of the GraphQL API of TokenTransferAPI. Its types and
+// operations are generated from the schema of the server with genqlient; Client adds
+// request signing, retries of failed requests and idempotency keys of transfers.
+package client
+
+//go:generate go tool genqlient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	DefaultMaxRetries    = 3
+	DefaultRetryDelay    = 200 * time.Millisecond
+	DefaultMaxRetryDelay = 10 * time.Second
+)
+
+// Config configures a Client.
+type Config struct {
+	// URL of the GraphQL endpoint, e.g. "https://tokens.example.com/query".
+	Endpoint string
+	// Adds credentials to every request; requests are sent without them if nil.
+	Signer Signer
+	// Client sending the requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// How many times a failed request is retried; DefaultMaxRetries if 0, and no
+	// retries if negative.
+	MaxRetries int
+	// Delay before the first retry, doubled before every next one; DefaultRetryDelay if 0.
+	RetryDelay time.Duration
+	// Longest delay before a retry; DefaultMaxRetryDelay if 0. Requests rate limited for
+	// longer are not retried.
+	MaxRetryDelay time.Duration
+}
+
+// Client calls the GraphQL API. Requests failing because of the network, an error of
+// the server or the rate limit are retried; transfers are safe to retry thanks to their
+// idempotency keys.
+type Client struct {
+	cfg     Config
+	graphql graphql.Client
+}
+
+func New(cfg Config) *Client {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	if cfg.MaxRetryDelay == 0 {
+		cfg.MaxRetryDelay = DefaultMaxRetryDelay
+	}
+
+	return &Client{
+		cfg: cfg,
+		graphql: &retryingClient{
+			graphql:       graphql.NewClient(cfg.Endpoint, signingDoer{httpClient: cfg.HTTPClient, signer: cfg.Signer}),
+			maxRetries:    max(cfg.MaxRetries, 0),
+			retryDelay:    cfg.RetryDelay,
+			maxRetryDelay: cfg.MaxRetryDelay,
+		},
+	}
+}
+
+// GetWallet returns the wallet with the given address, or nil if it doesn't exist.
+func (c *Client) GetWallet(ctx context.Context, address string) (*Wallet, error) {
+	resp, err := getWallet(ctx, c.graphql, address)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Wallet, nil
+}
+
+// TransferInput describes a transfer made by Client.Transfer.
+type TransferInput struct {
+	FromAddress string
+	ToAddress   string
+	Amount      int64
+	Memo        *string
+	Metadata    map[string]any
+	// Makes the transfer at most once, also when it is retried. Generated with
+	// NewIdempotencyKey if empty.
+	IdempotencyKey string
+}
+
+// Transfer moves tokens between wallets. If a transfer with the same idempotency key
+// was already made by the sender, it is returned instead of making a new one.
+func (c *Client) Transfer(ctx context.Context, input TransferInput) (*TransferResult, error) {
+	if input.IdempotencyKey == "" {
+		input.IdempotencyKey = NewIdempotencyKey()
+	}
+
+	resp, err := transferTokens(ctx, c.graphql, input.FromAddress, input.ToAddress, input.Amount,
+		input.Memo, input.Metadata, input.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.TransferTokens, nil
+}
+
+// Transfers returns at most limit transfers sent or received by the wallet, newest
+// first. Only transfers matching filter are returned if it is set, and only transfers
+// older than the one with ID before if before is set.
+func (c *Client) Transfers(ctx context.Context, address string, filter *TransferFilter, limit int, before *string) ([]Transfer, error) {
+	resp, err := getTransfers(ctx, c.graphql, address, filter, limit, before)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Transfers, nil
+}
+
+// NewIdempotencyKey returns a random idempotency key. A key should be generated once
+// per transfer and reused when the transfer is retried, also by a later process.
+func NewIdempotencyKey() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf) // never returns an error
+	return hex.EncodeToString(buf)
+}
+
+// ErrorCode returns the code of the first error reported by the API in err, e.g.
+// apperror.CodeWalletFrozen, or an empty code.
+func ErrorCode(err error) apperror.Code {
+	var errs gqlerror.List
+	var httpErr *graphql.HTTPError
+	if errors.As(err, &httpErr) {
+		errs = httpErr.Response.Errors
+	} else if !errors.As(err, &errs) {
+		return ""
+	}
+
+	for _, e := range errs {
+		if code, ok := e.Extensions["code"].(string); ok {
+			return apperror.Code(code)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,450 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
+	"github.com/kamil7430/TokenTransferAPI/service"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const (
+	alice = "0x1111111111111111111111111111111111111111"
+	bob   = "0x2222222222222222222222222222222222222222"
+	carol = "0x3333333333333333333333333333333333333333"
+)
+
+var (
+	jwtSecret = []byte("0123456789abcdef0123456789abcdef")
+	apiKeys   = "viewer-key script VIEWER\noperator-key script OPERATOR\n"
+)
+
+// ledger keeps wallets and transfers in memory, honouring idempotency keys like the
+// real wallet service.
+type ledger struct {
+	service.WalletServicer
+
+	mu        sync.Mutex
+	wallets   map[string]*model.Wallet
+	transfers []*model.Transfer
+	keys      map[string]*model.Transfer
+	polls     int
+}
+
+func newLedger() *ledger {
+	return &ledger{
+		wallets: map[string]*model.Wallet{
+			alice: {Address: alice, Tokens: 100, Status: model.WalletStatusActive},
+			bob:   {Address: bob, Tokens: 50, Status: model.WalletStatusFrozen},
+		},
+		keys: map[string]*model.Transfer{},
+	}
+}
+
+func (l *ledger) GetWallet(_ context.Context, address string) (*model.Wallet, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	wallet, ok := l.wallets[address]
+	if !ok {
+		return nil, nil
+	}
+	copied := *wallet
+	return &copied, nil
+}
+
+func (l *ledger) GetWalletsByAddresses(ctx context.Context, addresses []string) (map[string]*model.Wallet, error) {
+	wallets := make(map[string]*model.Wallet, len(addresses))
+	for _, address := range addresses {
+		wallets[address], _ = l.GetWallet(ctx, address)
+	}
+	return wallets, nil
+}
+
+func (l *ledger) GetWalletActivities(_ context.Context, addresses []string) (map[string]*model.WalletActivity, error) {
+	activities := make(map[string]*model.WalletActivity, len(addresses))
+	for _, address := range addresses {
+		activities[address] = &model.WalletActivity{Address: address}
+	}
+	return activities, nil
+}
+
+func (l *ledger) TransferTokens(_ context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any, idempotencyKey *string) (*model.TransferResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	from := l.wallets[fromAddress]
+	if idempotencyKey != nil {
+		if previous, ok := l.keys[*idempotencyKey]; ok {
+			return &model.TransferResult{Transfer: previous, FromWallet: from, ToWallet: l.wallets[toAddress]}, nil
+		}
+	}
+	if from == nil {
+		return nil, apperror.New(apperror.CodeWalletNotFound, "sender wallet does not exist")
+	}
+	if from.Status != model.WalletStatusActive {
+		return nil, apperror.New(apperror.CodeWalletFrozen, "sender wallet is frozen")
+	}
+	if from.Tokens < amount {
+		return nil, errors.New("insufficient balance")
+	}
+
+	to, recipientCreated := l.wallets[toAddress], false
+	if to == nil {
+		to, recipientCreated = &model.Wallet{Address: toAddress, Status: model.WalletStatusActive}, true
+		l.wallets[toAddress] = to
+	}
+	from.Tokens -= amount
+	to.Tokens += amount
+
+	transfer := &model.Transfer{
+		Model:          gorm.Model{ID: uint(len(l.transfers) + 1), CreatedAt: time.Now()},
+		FromAddress:    fromAddress,
+		ToAddress:      toAddress,
+		Amount:         amount,
+		Memo:           memo,
+		Metadata:       metadata,
+		IdempotencyKey: idempotencyKey,
+	}
+	l.transfers = append(l.transfers, transfer)
+	if idempotencyKey != nil {
+		l.keys[*idempotencyKey] = transfer
+	}
+	return &model.TransferResult{Transfer: transfer, FromWallet: from, ToWallet: to, RecipientCreated: recipientCreated}, nil
+}
+
+func (l *ledger) GetTransfers(_ context.Context, address string, _ *model.TransferFilter, limit int, before *uint) ([]*model.Transfer, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.polls++
+	var transfers []*model.Transfer
+	for i := len(l.transfers) - 1; i >= 0 && len(transfers) < limit; i-- {
+		transfer := l.transfers[i]
+		if (transfer.FromAddress == address || transfer.ToAddress == address) && (before == nil || transfer.ID < *before) {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
+
+func (l *ledger) transferCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.transfers)
+}
+
+func (l *ledger) pollCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.polls
+}
+
+// testServer serves the GraphQL API like the real server does. Responses to the next
+// failures requests are replaced by 503 errors after the requests are handled, as if
+// they were lost on the way back.
+type testServer struct {
+	*httptest.Server
+	ledger *ledger
+
+	mu       sync.Mutex
+	requests int
+	failures int
+}
+
+func newTestServer(t *testing.T, rateLimit *graph.RateLimit) *testServer {
+	apiKeyAuthenticator, err := auth.ParseAPIKeys(strings.NewReader(apiKeys))
+	require.NoError(t, err)
+	authenticator := &auth.Authenticator{
+		APIKeys: apiKeyAuthenticator,
+		JWT:     &auth.JWTAuthenticator{HMACSecret: jwtSecret},
+	}
+
+	s := &testServer{ledger: newLedger()}
+	srv := graph.NewHandler(graph.HandlerConfig{
+		Resolvers:       &graph.Resolver{WalletService: s.ledger, SubscriptionInterval: 10 * time.Millisecond},
+		ComplexityLimit: 200,
+		MaxDepth:        10,
+		RateLimit:       rateLimit,
+	})
+	logger := slog.New(slog.DiscardHandler)
+	h := caller_helper.Middleware(logging.Middleware(logger, authenticator.Middleware(srv)))
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		fail := s.failures > 0
+		if fail {
+			s.failures--
+		}
+		s.mu.Unlock()
+
+		if fail {
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testServer) failNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+func (s *testServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *testServer) newClient(signer Signer) *Client {
+	return New(Config{Endpoint: s.URL, Signer: signer, RetryDelay: time.Millisecond})
+}
+
+func TestClient_GetWallet(t *testing.T) {
+	s := newTestServer(t, nil)
+	c := s.newClient(APIKey("viewer-key"))
+
+	wallet, err := c.GetWallet(context.Background(), alice)
+	require.NoError(t, err)
+	require.Equal(t, alice, wallet.Address)
+	require.EqualValues(t, 100, wallet.Tokens)
+	require.Equal(t, WalletStatusActive, wallet.Status)
+
+	wallet, err = c.GetWallet(context.Background(), carol)
+	require.NoError(t, err)
+	require.Nil(t, wallet)
+}
+
+func TestClient_Transfer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retried transfer is made once", func(t *testing.T) {
+		s := newTestServer(t, nil)
+		c := s.newClient(APIKey("operator-key"))
+		s.failNext(2)
+
+		memo := "rent"
+		result, err := c.Transfer(ctx, TransferInput{
+			FromAddress: alice,
+			ToAddress:   carol,
+			Amount:      30,
+			Memo:        &memo,
+			Metadata:    map[string]any{"invoice": "17"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, s.requestCount())
+		require.Equal(t, 1, s.ledger.transferCount())
+		require.Equal(t, "1", result.Transfer.Id)
+		require.Equal(t, &memo, result.Transfer.Memo)
+		require.Equal(t, map[string]any{"invoice": "17"}, result.Transfer.Metadata)
+		require.EqualValues(t, 70, result.FromWallet.Tokens)
+		require.EqualValues(t, 30, result.ToWallet.Tokens)
+	})
+
+	t.Run("transfer with a used idempotency key is not made again", func(t *testing.T) {
+		s := newTestServer(t, nil)
+		c := s.newClient(APIKey("operator-key"))
+
+		input := TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10, IdempotencyKey: NewIdempotencyKey()}
+		first, err := c.Transfer(ctx, input)
+		require.NoError(t, err)
+		second, err := c.Transfer(ctx, input)
+		require.NoError(t, err)
+		require.Equal(t, first.Transfer.Id, second.Transfer.Id)
+		require.Equal(t, 1, s.ledger.transferCount())
+
+		_, err = c.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+		require.NoError(t, err)
+		require.Equal(t, 2, s.ledger.transferCount())
+	})
+
+	t.Run("rejected transfer is not retried", func(t *testing.T) {
+		s := newTestServer(t, nil)
+		c := s.newClient(APIKey("operator-key"))
+
+		_, err := c.Transfer(ctx, TransferInput{FromAddress: bob, ToAddress: alice, Amount: 10})
+		require.Equal(t, apperror.CodeWalletFrozen, ErrorCode(err))
+		require.Equal(t, 1, s.requestCount())
+	})
+
+	t.Run("failed requests are retried at most MaxRetries times", func(t *testing.T) {
+		s := newTestServer(t, nil)
+		c := New(Config{Endpoint: s.URL, Signer: APIKey("operator-key"), MaxRetries: 1, RetryDelay: time.Millisecond})
+		s.failNext(2)
+
+		_, err := c.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+		var httpErr *graphql.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+		require.Equal(t, 2, s.requestCount())
+	})
+}
+
+func TestClient_Transfer_RateLimited(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, &graph.RateLimit{
+		Store:       ratelimit.NewMemoryStore(),
+		ClientLimit: ratelimit.Limit{Rate: 100, Burst: 100},
+		WalletLimit: ratelimit.Limit{Rate: 1, Burst: 1},
+	})
+
+	c := s.newClient(APIKey("operator-key"))
+	_, err := c.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+	require.NoError(t, err)
+
+	// The wallet's bucket refills after a second, which the client waits for.
+	start := time.Now()
+	_, err = c.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+	require.Equal(t, 3, s.requestCount())
+
+	impatient := New(Config{Endpoint: s.URL, Signer: APIKey("operator-key"), MaxRetryDelay: 500 * time.Millisecond})
+	_, err = impatient.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+	require.Equal(t, apperror.CodeRateLimited, ErrorCode(err))
+	require.Equal(t, 4, s.requestCount())
+	require.Equal(t, 2, s.ledger.transferCount())
+}
+
+func TestClient_Transfers(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, nil)
+	c := s.newClient(APIKey("operator-key"))
+
+	for _, amount := range []int64{10, 20, 30} {
+		_, err := c.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: amount})
+		require.NoError(t, err)
+	}
+
+	transfers, err := c.Transfers(ctx, alice, nil, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.EqualValues(t, 30, transfers[0].Amount)
+	require.EqualValues(t, 20, transfers[1].Amount)
+
+	transfers, err = c.Transfers(ctx, alice, nil, 2, &transfers[1].Id)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.EqualValues(t, 10, transfers[0].Amount)
+}
+
+func TestClient_Signers(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, nil)
+
+	t.Run("JWT", func(t *testing.T) {
+		c := s.newClient(JWTSigner{Secret: jwtSecret, Subject: "script", Role: "OPERATOR"})
+		_, err := c.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+		require.NoError(t, err)
+	})
+
+	t.Run("JWT without the required role", func(t *testing.T) {
+		c := s.newClient(JWTSigner{Secret: jwtSecret, Subject: "script", Role: "VIEWER"})
+		_, err := c.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+		require.Equal(t, apperror.CodeForbidden, ErrorCode(err))
+	})
+
+	t.Run("invalid credentials are not retried", func(t *testing.T) {
+		for name, signer := range map[string]Signer{
+			"JWT":     JWTSigner{Secret: []byte("another secret of the same length"), Subject: "script", Role: "OPERATOR"},
+			"API key": APIKey("unknown-key"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				requests := s.requestCount()
+				_, err := s.newClient(signer).GetWallet(ctx, alice)
+				require.Equal(t, apperror.CodeUnauthenticated, ErrorCode(err))
+				require.Equal(t, requests+1, s.requestCount())
+			})
+		}
+	})
+}
+
+func TestClient_WatchTransfers(t *testing.T) {
+	s := newTestServer(t, nil)
+	operator := s.newClient(APIKey("operator-key"))
+	_, err := operator.Transfer(context.Background(), TransferInput{FromAddress: alice, ToAddress: carol, Amount: 5})
+	require.NoError(t, err)
+
+	t.Run("streams new transfers until ctx is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		received := make(chan Transfer)
+		done := make(chan error)
+		polls := s.ledger.pollCount()
+		go func() {
+			done <- s.newClient(APIKey("viewer-key")).WatchTransfers(ctx, alice, func(transfer Transfer) error {
+				received <- transfer
+				return nil
+			})
+		}()
+
+		// Wait until the wallet is polled, so that the transfers are made after subscribing.
+		require.Eventually(t, func() bool { return s.ledger.pollCount() >= polls+2 }, time.Second, time.Millisecond)
+		for _, amount := range []int64{10, 20} {
+			_, err := operator.Transfer(ctx, TransferInput{FromAddress: alice, ToAddress: carol, Amount: amount})
+			require.NoError(t, err)
+		}
+
+		for i, amount := range []int64{10, 20} {
+			transfer := <-received
+			require.Equal(t, strconv.Itoa(i+2), transfer.Id)
+			require.Equal(t, amount, transfer.Amount)
+		}
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	t.Run("stops when handle fails", func(t *testing.T) {
+		polls := s.ledger.pollCount()
+		done := make(chan error)
+		errStop := errors.New("stop")
+		go func() {
+			done <- s.newClient(JWTSigner{Secret: jwtSecret, Subject: "script", Role: "VIEWER"}).
+				WatchTransfers(context.Background(), alice, func(Transfer) error { return errStop })
+		}()
+
+		require.Eventually(t, func() bool { return s.ledger.pollCount() >= polls+2 }, time.Second, time.Millisecond)
+		_, err := operator.Transfer(context.Background(), TransferInput{FromAddress: alice, ToAddress: carol, Amount: 10})
+		require.NoError(t, err)
+		require.ErrorIs(t, <-done, errStop)
+	})
+
+	t.Run("requires credentials", func(t *testing.T) {
+		err := s.newClient(nil).WatchTransfers(context.Background(), alice, func(Transfer) error { return nil })
+		require.Equal(t, apperror.CodeUnauthenticated, ErrorCode(err))
+	})
+
+	t.Run("rejects invalid credentials", func(t *testing.T) {
+		err := s.newClient(APIKey("unknown-key")).WatchTransfers(context.Background(), alice, func(Transfer) error { return nil })
+		require.ErrorContains(t, err, "401 Unauthorized")
+	})
+}
+
+func TestWebSocketURL(t *testing.T) {
+	require.Equal(t, "wss://tokens.example.com/query", webSocketURL("https://tokens.example.com/query"))
+	require.Equal(t, "ws://localhost:8080/query", webSocketURL("http://localhost:8080/query"))
+}
@@ -0,0 +1,529 @@
+// Code generated by github.com/Khan/genqlient, DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// Transfer includes the GraphQL fields of Transfer requested by the fragment Transfer.
+// The GraphQL type's documentation follows.
+//
+// A ledger entry recording a single movement of tokens between wallets
+type Transfer struct {
+	Id          string    `json:"id"`
+	FromAddress string    `json:"fromAddress"`
+	ToAddress   string    `json:"toAddress"`
+	Amount      int64     `json:"amount"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Note attached by the sender
+	Memo *string `json:"memo"`
+	// JSON object attached by the sender
+	Metadata map[string]any `json:"metadata"`
+	// Reason given for the reversal, if this transfer is a reversal
+	Reason *string `json:"reason"`
+}
+
+// GetId returns Transfer.Id, and is useful for accessing the field via an interface.
+func (v *Transfer) GetId() string { return v.Id }
+
+// GetFromAddress returns Transfer.FromAddress, and is useful for accessing the field via an interface.
+func (v *Transfer) GetFromAddress() string { return v.FromAddress }
+
+// GetToAddress returns Transfer.ToAddress, and is useful for accessing the field via an interface.
+func (v *Transfer) GetToAddress() string { return v.ToAddress }
+
+// GetAmount returns Transfer.Amount, and is useful for accessing the field via an interface.
+func (v *Transfer) GetAmount() int64 { return v.Amount }
+
+// GetCreatedAt returns Transfer.CreatedAt, and is useful for accessing the field via an interface.
+func (v *Transfer) GetCreatedAt() time.Time { return v.CreatedAt }
+
+// GetMemo returns Transfer.Memo, and is useful for accessing the field via an interface.
+func (v *Transfer) GetMemo() *string { return v.Memo }
+
+// GetMetadata returns Transfer.Metadata, and is useful for accessing the field via an interface.
+func (v *Transfer) GetMetadata() map[string]any { return v.Metadata }
+
+// GetReason returns Transfer.Reason, and is useful for accessing the field via an interface.
+func (v *Transfer) GetReason() *string { return v.Reason }
+
+type TransferFilter struct {
+	// Only transfers whose metadata has this key
+	MetadataKey *string `json:"metadataKey"`
+	// Only transfers whose metadata has `metadataKey` set to this string
+	MetadataValue *string `json:"metadataValue"`
+}
+
+// GetMetadataKey returns TransferFilter.MetadataKey, and is useful for accessing the field via an interface.
+func (v *TransferFilter) GetMetadataKey() *string { return v.MetadataKey }
+
+// GetMetadataValue returns TransferFilter.MetadataValue, and is useful for accessing the field via an interface.
+func (v *TransferFilter) GetMetadataValue() *string { return v.MetadataValue }
+
+// TransferResult includes the GraphQL fields of TransferResult requested by the fragment TransferResult.
+// The GraphQL type's documentation follows.
+//
+// Result of a transfer
+type TransferResult struct {
+	// The ledger entry of the transfer
+	Transfer Transfer `json:"transfer"`
+	// The sender's wallet after the transfer
+	FromWallet Wallet `json:"fromWallet"`
+	// The recipient's wallet after the transfer
+	ToWallet Wallet `json:"toWallet"`
+	// Whether the recipient's wallet was created by the transfer
+	RecipientCreated bool `json:"recipientCreated"`
+}
+
+// GetTransfer returns TransferResult.Transfer, and is useful for accessing the field via an interface.
+func (v *TransferResult) GetTransfer() Transfer { return v.Transfer }
+
+// GetFromWallet returns TransferResult.FromWallet, and is useful for accessing the field via an interface.
+func (v *TransferResult) GetFromWallet() Wallet { return v.FromWallet }
+
+// GetToWallet returns TransferResult.ToWallet, and is useful for accessing the field via an interface.
+func (v *TransferResult) GetToWallet() Wallet { return v.ToWallet }
+
+// GetRecipientCreated returns TransferResult.RecipientCreated, and is useful for accessing the field via an interface.
+func (v *TransferResult) GetRecipientCreated() bool { return v.RecipientCreated }
+
+// Wallet includes the GraphQL fields of Wallet requested by the fragment Wallet.
+type Wallet struct {
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Total balance, including reserved tokens
+	Tokens int64 `json:"tokens"`
+	// Tokens that can be transferred or held
+	Available int64 `json:"available"`
+	// Tokens reserved by active holds
+	Reserved int64        `json:"reserved"`
+	Status   WalletStatus `json:"status"`
+	// Reason given for the last status change
+	StatusReason *string `json:"statusReason"`
+	// Time of the latest transfer sent or received by the wallet
+	LastActivityAt *time.Time `json:"lastActivityAt"`
+	// Number of transfers received by the wallet
+	IncomingTransfers int `json:"incomingTransfers"`
+	// Number of transfers sent by the wallet
+	OutgoingTransfers int `json:"outgoingTransfers"`
+	// Total amount of tokens received by the wallet
+	TotalReceived int64 `json:"totalReceived"`
+	// Total amount of tokens sent by the wallet
+	TotalSent int64 `json:"totalSent"`
+}
+
+// GetAddress returns Wallet.Address, and is useful for accessing the field via an interface.
+func (v *Wallet) GetAddress() string { return v.Address }
+
+// GetCreatedAt returns Wallet.CreatedAt, and is useful for accessing the field via an interface.
+func (v *Wallet) GetCreatedAt() time.Time { return v.CreatedAt }
+
+// GetUpdatedAt returns Wallet.UpdatedAt, and is useful for accessing the field via an interface.
+func (v *Wallet) GetUpdatedAt() time.Time { return v.UpdatedAt }
+
+// GetTokens returns Wallet.Tokens, and is useful for accessing the field via an interface.
+func (v *Wallet) GetTokens() int64 { return v.Tokens }
+
+// GetAvailable returns Wallet.Available, and is useful for accessing the field via an interface.
+func (v *Wallet) GetAvailable() int64 { return v.Available }
+
+// GetReserved returns Wallet.Reserved, and is useful for accessing the field via an interface.
+func (v *Wallet) GetReserved() int64 { return v.Reserved }
+
+// GetStatus returns Wallet.Status, and is useful for accessing the field via an interface.
+func (v *Wallet) GetStatus() WalletStatus { return v.Status }
+
+// GetStatusReason returns Wallet.StatusReason, and is useful for accessing the field via an interface.
+func (v *Wallet) GetStatusReason() *string { return v.StatusReason }
+
+// GetLastActivityAt returns Wallet.LastActivityAt, and is useful for accessing the field via an interface.
+func (v *Wallet) GetLastActivityAt() *time.Time { return v.LastActivityAt }
+
+// GetIncomingTransfers returns Wallet.IncomingTransfers, and is useful for accessing the field via an interface.
+func (v *Wallet) GetIncomingTransfers() int { return v.IncomingTransfers }
+
+// GetOutgoingTransfers returns Wallet.OutgoingTransfers, and is useful for accessing the field via an interface.
+func (v *Wallet) GetOutgoingTransfers() int { return v.OutgoingTransfers }
+
+// GetTotalReceived returns Wallet.TotalReceived, and is useful for accessing the field via an interface.
+func (v *Wallet) GetTotalReceived() int64 { return v.TotalReceived }
+
+// GetTotalSent returns Wallet.TotalSent, and is useful for accessing the field via an interface.
+func (v *Wallet) GetTotalSent() int64 { return v.TotalSent }
+
+type WalletStatus string
+
+const (
+	// The wallet can send and receive tokens
+	WalletStatusActive WalletStatus = "ACTIVE"
+	// The wallet can't send tokens, and may be prevented from receiving them
+	WalletStatusFrozen WalletStatus = "FROZEN"
+	// The wallet can neither send nor receive tokens
+	WalletStatusClosed WalletStatus = "CLOSED"
+)
+
+var AllWalletStatus = []WalletStatus{
+	WalletStatusActive,
+	WalletStatusFrozen,
+	WalletStatusClosed,
+}
+
+// __getTransfersInput is used internally by genqlient
+type __getTransfersInput struct {
+	Address string          `json:"address"`
+	Filter  *TransferFilter `json:"filter,omitempty"`
+	Limit   int             `json:"limit"`
+	Before  *string         `json:"before,omitempty"`
+}
+
+// GetAddress returns __getTransfersInput.Address, and is useful for accessing the field via an interface.
+func (v *__getTransfersInput) GetAddress() string { return v.Address }
+
+// GetFilter returns __getTransfersInput.Filter, and is useful for accessing the field via an interface.
+func (v *__getTransfersInput) GetFilter() *TransferFilter { return v.Filter }
+
+// GetLimit returns __getTransfersInput.Limit, and is useful for accessing the field via an interface.
+func (v *__getTransfersInput) GetLimit() int { return v.Limit }
+
+// GetBefore returns __getTransfersInput.Before, and is useful for accessing the field via an interface.
+func (v *__getTransfersInput) GetBefore() *string { return v.Before }
+
+// __getWalletInput is used internally by genqlient
+type __getWalletInput struct {
+	Address string `json:"address"`
+}
+
+// GetAddress returns __getWalletInput.Address, and is useful for accessing the field via an interface.
+func (v *__getWalletInput) GetAddress() string { return v.Address }
+
+// __transferTokensInput is used internally by genqlient
+type __transferTokensInput struct {
+	FromAddress    string         `json:"fromAddress"`
+	ToAddress      string         `json:"toAddress"`
+	Amount         int64          `json:"amount"`
+	Memo           *string        `json:"memo,omitempty"`
+	Metadata       map[string]any `json:"metadata,omitempty"`
+	IdempotencyKey string         `json:"idempotencyKey"`
+}
+
+// GetFromAddress returns __transferTokensInput.FromAddress, and is useful for accessing the field via an interface.
+func (v *__transferTokensInput) GetFromAddress() string { return v.FromAddress }
+
+// GetToAddress returns __transferTokensInput.ToAddress, and is useful for accessing the field via an interface.
+func (v *__transferTokensInput) GetToAddress() string { return v.ToAddress }
+
+// GetAmount returns __transferTokensInput.Amount, and is useful for accessing the field via an interface.
+func (v *__transferTokensInput) GetAmount() int64 { return v.Amount }
+
+// GetMemo returns __transferTokensInput.Memo, and is useful for accessing the field via an interface.
+func (v *__transferTokensInput) GetMemo() *string { return v.Memo }
+
+// GetMetadata returns __transferTokensInput.Metadata, and is useful for accessing the field via an interface.
+func (v *__transferTokensInput) GetMetadata() map[string]any { return v.Metadata }
+
+// GetIdempotencyKey returns __transferTokensInput.IdempotencyKey, and is useful for accessing the field via an interface.
+func (v *__transferTokensInput) GetIdempotencyKey() string { return v.IdempotencyKey }
+
+// __walletTransfersInput is used internally by genqlient
+type __walletTransfersInput struct {
+	Address string `json:"address"`
+}
+
+// GetAddress returns __walletTransfersInput.Address, and is useful for accessing the field via an interface.
+func (v *__walletTransfersInput) GetAddress() string { return v.Address }
+
+// getTransfersResponse is returned by getTransfers on success.
+type getTransfersResponse struct {
+	// Lists transfers sent or received by the wallet with the specified address, newest
+	// first. Only transfers older than the transfer with id `before` are listed, if it is set.
+	Transfers []Transfer `json:"transfers"`
+}
+
+// GetTransfers returns getTransfersResponse.Transfers, and is useful for accessing the field via an interface.
+func (v *getTransfersResponse) GetTransfers() []Transfer { return v.Transfers }
+
+// getWalletResponse is returned by getWallet on success.
+type getWalletResponse struct {
+	// Fetches the wallet with the specified address, or null if it does not exist
+	Wallet *Wallet `json:"wallet"`
+}
+
+// GetWallet returns getWalletResponse.Wallet, and is useful for accessing the field via an interface.
+func (v *getWalletResponse) GetWallet() *Wallet { return v.Wallet }
+
+// transferTokensResponse is returned by transferTokens on success.
+type transferTokensResponse struct {
+	// Concurrent-safe mutation that transfers `amount` tokens from wallet
+	// with `from_address` address to wallet with `to_address` address.
+	// Creates the second wallet if it does not exist. The optional `memo` and `metadata`
+	// are stored with the transfer.
+	// If `idempotency_key` is set and the sender already made a transfer with the same key,
+	// that transfer is returned instead of making a new one, so that the mutation can be
+	// retried safely. The wallets of such a result are the current ones, not the ones right
+	// after the transfer. Keys are scoped to the sender and should be unique, e.g. random UUIDs.
+	TransferTokens TransferResult `json:"transferTokens"`
+}
+
+// GetTransferTokens returns transferTokensResponse.TransferTokens, and is useful for accessing the field via an interface.
+func (v *transferTokensResponse) GetTransferTokens() TransferResult { return v.TransferTokens }
+
+// walletTransfersResponse is returned by walletTransfers on success.
+type walletTransfersResponse struct {
+	// Streams transfers sent or received by the wallet with the specified address, oldest
+	// first, as they are made. Transfers made before subscribing are not included.
+	WalletTransfers Transfer `json:"walletTransfers"`
+}
+
+// GetWalletTransfers returns walletTransfersResponse.WalletTransfers, and is useful for accessing the field via an interface.
+func (v *walletTransfersResponse) GetWalletTransfers() Transfer { return v.WalletTransfers }
+
+// The query executed by getTransfers.
+const getTransfers_Operation = `
+query getTransfers ($address: Address!, $filter: TransferFilter, $limit: Int!, $before: ID) {
+	transfers(address: $address, filter: $filter, limit: $limit, before: $before) {
+		... Transfer
+	}
+}
+fragment Transfer on Transfer {
+	id
+	fromAddress
+	toAddress
+	amount
+	createdAt
+	memo
+	metadata
+	reason
+}
+`
+
+func getTransfers(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	address string,
+	filter *TransferFilter,
+	limit int,
+	before *string,
+) (data_ *getTransfersResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "getTransfers",
+		Query:  getTransfers_Operation,
+		Variables: &__getTransfersInput{
+			Address: address,
+			Filter:  filter,
+			Limit:   limit,
+			Before:  before,
+		},
+	}
+
+	data_ = &getTransfersResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
+// The query executed by getWallet.
+const getWallet_Operation = `
+query getWallet ($address: Address!) {
+	wallet(address: $address) {
+		... Wallet
+	}
+}
+fragment Wallet on Wallet {
+	address
+	createdAt
+	updatedAt
+	tokens
+	available
+	reserved
+	status
+	statusReason
+	lastActivityAt
+	incomingTransfers
+	outgoingTransfers
+	totalReceived
+	totalSent
+}
+`
+
+func getWallet(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	address string,
+) (data_ *getWalletResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "getWallet",
+		Query:  getWallet_Operation,
+		Variables: &__getWalletInput{
+			Address: address,
+		},
+	}
+
+	data_ = &getWalletResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
+// The mutation executed by transferTokens.
+const transferTokens_Operation = `
+mutation transferTokens ($fromAddress: Address!, $toAddress: Address!, $amount: Int64!, $memo: String, $metadata: JSON, $idempotencyKey: String!) {
+	transferTokens(from_address: $fromAddress, to_address: $toAddress, amount: $amount, memo: $memo, metadata: $metadata, idempotency_key: $idempotencyKey) {
+		... TransferResult
+	}
+}
+fragment TransferResult on TransferResult {
+	transfer {
+		... Transfer
+	}
+	fromWallet {
+		... Wallet
+	}
+	toWallet {
+		... Wallet
+	}
+	recipientCreated
+}
+fragment Transfer on Transfer {
+	id
+	fromAddress
+	toAddress
+	amount
+	createdAt
+	memo
+	metadata
+	reason
+}
+fragment Wallet on Wallet {
+	address
+	createdAt
+	updatedAt
+	tokens
+	available
+	reserved
+	status
+	statusReason
+	lastActivityAt
+	incomingTransfers
+	outgoingTransfers
+	totalReceived
+	totalSent
+}
+`
+
+func transferTokens(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	fromAddress string,
+	toAddress string,
+	amount int64,
+	memo *string,
+	metadata map[string]any,
+	idempotencyKey string,
+) (data_ *transferTokensResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "transferTokens",
+		Query:  transferTokens_Operation,
+		Variables: &__transferTokensInput{
+			FromAddress:    fromAddress,
+			ToAddress:      toAddress,
+			Amount:         amount,
+			Memo:           memo,
+			Metadata:       metadata,
+			IdempotencyKey: idempotencyKey,
+		},
+	}
+
+	data_ = &transferTokensResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
+// The subscription executed by walletTransfers.
+const walletTransfers_Operation = `
+subscription walletTransfers ($address: Address!) {
+	walletTransfers(address: $address) {
+		... Transfer
+	}
+}
+fragment Transfer on Transfer {
+	id
+	fromAddress
+	toAddress
+	amount
+	createdAt
+	memo
+	metadata
+	reason
+}
+`
+
+// To unsubscribe, use [graphql.WebSocketClient.Unsubscribe]
+func walletTransfers(
+	ctx_ context.Context,
+	client_ graphql.WebSocketClient,
+	address string,
+) (dataChan_ chan walletTransfersWsResponse, subscriptionID_ string, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "walletTransfers",
+		Query:  walletTransfers_Operation,
+		Variables: &__walletTransfersInput{
+			Address: address,
+		},
+	}
+
+	dataChan_ = make(chan walletTransfersWsResponse)
+	subscriptionID_, err_ = client_.Subscribe(req_, dataChan_, walletTransfersForwardData)
+
+	return dataChan_, subscriptionID_, err_
+}
+
+type walletTransfersWsResponse graphql.BaseResponse[*walletTransfersResponse]
+
+func walletTransfersForwardData(interfaceChan interface{}, jsonRawMsg json.RawMessage) error {
+	var gqlResp graphql.Response
+	var wsResp walletTransfersWsResponse
+	err := json.Unmarshal(jsonRawMsg, &gqlResp)
+	if err != nil {
+		return err
+	}
+	if len(gqlResp.Errors) == 0 {
+		err = json.Unmarshal(jsonRawMsg, &wsResp)
+		if err != nil {
+			return err
+		}
+	} else {
+		wsResp.Errors = gqlResp.Errors
+	}
+	dataChan_, ok := interfaceChan.(chan walletTransfersWsResponse)
+	if !ok {
+		return errors.New("failed to cast interface into 'chan walletTransfersWsResponse'")
+	}
+	dataChan_ <- wsResp
+	return nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// retryingClient retries requests which failed because of the network, an error of the
+// server or the rate limit, with exponential backoff. Other errors, like those of the
+// business rules, would fail again and are returned right away.
+type retryingClient struct {
+	graphql       graphql.Client
+	maxRetries    int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+}
+
+func (r *retryingClient) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	delay := r.retryDelay
+	for attempt := 0; ; attempt++ {
+		resp.Errors, resp.Extensions = nil, nil
+		err := r.graphql.MakeRequest(ctx, req, resp)
+		if err == nil || attempt == r.maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		wait, ok := r.waitBeforeRetry(err, delay)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(2*delay, r.maxRetryDelay)
+	}
+}
+
+// waitBeforeRetry returns how long to wait before retrying a request which failed with
+// err, or false if it shouldn't be retried.
+func (r *retryingClient) waitBeforeRetry(err error, delay time.Duration) (time.Duration, bool) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return delay, true
+	}
+
+	var httpErr *graphql.HTTPError
+	if errors.As(err, &httpErr) {
+		retry := httpErr.StatusCode >= http.StatusInternalServerError || httpErr.StatusCode == http.StatusTooManyRequests
+		return delay, retry
+	}
+
+	var errs gqlerror.List
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if e.Extensions["code"] != string(apperror.CodeRateLimited) {
+				continue
+			}
+			seconds, _ := e.Extensions["retryAfter"].(float64)
+			wait := max(delay, time.Duration(seconds*float64(time.Second)))
+			return wait, wait <= r.maxRetryDelay
+		}
+	}
+	return 0, false
+}
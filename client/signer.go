@@ -0,0 +1,89 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+)
+
+// How long tokens of a JWTSigner are valid by default.
+const DefaultTokenTTL = time.Minute
+
+// Signer adds credentials to the requests sent by Client, including the requests
+// opening websocket connections of subscriptions.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// APIKey signs requests with an API key, sent in the X-API-Key header.
+type APIKey string
+
+func (k APIKey) Sign(req *http.Request) error {
+	req.Header.Set(auth.APIKeyHeader, string(k))
+	return nil
+}
+
+// JWTSigner signs every request with a new short-lived JSON Web Token signed with a
+// shared secret (HS256), so that a leaked token can't be used for long.
+type JWTSigner struct {
+	Secret  []byte
+	Subject string
+	Role    string
+	// "iss" and "aud" claims; omitted when empty.
+	Issuer   string
+	Audience string
+	// How long a token is valid; DefaultTokenTTL if 0.
+	TTL time.Duration
+}
+
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role"`
+}
+
+func (s JWTSigner) Sign(req *http.Request) error {
+	ttl := s.TTL
+	if ttl == 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	now := time.Now()
+	c := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        NewIdempotencyKey(),
+			Subject:   s.Subject,
+			Issuer:    s.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Role: s.Role,
+	}
+	if s.Audience != "" {
+		c.Audience = jwt.ClaimStrings{s.Audience}
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(s.Secret)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// signingDoer sends requests signed by signer, if it is set.
+type signingDoer struct {
+	httpClient *http.Client
+	signer     Signer
+}
+
+func (d signingDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.signer != nil {
+		if err := d.signer.Sign(req); err != nil {
+			return nil, fmt.Errorf("signing request failed: %w", err)
+		}
+	}
+	return d.httpClient.Do(req)
+}
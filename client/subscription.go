@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/gorilla/websocket"
+)
+
+// ErrSubscriptionEnded is returned by Client.WatchTransfers when the server ends the
+// subscription.
+var ErrSubscriptionEnded = errors.New("subscription ended by the server")
+
+// WatchTransfers calls handle with every transfer sent or received by the wallet after
+// the subscription starts, oldest first, until ctx is done or handle returns an error.
+// It returns the error that stopped watching, e.g. ctx's error. Transfers are received
+// over a websocket connection, which isn't retried when it breaks.
+func (c *Client) WatchTransfers(ctx context.Context, address string, handle func(Transfer) error) error {
+	header, err := c.webSocketHeader(ctx)
+	if err != nil {
+		return err
+	}
+
+	dialer := &webSocketDialer{}
+	ws := graphql.NewClientUsingWebSocket(webSocketURL(c.cfg.Endpoint), dialer, graphql.WithWebsocketHeader(header))
+	errs, err := ws.Start(ctx)
+	if err != nil {
+		return err
+	}
+
+	transfers, _, err := walletTransfers(ctx, ws, address)
+	if err != nil {
+		closeWebSocket(dialer.conn, nil, errs)
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			closeWebSocket(dialer.conn, transfers, errs)
+			return ctx.Err()
+		case err := <-errs:
+			_ = dialer.conn.Close()
+			return err
+		case resp, ok := <-transfers:
+			if !ok {
+				closeWebSocket(dialer.conn, nil, errs)
+				return ErrSubscriptionEnded
+			}
+			if len(resp.Errors) > 0 {
+				closeWebSocket(dialer.conn, transfers, errs)
+				return resp.Errors
+			}
+			if err := handle(resp.Data.WalletTransfers); err != nil {
+				closeWebSocket(dialer.conn, transfers, errs)
+				return err
+			}
+		}
+	}
+}
+
+// webSocketHeader returns the header of the request opening the websocket connection,
+// with the credentials of the signer.
+func (c *Client) webSocketHeader(ctx context.Context) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.Endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.cfg.Signer != nil {
+		if err := c.cfg.Signer.Sign(req); err != nil {
+			return nil, fmt.Errorf("signing request failed: %w", err)
+		}
+	}
+	return req.Header, nil
+}
+
+// closeWebSocket closes the connection and waits until genqlient stops listening on it,
+// which it does after reporting the failed read on errs. Messages it is still trying to
+// deliver on transfers are dropped. Closing the client of genqlient instead would race
+// with its listener.
+func closeWebSocket(conn *websocket.Conn, transfers chan walletTransfersWsResponse, errs chan error) {
+	_ = conn.Close()
+	for {
+		select {
+		case _, ok := <-transfers:
+			if !ok {
+				transfers = nil
+			}
+		case <-errs:
+			return
+		}
+	}
+}
+
+func webSocketURL(endpoint string) string {
+	if rest, ok := strings.CutPrefix(endpoint, "https://"); ok {
+		return "wss://" + rest
+	}
+	if rest, ok := strings.CutPrefix(endpoint, "http://"); ok {
+		return "ws://" + rest
+	}
+	return endpoint
+}
+
+// webSocketDialer opens websocket connections for genqlient and keeps the last one, so
+// that it can be closed.
+type webSocketDialer struct {
+	conn *websocket.Conn
+}
+
+func (d *webSocketDialer) DialContext(ctx context.Context, url string, header http.Header) (graphql.WSConn, error) {
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("%w: %s", err, resp.Status)
+		}
+		return nil, err
+	}
+	d.conn = conn
+	return conn, nil
+}
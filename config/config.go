@@ -0,0 +1,413 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/auth"
+)
+
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
+type Config struct {
+	Port        string
+	Environment string
+	LogLevel    slog.Level
+	Database    DatabaseConfig
+	GraphQL     GraphQLConfig
+	RateLimit   RateLimitConfig
+	Wallets     WalletsConfig
+	Auth        AuthConfig
+	Outbox      OutboxConfig
+	Webhooks    WebhooksConfig
+	GRPC        GRPCConfig
+}
+
+type DatabaseConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+}
+
+// DSN returns the connection string understood by the Postgres driver.
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		d.Host, d.User, d.Password, d.Name, d.Port)
+}
+
+type GraphQLConfig struct {
+	// Maximum complexity of a single operation, as computed by graph.NewComplexityRoot.
+	ComplexityLimit int
+	// Maximum nesting of selection sets in a single operation.
+	MaxDepth int
+	// Whether the schema can be introspected by clients.
+	Introspection bool
+	// Whether the GraphQL playground is served at /.
+	Playground bool
+	// How often subscriptions check for new transfers.
+	SubscriptionInterval time.Duration
+}
+
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+type RateLimitConfig struct {
+	Enabled bool
+	// Where token buckets are kept: RateLimitStoreMemory or RateLimitStorePostgres.
+	Store string
+	// Transfers per second allowed for a single caller, and the size of its bucket.
+	ClientRate  float64
+	ClientBurst int
+	// Transfers per second allowed from a single wallet, and the size of its bucket.
+	WalletRate  float64
+	WalletBurst int
+}
+
+type WalletsConfig struct {
+	// Whether frozen wallets are prevented from receiving tokens, not only from sending them.
+	BlockFrozenRecipients bool
+	// Whether transfers to addresses without a wallet are refused, instead of creating the wallet.
+	ForbidImplicitRecipientCreation bool
+	// How often holds past their expiry time are released.
+	HoldExpiryInterval time.Duration
+	// How often scheduled transfers are checked for ones that are due.
+	SchedulePollInterval time.Duration
+	// How often the balances of all wallets are recorded for historical balance queries.
+	BalanceSnapshotInterval time.Duration
+	// How often balances are reconciled with the ledger; zero disables periodic reconciliation.
+	ReconcileInterval time.Duration
+}
+
+const (
+	OutboxSinkNone   = "none"
+	OutboxSinkStdout = "stdout"
+	OutboxSinkHTTP   = "http"
+	OutboxSinkNATS   = "nats"
+	OutboxSinkKafka  = "kafka"
+)
+
+type OutboxConfig struct {
+	// Where events of transfers are delivered besides webhooks; OutboxSinkNone delivers them
+	// to webhooks only.
+	Sink string
+	// URL events are posted to by the HTTP sink.
+	HTTPURL string
+	// Server of the NATS sink, and the prefix of the subjects events are published on.
+	NATSURL     string
+	NATSSubject string
+	// Brokers of the Kafka sink, and the topic events are written to.
+	KafkaBrokers []string
+	KafkaTopic   string
+	// How often the outbox is checked for events to deliver.
+	RelayInterval time.Duration
+}
+
+type WebhooksConfig struct {
+	// How often webhook deliveries are checked for ones that are due.
+	PollInterval time.Duration
+	// Number of attempts after which a webhook delivery fails for good.
+	MaxAttempts int
+	// Whether webhooks may be delivered to loopback, private and link-local addresses.
+	AllowPrivateAddresses bool
+}
+
+type GRPCConfig struct {
+	// Port of the gRPC server, which has to differ from the port of the HTTP server.
+	Port string
+	// How often wallets watched with WatchWallet are checked for changes.
+	WatchInterval time.Duration
+}
+
+type AuthConfig struct {
+	// File with API keys, one "<key> <subject> <role>" per line; empty disables API keys.
+	APIKeysFile string
+	// File with the secret of HS256-signed JWTs; empty disables HS256.
+	JWTSecretFile string
+	// JSON Web Key Set with the public keys of RS256-signed JWTs; empty disables RS256.
+	JWKSFile string
+	// Required issuer and audience of JWTs; not checked when empty.
+	JWTIssuer   string
+	JWTAudience string
+	// Role of callers that send no credentials; empty means no role at all.
+	AnonymousRole auth.Role
+}
+
+// Load reads the configuration from environment variables, falling back to defaults.
+func Load() (*Config, error) {
+	var err error
+	c := &Config{
+		Port:        envString("PORT", "8080"),
+		Environment: envString("APP_ENV", EnvironmentDevelopment),
+	}
+
+	if c.Environment != EnvironmentDevelopment && c.Environment != EnvironmentProduction {
+		return nil, fmt.Errorf("APP_ENV: unknown environment %q", c.Environment)
+	}
+	isDevelopment := c.Environment == EnvironmentDevelopment
+
+	if levelText := os.Getenv("LOG_LEVEL"); levelText != "" {
+		err = c.LogLevel.UnmarshalText([]byte(levelText))
+		if err != nil {
+			return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+
+	c.Database = DatabaseConfig{
+		Host: envString("POSTGRES_HOST", "db"),
+		Port: envString("POSTGRES_DB_PORT", "5432"),
+		User: os.Getenv("POSTGRES_USER"),
+		Name: os.Getenv("POSTGRES_DB"),
+	}
+	passwordFile, err := os.ReadFile(os.Getenv("POSTGRES_PASSWORD_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("POSTGRES_PASSWORD_FILE: %w", err)
+	}
+	c.Database.Password = strings.TrimSpace(string(passwordFile))
+
+	c.GraphQL.ComplexityLimit, err = envInt("GRAPHQL_COMPLEXITY_LIMIT", 200)
+	if err != nil {
+		return nil, err
+	}
+	c.GraphQL.MaxDepth, err = envInt("GRAPHQL_MAX_DEPTH", 10)
+	if err != nil {
+		return nil, err
+	}
+	c.GraphQL.Introspection, err = envBool("GRAPHQL_INTROSPECTION", isDevelopment)
+	if err != nil {
+		return nil, err
+	}
+	c.GraphQL.Playground, err = envBool("GRAPHQL_PLAYGROUND", isDevelopment)
+	if err != nil {
+		return nil, err
+	}
+	c.GraphQL.SubscriptionInterval, err = envDuration("GRAPHQL_SUBSCRIPTION_INTERVAL", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if c.GraphQL.SubscriptionInterval <= 0 {
+		return nil, fmt.Errorf("GRAPHQL_SUBSCRIPTION_INTERVAL: must be positive")
+	}
+
+	c.RateLimit.Enabled, err = envBool("RATE_LIMIT_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	c.RateLimit.Store = envString("RATE_LIMIT_STORE", RateLimitStoreMemory)
+	if c.RateLimit.Store != RateLimitStoreMemory && c.RateLimit.Store != RateLimitStorePostgres {
+		return nil, fmt.Errorf("RATE_LIMIT_STORE: unknown store %q", c.RateLimit.Store)
+	}
+	c.RateLimit.ClientRate, err = envFloat("RATE_LIMIT_CLIENT_RATE", 5)
+	if err != nil {
+		return nil, err
+	}
+	if c.RateLimit.ClientRate <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_CLIENT_RATE: must be positive")
+	}
+	c.RateLimit.ClientBurst, err = envInt("RATE_LIMIT_CLIENT_BURST", 20)
+	if err != nil {
+		return nil, err
+	}
+	if c.RateLimit.ClientBurst <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_CLIENT_BURST: must be positive")
+	}
+	c.RateLimit.WalletRate, err = envFloat("RATE_LIMIT_WALLET_RATE", 2)
+	if err != nil {
+		return nil, err
+	}
+	if c.RateLimit.WalletRate <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_WALLET_RATE: must be positive")
+	}
+	c.RateLimit.WalletBurst, err = envInt("RATE_LIMIT_WALLET_BURST", 10)
+	if err != nil {
+		return nil, err
+	}
+	if c.RateLimit.WalletBurst <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_WALLET_BURST: must be positive")
+	}
+
+	c.Wallets.BlockFrozenRecipients, err = envBool("BLOCK_FROZEN_RECIPIENTS", false)
+	if err != nil {
+		return nil, err
+	}
+	c.Wallets.ForbidImplicitRecipientCreation, err = envBool("FORBID_IMPLICIT_RECIPIENT_CREATION", false)
+	if err != nil {
+		return nil, err
+	}
+	c.Wallets.HoldExpiryInterval, err = envDuration("HOLD_EXPIRY_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if c.Wallets.HoldExpiryInterval <= 0 {
+		return nil, fmt.Errorf("HOLD_EXPIRY_INTERVAL: must be positive")
+	}
+	c.Wallets.SchedulePollInterval, err = envDuration("SCHEDULE_POLL_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if c.Wallets.SchedulePollInterval <= 0 {
+		return nil, fmt.Errorf("SCHEDULE_POLL_INTERVAL: must be positive")
+	}
+	c.Wallets.BalanceSnapshotInterval, err = envDuration("BALANCE_SNAPSHOT_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if c.Wallets.BalanceSnapshotInterval <= 0 {
+		return nil, fmt.Errorf("BALANCE_SNAPSHOT_INTERVAL: must be positive")
+	}
+	c.Wallets.ReconcileInterval, err = envDuration("RECONCILE_INTERVAL", 0)
+	if err != nil {
+		return nil, err
+	}
+	if c.Wallets.ReconcileInterval < 0 {
+		return nil, fmt.Errorf("RECONCILE_INTERVAL: must not be negative")
+	}
+
+	c.Outbox = OutboxConfig{
+		Sink:        envString("OUTBOX_SINK", OutboxSinkNone),
+		HTTPURL:     os.Getenv("OUTBOX_HTTP_URL"),
+		NATSURL:     os.Getenv("OUTBOX_NATS_URL"),
+		NATSSubject: envString("OUTBOX_NATS_SUBJECT", "tokens"),
+		KafkaTopic:  envString("OUTBOX_KAFKA_TOPIC", "token-transfers"),
+	}
+	if brokers := os.Getenv("OUTBOX_KAFKA_BROKERS"); brokers != "" {
+		c.Outbox.KafkaBrokers = strings.Split(brokers, ",")
+	}
+	switch c.Outbox.Sink {
+	case OutboxSinkNone, OutboxSinkStdout:
+	case OutboxSinkHTTP:
+		if c.Outbox.HTTPURL == "" {
+			return nil, fmt.Errorf("OUTBOX_HTTP_URL: required by the %s sink", c.Outbox.Sink)
+		}
+	case OutboxSinkNATS:
+		if c.Outbox.NATSURL == "" {
+			return nil, fmt.Errorf("OUTBOX_NATS_URL: required by the %s sink", c.Outbox.Sink)
+		}
+	case OutboxSinkKafka:
+		if len(c.Outbox.KafkaBrokers) == 0 {
+			return nil, fmt.Errorf("OUTBOX_KAFKA_BROKERS: required by the %s sink", c.Outbox.Sink)
+		}
+	default:
+		return nil, fmt.Errorf("OUTBOX_SINK: unknown sink %q", c.Outbox.Sink)
+	}
+	c.Outbox.RelayInterval, err = envDuration("OUTBOX_RELAY_INTERVAL", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if c.Outbox.RelayInterval <= 0 {
+		return nil, fmt.Errorf("OUTBOX_RELAY_INTERVAL: must be positive")
+	}
+
+	c.Webhooks.PollInterval, err = envDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if c.Webhooks.PollInterval <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_POLL_INTERVAL: must be positive")
+	}
+	c.Webhooks.MaxAttempts, err = envInt("WEBHOOK_MAX_ATTEMPTS", 10)
+	if err != nil {
+		return nil, err
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS: must be positive")
+	}
+	c.Webhooks.AllowPrivateAddresses, err = envBool("WEBHOOK_ALLOW_PRIVATE_ADDRESSES", false)
+	if err != nil {
+		return nil, err
+	}
+
+	c.GRPC.Port = envString("GRPC_PORT", "9090")
+	if c.GRPC.Port == c.Port {
+		return nil, fmt.Errorf("GRPC_PORT: must differ from PORT")
+	}
+	c.GRPC.WatchInterval, err = envDuration("GRPC_WATCH_INTERVAL", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if c.GRPC.WatchInterval <= 0 {
+		return nil, fmt.Errorf("GRPC_WATCH_INTERVAL: must be positive")
+	}
+
+	c.Auth = AuthConfig{
+		APIKeysFile:   os.Getenv("AUTH_API_KEYS_FILE"),
+		JWTSecretFile: os.Getenv("AUTH_JWT_SECRET_FILE"),
+		JWKSFile:      os.Getenv("AUTH_JWKS_FILE"),
+		JWTIssuer:     os.Getenv("AUTH_JWT_ISSUER"),
+		JWTAudience:   os.Getenv("AUTH_JWT_AUDIENCE"),
+	}
+	if roleName := os.Getenv("AUTH_ANONYMOUS_ROLE"); roleName != "" {
+		c.Auth.AnonymousRole, err = auth.ParseRole(roleName)
+		if err != nil {
+			return nil, fmt.Errorf("AUTH_ANONYMOUS_ROLE: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+func envString(name string, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func envInt(name string, defaultValue int) (int, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+func envFloat(name string, defaultValue float64) (float64, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+func envBool(name string, defaultValue bool) (bool, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+func envDuration(name string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return parsed, nil
+}
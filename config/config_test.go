@@ -0,0 +1,134 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func setRequiredEnv(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password.txt")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0o600))
+
+	t.Setenv("POSTGRES_USER", "user")
+	t.Setenv("POSTGRES_DB", "tokens")
+	t.Setenv("POSTGRES_PASSWORD_FILE", passwordFile)
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	setRequiredEnv(t)
+
+	c, err := Load()
+	require.NoError(t, err)
+	require.Equal(t, "8080", c.Port)
+	require.Equal(t, EnvironmentDevelopment, c.Environment)
+	require.Equal(t, slog.LevelInfo, c.LogLevel)
+	require.Equal(t, "secret", c.Database.Password)
+	require.Equal(t, "host=db user=user password=secret dbname=tokens port=5432 sslmode=disable", c.Database.DSN())
+	require.Equal(t, 200, c.GraphQL.ComplexityLimit)
+	require.Equal(t, 10, c.GraphQL.MaxDepth)
+	require.True(t, c.GraphQL.Introspection)
+	require.True(t, c.GraphQL.Playground)
+	require.Equal(t, time.Second, c.GraphQL.SubscriptionInterval)
+	require.True(t, c.RateLimit.Enabled)
+	require.Equal(t, RateLimitStoreMemory, c.RateLimit.Store)
+	require.Equal(t, 5.0, c.RateLimit.ClientRate)
+	require.Equal(t, 20, c.RateLimit.ClientBurst)
+	require.False(t, c.Wallets.BlockFrozenRecipients)
+	require.False(t, c.Wallets.ForbidImplicitRecipientCreation)
+	require.Equal(t, 30*time.Second, c.Wallets.HoldExpiryInterval)
+	require.Equal(t, 10*time.Second, c.Wallets.SchedulePollInterval)
+	require.Equal(t, time.Hour, c.Wallets.BalanceSnapshotInterval)
+	require.Zero(t, c.Wallets.ReconcileInterval)
+	require.Equal(t, OutboxSinkNone, c.Outbox.Sink)
+	require.Equal(t, time.Second, c.Outbox.RelayInterval)
+	require.Equal(t, 5*time.Second, c.Webhooks.PollInterval)
+	require.Equal(t, 10, c.Webhooks.MaxAttempts)
+	require.False(t, c.Webhooks.AllowPrivateAddresses)
+	require.Equal(t, "9090", c.GRPC.Port)
+	require.Equal(t, time.Second, c.GRPC.WatchInterval)
+	require.Equal(t, auth.Role(""), c.Auth.AnonymousRole)
+}
+
+func TestLoad_Production_ShouldDisableIntrospectionAndPlayground(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("APP_ENV", EnvironmentProduction)
+
+	c, err := Load()
+	require.NoError(t, err)
+	require.False(t, c.GraphQL.Introspection)
+	require.False(t, c.GraphQL.Playground)
+}
+
+func TestLoad_ExplicitValues_ShouldOverrideDefaults(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("APP_ENV", EnvironmentProduction)
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("GRAPHQL_COMPLEXITY_LIMIT", "50")
+	t.Setenv("GRAPHQL_MAX_DEPTH", "4")
+	t.Setenv("GRAPHQL_INTROSPECTION", "true")
+	t.Setenv("GRAPHQL_SUBSCRIPTION_INTERVAL", "250ms")
+	t.Setenv("AUTH_ANONYMOUS_ROLE", "viewer")
+	t.Setenv("HOLD_EXPIRY_INTERVAL", "5m")
+	t.Setenv("OUTBOX_SINK", "kafka")
+	t.Setenv("OUTBOX_KAFKA_BROKERS", "kafka-1:9092,kafka-2:9092")
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_ADDRESSES", "true")
+	t.Setenv("GRPC_PORT", "50051")
+
+	c, err := Load()
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelDebug, c.LogLevel)
+	require.Equal(t, 50, c.GraphQL.ComplexityLimit)
+	require.Equal(t, 4, c.GraphQL.MaxDepth)
+	require.True(t, c.GraphQL.Introspection)
+	require.False(t, c.GraphQL.Playground)
+	require.Equal(t, 250*time.Millisecond, c.GraphQL.SubscriptionInterval)
+	require.Equal(t, auth.RoleViewer, c.Auth.AnonymousRole)
+	require.Equal(t, 5*time.Minute, c.Wallets.HoldExpiryInterval)
+	require.Equal(t, OutboxSinkKafka, c.Outbox.Sink)
+	require.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, c.Outbox.KafkaBrokers)
+	require.Equal(t, "token-transfers", c.Outbox.KafkaTopic)
+	require.Equal(t, 3, c.Webhooks.MaxAttempts)
+	require.True(t, c.Webhooks.AllowPrivateAddresses)
+	require.Equal(t, "50051", c.GRPC.Port)
+}
+
+func TestLoad_InvalidValues_ShouldReturnError(t *testing.T) {
+	cases := map[string]string{
+		"APP_ENV":                       "staging",
+		"LOG_LEVEL":                     "loud",
+		"GRAPHQL_COMPLEXITY_LIMIT":      "many",
+		"GRAPHQL_INTROSPECTION":         "maybe",
+		"GRAPHQL_SUBSCRIPTION_INTERVAL": "0s",
+		"RATE_LIMIT_STORE":              "redis",
+		"RATE_LIMIT_CLIENT_RATE":        "0",
+		"RATE_LIMIT_CLIENT_BURST":       "-1",
+		"RATE_LIMIT_WALLET_RATE":        "fast",
+		"RATE_LIMIT_WALLET_BURST":       "0",
+		"AUTH_ANONYMOUS_ROLE":           "guest",
+		"HOLD_EXPIRY_INTERVAL":          "0s",
+		"SCHEDULE_POLL_INTERVAL":        "often",
+		"OUTBOX_SINK":                   "carrier-pigeon",
+		"OUTBOX_RELAY_INTERVAL":         "-1s",
+		"WEBHOOK_POLL_INTERVAL":         "0s",
+		"WEBHOOK_MAX_ATTEMPTS":          "0",
+		"GRPC_PORT":                     "8080",
+		"GRPC_WATCH_INTERVAL":           "0s",
+	}
+
+	for name, value := range cases {
+		t.Run(name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv(name, value)
+
+			_, err := Load()
+			require.Error(t, err)
+		})
+	}
+}
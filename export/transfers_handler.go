@@ -0,0 +1,280 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/service"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Columns of CSV exports, also the keys of JSONL exports.
+var columns = []string{"id", "created_at", "from_address", "to_address", "amount", "memo", "metadata", "reversal_of_id", "reason"}
+
+// TransfersHandler streams the history of transfers as CSV or JSON Lines. It serves
+// GET requests with optional query parameters: address (only transfers of this wallet),
+// from and to (RFC 3339 times; from is inclusive, to exclusive) and format (csv or jsonl,
+// csv by default). Callers need the viewer role.
+type TransfersHandler struct {
+	WalletService service.WalletServicer
+}
+
+// transferRecord is a transfer as written to exports.
+type transferRecord struct {
+	ID           uint           `json:"id"`
+	CreatedAt    string         `json:"created_at"`
+	FromAddress  string         `json:"from_address"`
+	ToAddress    string         `json:"to_address"`
+	Amount       int            `json:"amount"`
+	Memo         *string        `json:"memo"`
+	Metadata     map[string]any `json:"metadata"`
+	ReversalOfID *uint          `json:"reversal_of_id"`
+	Reason       *string        `json:"reason"`
+}
+
+func newTransferRecord(transfer *model.Transfer) transferRecord {
+	return transferRecord{
+		ID:           transfer.ID,
+		CreatedAt:    transfer.CreatedAt.UTC().Format(time.RFC3339Nano),
+		FromAddress:  transfer.FromAddress,
+		ToAddress:    transfer.ToAddress,
+		Amount:       transfer.Amount,
+		Memo:         transfer.Memo,
+		Metadata:     transfer.Metadata,
+		ReversalOfID: transfer.ReversalOfID,
+		Reason:       transfer.Reason,
+	}
+}
+
+func (h *TransfersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "", "method not allowed")
+		return
+	}
+	if auth.FromContext(ctx) == nil {
+		writeError(w, http.StatusUnauthorized, apperror.CodeUnauthenticated, "authentication required")
+		return
+	}
+	if !auth.HasRole(ctx, auth.RoleViewer) {
+		writeError(w, http.StatusForbidden, apperror.CodeForbidden, fmt.Sprintf("role %s required", auth.RoleViewer))
+		return
+	}
+
+	address, from, to, format, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	var writer recordWriter
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="transfers.%s"`, format))
+		if format == FormatJSONL {
+			w.Header().Set("Content-Type", "application/jsonl")
+			writer = &jsonlWriter{encoder: json.NewEncoder(w)}
+		} else {
+			w.Header().Set("Content-Type", "text/csv")
+			writer = &csvWriter{writer: csv.NewWriter(w)}
+		}
+		return writer.writeHeader()
+	}
+
+	controller := http.NewResponseController(w)
+	err = h.WalletService.ExportTransfers(ctx, address, from, to, func(batch []model.Transfer) error {
+		if !started {
+			err := start()
+			if err != nil {
+				return err
+			}
+		}
+		for i := range batch {
+			err := writer.write(newTransferRecord(&batch[i]))
+			if err != nil {
+				return err
+			}
+		}
+		err := writer.flush()
+		if err != nil {
+			return err
+		}
+		// Errors mean that the writer can't be flushed; the data is sent when it's full.
+		_ = controller.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			logging.FromContext(ctx).Error("transfer export failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "", "export failed")
+			return
+		}
+		// The status was already sent, so the connection is broken off for the client
+		// not to take the partial export for a complete one.
+		logging.FromContext(ctx).Error("transfer export interrupted", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if !started {
+		err = start()
+		if err == nil {
+			err = writer.flush()
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("writing transfer export failed", "error", err)
+		}
+	}
+}
+
+func parseQuery(r *http.Request) (*string, *time.Time, *time.Time, string, error) {
+	query := r.URL.Query()
+
+	var address *string
+	if value := query.Get("address"); value != "" {
+		err := address_helper.CheckAddress(value)
+		if err != nil {
+			return nil, nil, nil, "", fmt.Errorf("address: %w", err)
+		}
+		address = &value
+	}
+
+	from, err := parseTime(query.Get("from"))
+	if err != nil {
+		return nil, nil, nil, "", fmt.Errorf("from: %w", err)
+	}
+	to, err := parseTime(query.Get("to"))
+	if err != nil {
+		return nil, nil, nil, "", fmt.Errorf("to: %w", err)
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, nil, nil, "", errors.New("from must not be after to")
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = FormatCSV
+	}
+	if format != FormatCSV && format != FormatJSONL {
+		return nil, nil, nil, "", fmt.Errorf("format: must be %s or %s", FormatCSV, FormatJSONL)
+	}
+
+	return address, from, to, format, nil
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an RFC 3339 time", value)
+	}
+	return &t, nil
+}
+
+// writeError responds with an error in the same format as GraphQL errors.
+func writeError(w http.ResponseWriter, status int, code apperror.Code, message string) {
+	body := map[string]any{"message": message}
+	if code != "" {
+		body["extensions"] = map[string]any{"code": code}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]any{body}})
+}
+
+type recordWriter interface {
+	writeHeader() error
+	write(record transferRecord) error
+	flush() error
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) writeHeader() error {
+	return c.writer.Write(columns)
+}
+
+func (c *csvWriter) write(record transferRecord) error {
+	metadata := ""
+	if record.Metadata != nil {
+		encoded, err := json.Marshal(record.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(encoded)
+	}
+	reversalOfID := ""
+	if record.ReversalOfID != nil {
+		reversalOfID = strconv.FormatUint(uint64(*record.ReversalOfID), 10)
+	}
+
+	return c.writer.Write([]string{
+		strconv.FormatUint(uint64(record.ID), 10),
+		record.CreatedAt,
+		record.FromAddress,
+		record.ToAddress,
+		strconv.Itoa(record.Amount),
+		escapeFormula(valueOrEmpty(record.Memo)),
+		escapeFormula(metadata),
+		reversalOfID,
+		escapeFormula(valueOrEmpty(record.Reason)),
+	})
+}
+
+// escapeFormula prefixes a value that a spreadsheet would run as a formula with an
+// apostrophe, so that it's shown as text.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (c *csvWriter) flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonlWriter) writeHeader() error {
+	return nil
+}
+
+func (j *jsonlWriter) write(record transferRecord) error {
+	return j.encoder.Encode(record)
+}
+
+func (j *jsonlWriter) flush() error {
+	return nil
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
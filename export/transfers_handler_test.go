@@ -0,0 +1,152 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/service"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const (
+	alice = "0x1111111111111111111111111111111111111111"
+	bob   = "0x2222222222222222222222222222222222222222"
+)
+
+type fakeWalletService struct {
+	service.WalletServicer
+
+	batches [][]model.Transfer
+	err     error
+
+	address  *string
+	from, to *time.Time
+}
+
+func (f *fakeWalletService) ExportTransfers(ctx context.Context, address *string, from *time.Time, to *time.Time, handle func(batch []model.Transfer) error) error {
+	f.address, f.from, f.to = address, from, to
+	for _, batch := range f.batches {
+		err := handle(batch)
+		if err != nil {
+			return err
+		}
+	}
+	return f.err
+}
+
+func TestTransfersHandler(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	memo := "invoice 7, \"march\""
+	reversalOf := uint(1)
+	reason := "mistake"
+	batches := [][]model.Transfer{
+		{{Model: gorm.Model{ID: 1, CreatedAt: createdAt}, FromAddress: alice, ToAddress: bob, Amount: 100, Memo: &memo, Metadata: map[string]any{"order": "A-1"}}},
+		{{Model: gorm.Model{ID: 2, CreatedAt: createdAt.Add(time.Hour)}, FromAddress: bob, ToAddress: alice, Amount: 100, ReversalOfID: &reversalOf, Reason: &reason}},
+	}
+
+	serve := func(walletService service.WalletServicer, role auth.Role, target string) *httptest.ResponseRecorder {
+		handler := &TransfersHandler{WalletService: walletService}
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if role != "" {
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Subject: "finance", Role: role}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		walletService := &fakeWalletService{batches: batches}
+		rec := serve(walletService, auth.RoleViewer, "/export/transfers?address="+alice+"&from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename="transfers.csv"`, rec.Header().Get("Content-Disposition"))
+		require.Equal(t, "id,created_at,from_address,to_address,amount,memo,metadata,reversal_of_id,reason\n"+
+			"1,2026-03-01T12:00:00Z,"+alice+","+bob+",100,\"invoice 7, \"\"march\"\"\",\"{\"\"order\"\":\"\"A-1\"\"}\",,\n"+
+			"2,2026-03-01T13:00:00Z,"+bob+","+alice+",100,,,1,mistake\n", rec.Body.String())
+
+		require.Equal(t, alice, *walletService.address)
+		require.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), *walletService.from)
+		require.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), *walletService.to)
+	})
+
+	t.Run("csv escapes formulas", func(t *testing.T) {
+		memo := "=HYPERLINK(\"http://example.com\",\"invoice\")"
+		reason := "-1+2"
+		walletService := &fakeWalletService{batches: [][]model.Transfer{
+			{{Model: gorm.Model{ID: 1, CreatedAt: createdAt}, FromAddress: alice, ToAddress: bob, Amount: 100, Memo: &memo, Reason: &reason}},
+		}}
+		rec := serve(walletService, auth.RoleViewer, "/export/transfers")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "id,created_at,from_address,to_address,amount,memo,metadata,reversal_of_id,reason\n"+
+			"1,2026-03-01T12:00:00Z,"+alice+","+bob+",100,\"'=HYPERLINK(\"\"http://example.com\"\",\"\"invoice\"\")\",,,'-1+2\n", rec.Body.String())
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		walletService := &fakeWalletService{batches: batches}
+		rec := serve(walletService, auth.RoleViewer, "/export/transfers?format=jsonl")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/jsonl", rec.Header().Get("Content-Type"))
+		require.Equal(t, `{"id":1,"created_at":"2026-03-01T12:00:00Z","from_address":"`+alice+`","to_address":"`+bob+`","amount":100,"memo":"invoice 7, \"march\"","metadata":{"order":"A-1"},"reversal_of_id":null,"reason":null}`+"\n"+
+			`{"id":2,"created_at":"2026-03-01T13:00:00Z","from_address":"`+bob+`","to_address":"`+alice+`","amount":100,"memo":null,"metadata":null,"reversal_of_id":1,"reason":"mistake"}`+"\n", rec.Body.String())
+
+		require.Nil(t, walletService.address)
+		require.Nil(t, walletService.from)
+		require.Nil(t, walletService.to)
+	})
+
+	t.Run("no transfers", func(t *testing.T) {
+		rec := serve(&fakeWalletService{}, auth.RoleViewer, "/export/transfers")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "id,created_at,from_address,to_address,amount,memo,metadata,reversal_of_id,reason\n", rec.Body.String())
+	})
+
+	t.Run("authentication and role required", func(t *testing.T) {
+		rec := serve(&fakeWalletService{}, "", "/export/transfers")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		require.Contains(t, rec.Body.String(), "UNAUTHENTICATED")
+
+		rec = serve(&fakeWalletService{}, auth.Role("NONE"), "/export/transfers")
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Contains(t, rec.Body.String(), "FORBIDDEN")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"address=0x123",
+			"from=yesterday",
+			"to=2026-13-01T00:00:00Z",
+			"from=2026-04-01T00:00:00Z&to=2026-03-01T00:00:00Z",
+			"format=xml",
+		} {
+			rec := serve(&fakeWalletService{}, auth.RoleViewer, "/export/transfers?"+query)
+			require.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("error before streaming", func(t *testing.T) {
+		rec := serve(&fakeWalletService{err: errors.New("connection refused")}, auth.RoleViewer, "/export/transfers")
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.NotContains(t, rec.Body.String(), "connection refused")
+	})
+
+	t.Run("error while streaming aborts the response", func(t *testing.T) {
+		walletService := &fakeWalletService{batches: batches, err: errors.New("connection reset")}
+
+		require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			serve(walletService, auth.RoleViewer, "/export/transfers")
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+// Complexity of a field that writes to the database while holding row locks.
+const writeComplexity = 10
+
+// Complexity of a field that looks up a single row by a unique key.
+const lookupComplexity = 1
+
+// Assumed number of items in lists that are not paginated.
+const unpaginatedListSize = 10
+
+// NewComplexityRoot returns the complexity functions used to compute the cost of an
+// operation. Fields not listed here cost 1 plus the cost of their selection set.
+func NewComplexityRoot() ComplexityRoot {
+	var c ComplexityRoot
+
+	c.Query.Wallet = func(childComplexity int, _ string) int {
+		return lookupComplexity + childComplexity
+	}
+	c.Query.WalletsByAddresses = func(childComplexity int, addresses []string) int {
+		return lookupComplexity + len(addresses)*childComplexity
+	}
+	c.Query.Wallets = func(childComplexity int, _ *model.WalletFilter, _ model.WalletOrder, first int32, _ *string) int {
+		return lookupComplexity + int(first)*childComplexity
+	}
+	c.Query.BalanceAt = func(_ int, _ string, _ time.Time) int {
+		return lookupComplexity
+	}
+	c.Wallet.BalanceHistory = func(childComplexity int, _ time.Time, _ time.Time, _ model.BalanceInterval) int {
+		return lookupComplexity + unpaginatedListSize*childComplexity
+	}
+	c.Query.RichList = func(childComplexity int, limit int32) int {
+		return lookupComplexity + int(limit)*childComplexity
+	}
+	c.Query.Transfer = func(childComplexity int, _ uint) int {
+		return lookupComplexity + childComplexity
+	}
+	c.Query.Transfers = func(childComplexity int, _ string, _ *model.TransferFilter, limit int32, _ *uint) int {
+		return lookupComplexity + int(limit)*childComplexity
+	}
+	c.Query.Hold = func(childComplexity int, _ uint) int {
+		return lookupComplexity + childComplexity
+	}
+	c.Query.ScheduledTransfer = func(childComplexity int, _ uint) int {
+		return lookupComplexity + childComplexity
+	}
+	c.Query.ScheduledTransfers = func(childComplexity int, _ string, _ *model.ScheduleStatus) int {
+		return lookupComplexity + unpaginatedListSize*childComplexity
+	}
+	c.Query.WalletPolicy = func(childComplexity int, _ string) int {
+		return lookupComplexity + childComplexity
+	}
+	c.Query.Webhook = func(childComplexity int, _ uint) int {
+		return lookupComplexity + childComplexity
+	}
+	c.Query.Webhooks = func(childComplexity int) int {
+		return lookupComplexity + unpaginatedListSize*childComplexity
+	}
+	c.Query.WebhookDeliveries = func(childComplexity int, _ uint, _ *model.WebhookDeliveryStatus, limit int32) int {
+		return lookupComplexity + int(limit)*childComplexity
+	}
+
+	c.Mutation.Transfer = func(childComplexity int, _ string, _ string, _ int, _ *string, _ map[string]any) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.TransferTokens = func(childComplexity int, _ string, _ string, _ int, _ *string, _ map[string]any, _ *string) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.ReverseTransfer = func(childComplexity int, _ uint, _ string, _ bool) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.HoldTokens = func(childComplexity int, _ string, _ string, _ int, _ time.Time) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.CaptureHold = func(childComplexity int, _ uint) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.VoidHold = func(childComplexity int, _ uint) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.ScheduleTransfer = func(childComplexity int, _ string, _ string, _ int, _ time.Time, _ *model.Recurrence) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.CancelScheduledTransfer = func(childComplexity int, _ uint) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.SetWalletPolicy = func(childComplexity int, _ string, _ model.WalletPolicyInput) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.RemoveWalletPolicy = func(childComplexity int, _ string) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.CreateWallet = func(childComplexity int, _ string) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.FreezeWallet = func(childComplexity int, _ string, _ string) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.UnfreezeWallet = func(childComplexity int, _ string, _ string) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.CloseWallet = func(childComplexity int, _ string, _ string) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.RegisterWebhook = func(childComplexity int, _ string, _ []model.WebhookEvent, _ []string) int {
+		return writeComplexity + childComplexity
+	}
+	c.Mutation.DeleteWebhook = func(childComplexity int, _ uint) int {
+		return writeComplexity + childComplexity
+	}
+
+	c.Subscription.WalletTransfers = func(childComplexity int, _ string) int {
+		return lookupComplexity + childComplexity
+	}
+
+	c.Transfer.ReversalOf = func(childComplexity int) int {
+		return lookupComplexity + childComplexity
+	}
+	c.Transfer.ReversedBy = func(childComplexity int) int {
+		return lookupComplexity + childComplexity
+	}
+	c.ScheduledTransfer.Runs = func(childComplexity int, limit int32) int {
+		return lookupComplexity + int(limit)*childComplexity
+	}
+
+	return c
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/service"
+	"github.com/vikstrous/dataloadgen"
+)
+
+// How long a loader waits for more keys before fetching a batch.
+const loaderWait = time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the lookups made by field resolvers of a single operation, so that
+// listing many wallets doesn't take a query per wallet.
+type loaders struct {
+	wallet         *dataloadgen.Loader[string, *model.Wallet]
+	walletActivity *dataloadgen.Loader[string, *model.WalletActivity]
+}
+
+// DataLoaders creates new loaders for every operation. Loaders cache the loaded values,
+// so they are never shared between operations.
+type DataLoaders struct {
+	WalletService service.WalletServicer
+}
+
+var _ interface {
+	graphql.OperationInterceptor
+	graphql.HandlerExtension
+} = DataLoaders{}
+
+func (d DataLoaders) ExtensionName() string {
+	return "DataLoaders"
+}
+
+func (d DataLoaders) Validate(_ graphql.ExecutableSchema) error {
+	if d.WalletService == nil {
+		return errors.New("DataLoaders WalletService can not be nil")
+	}
+	return nil
+}
+
+func (d DataLoaders) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	l := &loaders{
+		wallet:         dataloadgen.NewMappedLoader(d.WalletService.GetWalletsByAddresses, dataloadgen.WithWait(loaderWait)),
+		walletActivity: dataloadgen.NewMappedLoader(d.WalletService.GetWalletActivities, dataloadgen.WithWait(loaderWait)),
+	}
+	return next(context.WithValue(ctx, loadersKey{}, l))
+}
+
+// firstError returns the first error of the keys loaded with LoadAll, instead of all of
+// them. A failed batch gives the same error for every key.
+func firstError(err error) error {
+	var errs dataloadgen.ErrorSlice
+	if errors.As(err, &errs) {
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// loadersFor returns the loaders of the current operation.
+func loadersFor(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/stretchr/testify/require"
+)
+
+// countingWalletService counts the batches of wallets and wallet activities it is asked for.
+type countingWalletService struct {
+	fakeWalletService
+	mu            sync.Mutex
+	batches       [][]string
+	walletBatches [][]string
+}
+
+func (c *countingWalletService) GetWalletsByAddresses(ctx context.Context, addresses []string) (map[string]*model.Wallet, error) {
+	c.mu.Lock()
+	c.walletBatches = append(c.walletBatches, addresses)
+	c.mu.Unlock()
+	return c.fakeWalletService.GetWalletsByAddresses(ctx, addresses)
+}
+
+func (c *countingWalletService) GetWalletActivities(ctx context.Context, addresses []string) (map[string]*model.WalletActivity, error) {
+	c.mu.Lock()
+	c.batches = append(c.batches, addresses)
+	c.mu.Unlock()
+	return c.fakeWalletService.GetWalletActivities(ctx, addresses)
+}
+
+func TestDataLoaders(t *testing.T) {
+	walletService := &countingWalletService{}
+	c := newTestClient(newTestServerWith(walletService), auth.RoleViewer)
+
+	var resp map[string]struct {
+		IncomingTransfers int
+		TotalReceived     int
+		TotalSent         int
+		LastActivityAt    *string
+	}
+	err := c.Post(`{
+		a: wallet(address: "0x0000000000000000000000000000000000000001") { incomingTransfers totalReceived totalSent lastActivityAt }
+		b: wallet(address: "0x0000000000000000000000000000000000000002") { incomingTransfers totalReceived }
+		c: wallet(address: "0x0000000000000000000000000000000000000003") { totalSent }
+	}`, &resp)
+	require.NoError(t, err)
+	require.Equal(t, 2, resp["a"].IncomingTransfers)
+	require.Equal(t, 30, resp["b"].TotalReceived)
+	require.Nil(t, resp["a"].LastActivityAt)
+
+	require.Len(t, walletService.batches, 1)
+	require.ElementsMatch(t, []string{
+		"0x0000000000000000000000000000000000000001",
+		"0x0000000000000000000000000000000000000002",
+		"0x0000000000000000000000000000000000000003",
+	}, walletService.batches[0])
+}
+
+func TestWalletsByAddresses(t *testing.T) {
+	walletService := &countingWalletService{}
+	c := newTestClient(newTestServerWith(walletService), auth.RoleViewer)
+
+	var resp map[string][]*struct {
+		Address string
+		Tokens  int
+	}
+	err := c.Post(`{
+		a: walletsByAddresses(addresses: ["0x0000000000000000000000000000000000000001", "0x000000000000000000000000000000000000dead"]) { address tokens }
+		b: walletsByAddresses(addresses: ["0x0000000000000000000000000000000000000002"]) { address }
+	}`, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp["a"], 2)
+	require.Equal(t, "0x0000000000000000000000000000000000000001", resp["a"][0].Address)
+	require.Equal(t, 100, resp["a"][0].Tokens)
+	require.Nil(t, resp["a"][1])
+	require.Equal(t, "0x0000000000000000000000000000000000000002", resp["b"][0].Address)
+
+	require.Len(t, walletService.walletBatches, 1)
+	require.Len(t, walletService.walletBatches[0], 3)
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecatedTransfer(t *testing.T) {
+	srv := newTestServer()
+	srv.Use(extension.Introspection{})
+	c := newTestClient(srv, auth.RoleOperator)
+
+	t.Run("old and new transfer mutations both work", func(t *testing.T) {
+		var resp struct {
+			Transfer       int
+			TransferTokens struct {
+				Transfer         struct{ ID string }
+				FromWallet       struct{ Tokens int }
+				ToWallet         struct{ Tokens int }
+				RecipientCreated bool
+			}
+		}
+		err := c.Post(`mutation {
+			transfer(from_address: "0x0000000000000000000000000000000000000001", to_address: "0x0000000000000000000000000000000000000002", amount: 10)
+			transferTokens(from_address: "0x0000000000000000000000000000000000000001", to_address: "0x0000000000000000000000000000000000000002", amount: 10) {
+				transfer { id }
+				fromWallet { tokens }
+				toWallet { tokens }
+				recipientCreated
+			}
+		}`, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "1", resp.TransferTokens.Transfer.ID)
+		require.Equal(t, 90, resp.TransferTokens.FromWallet.Tokens)
+		require.Equal(t, 10, resp.TransferTokens.ToWallet.Tokens)
+		require.True(t, resp.TransferTokens.RecipientCreated)
+	})
+
+	t.Run("old transfer mutation is marked as deprecated", func(t *testing.T) {
+		var resp struct {
+			Type struct {
+				Fields []struct {
+					Name              string
+					IsDeprecated      bool
+					DeprecationReason *string
+				}
+			} `json:"__type"`
+		}
+		err := c.Post(`{ __type(name: "Mutation") { fields(includeDeprecated: true) { name isDeprecated deprecationReason } } }`, &resp)
+		require.NoError(t, err)
+
+		deprecated := map[string]bool{}
+		for _, field := range resp.Type.Fields {
+			deprecated[field.Name] = field.IsDeprecated
+		}
+		require.True(t, deprecated["transfer"])
+		require.False(t, deprecated["transferTokens"])
+	})
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepthLimit(t *testing.T) {
+	const query = `query { wallet(address: "0x0000000000000000000000000000000000000001") { address } }`
+	const fragmentQuery = `
+		query {
+			wallet(address: "0x0000000000000000000000000000000000000001") { ...walletFields }
+		}
+		fragment walletFields on Wallet { address tokens }`
+
+	t.Run("allows operation within the limit", func(t *testing.T) {
+		srv := newTestServer()
+		srv.Use(DepthLimit{MaxDepth: 2})
+		c := newTestClient(srv, auth.RoleAdmin)
+
+		var resp struct{ Wallet struct{ Address string } }
+		err := c.Post(query, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", resp.Wallet.Address)
+	})
+
+	t.Run("rejects operation over the limit", func(t *testing.T) {
+		srv := newTestServer()
+		srv.Use(DepthLimit{MaxDepth: 1})
+		c := newTestClient(srv, auth.RoleAdmin)
+
+		var resp map[string]any
+		err := c.Post(query, &resp)
+		require.ErrorContains(t, err, "exceeds the limit of 1")
+	})
+
+	t.Run("counts fields selected through fragments", func(t *testing.T) {
+		srv := newTestServer()
+		srv.Use(DepthLimit{MaxDepth: 1})
+		c := newTestClient(srv, auth.RoleAdmin)
+
+		var resp map[string]any
+		err := c.Post(fragmentQuery, &resp)
+		require.ErrorContains(t, err, "operation has depth 2")
+	})
+}
+
+func TestComplexityLimit(t *testing.T) {
+	const mutation = `mutation {
+		transfer(from_address: "0x0000000000000000000000000000000000000001", to_address: "0x0000000000000000000000000000000000000002", amount: 1)
+	}`
+
+	srv := newTestServer()
+	srv.Use(extension.FixedComplexityLimit(writeComplexity - 1))
+	c := newTestClient(srv, auth.RoleAdmin)
+
+	var resp map[string]any
+	err := c.Post(mutation, &resp)
+	require.ErrorContains(t, err, "exceeds the limit")
+}
@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+// NewDirectiveRoot returns the implementations of the schema directives.
+func NewDirectiveRoot() DirectiveRoot {
+	return DirectiveRoot{
+		HasRole: HasRole,
+	}
+}
+
+// HasRole implements the @hasRole directive, resolving the field only for callers
+// having at least the required role.
+func HasRole(ctx context.Context, _ any, next graphql.Resolver, role model.Role) (any, error) {
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return nil, apperror.New(apperror.CodeUnauthenticated, "authentication required")
+	}
+	if !principal.Role.Includes(auth.Role(role)) {
+		return nil, apperror.Newf(apperror.CodeForbidden, "role %s required", role)
+	}
+	return next(ctx)
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasRole(t *testing.T) {
+	const query = `query { wallet(address: "0x0000000000000000000000000000000000000001") { tokens } }`
+	const mutation = `mutation {
+		transfer(from_address: "0x0000000000000000000000000000000000000001", to_address: "0x0000000000000000000000000000000000000002", amount: 1)
+	}`
+	const adminMutation = `mutation {
+		freezeWallet(address: "0x0000000000000000000000000000000000000001", reason: "test") { status }
+	}`
+
+	errorCode := func(t *testing.T, c *client.Client, operation string) string {
+		resp, err := c.RawPost(operation)
+		require.NoError(t, err)
+		if resp.Errors == nil {
+			return ""
+		}
+		var errs []struct {
+			Extensions map[string]any
+		}
+		require.NoError(t, json.Unmarshal(resp.Errors, &errs))
+		require.Len(t, errs, 1)
+		return errs[0].Extensions["code"].(string)
+	}
+
+	srv := newTestServer()
+	srv.SetErrorPresenter(PresentError)
+
+	t.Run("anonymous caller", func(t *testing.T) {
+		c := client.New(srv)
+		require.Equal(t, "UNAUTHENTICATED", errorCode(t, c, query))
+	})
+
+	t.Run("viewer", func(t *testing.T) {
+		c := newTestClient(srv, auth.RoleViewer)
+		require.Equal(t, "", errorCode(t, c, query))
+		require.Equal(t, "FORBIDDEN", errorCode(t, c, mutation))
+		require.Equal(t, "FORBIDDEN", errorCode(t, c, adminMutation))
+	})
+
+	t.Run("operator", func(t *testing.T) {
+		c := newTestClient(srv, auth.RoleOperator)
+		require.Equal(t, "", errorCode(t, c, query))
+		require.Equal(t, "", errorCode(t, c, mutation))
+		require.Equal(t, "FORBIDDEN", errorCode(t, c, adminMutation))
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		c := newTestClient(srv, auth.RoleAdmin)
+		require.Equal(t, "", errorCode(t, c, query))
+		require.Equal(t, "", errorCode(t, c, mutation))
+		require.Equal(t, "", errorCode(t, c, adminMutation))
+	})
+}
@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// PresentError converts errors returned by resolvers into GraphQL errors, exposing the
+// code and extensions of application errors to clients.
+func PresentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = make(map[string]any, len(appErr.Extensions)+1)
+		}
+		for key, value := range appErr.Extensions {
+			gqlErr.Extensions[key] = value
+		}
+		gqlErr.Extensions["code"] = string(appErr.Code)
+	}
+
+	return gqlErr
+}
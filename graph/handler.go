@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// How often keep-alive messages are sent to subscribers using the older graphql-ws
+// protocol, so that proxies don't close their idle connections.
+const websocketKeepAlive = 10 * time.Second
+
+// HandlerConfig configures the handler of the GraphQL API.
+type HandlerConfig struct {
+	Resolvers *Resolver
+	// Maximum complexity of a single operation, as computed by NewComplexityRoot.
+	ComplexityLimit int
+	// Maximum nesting of selection sets in a single operation.
+	MaxDepth int
+	// Whether the schema can be queried by introspection.
+	Introspection bool
+	// Limits of mutations moving tokens; nil disables rate limiting.
+	RateLimit *RateLimit
+}
+
+// NewHandler returns the handler of the GraphQL API. Queries and mutations are served
+// over HTTP, subscriptions over websockets. Callers are expected to be authenticated by
+// middleware, also for subscriptions, whose websocket connections keep the context of
+// the upgraded request.
+func NewHandler(cfg HandlerConfig) *handler.Server {
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  cfg.Resolvers,
+		Directives: NewDirectiveRoot(),
+		Complexity: NewComplexityRoot(),
+	}))
+
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: websocketKeepAlive})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+
+	srv.SetErrorPresenter(PresentError)
+	srv.AroundOperations(LogOperation)
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	if cfg.Introspection {
+		srv.Use(extension.Introspection{})
+	}
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
+	srv.Use(extension.FixedComplexityLimit(cfg.ComplexityLimit))
+	srv.Use(DepthLimit{MaxDepth: cfg.MaxDepth})
+	srv.Use(DataLoaders{WalletService: cfg.Resolvers.WalletService})
+	if cfg.RateLimit != nil {
+		srv.Use(*cfg.RateLimit)
+	}
+	return srv
+}
@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+)
+
+// LogOperation adds the GraphQL operation name to the request logger, so that everything
+// logged while resolving the operation can be correlated with it.
+func LogOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+
+	operationName := oc.OperationName
+	if operationName == "" && oc.Operation != nil {
+		operationName = oc.Operation.Name
+	}
+	operationType := ""
+	if oc.Operation != nil {
+		operationType = string(oc.Operation.Operation)
+	}
+
+	ctx = logging.With(ctx,
+		"operation", operationName,
+		"operation_type", operationType,
+	)
+	logger := logging.FromContext(ctx)
+
+	responseHandler := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		response := responseHandler(ctx)
+		if response != nil && len(response.Errors) > 0 {
+			logger.Warn("graphql operation returned errors", "errors", response.Errors.Error())
+		}
+		return response
+	}
+}
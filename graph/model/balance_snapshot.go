@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BalanceSnapshot records the balance of a wallet at some time, so that its past balances
+// can be computed from the nearest snapshot instead of the whole history of transfers.
+type BalanceSnapshot struct {
+	gorm.Model
+	Address string    `json:"address" gorm:"index:idx_balance_snapshots_address_taken_at"`
+	Tokens  int       `json:"tokens"`
+	TakenAt time.Time `json:"takenAt" gorm:"index:idx_balance_snapshots_address_taken_at"`
+}
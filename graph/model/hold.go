@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Hold reserves tokens of the sender's wallet for a transfer that is settled later.
+// While the hold is HELD, its amount is counted in the sender's Reserved balance.
+type Hold struct {
+	gorm.Model
+	FromAddress string     `json:"from_address" gorm:"index"`
+	ToAddress   string     `json:"to_address"`
+	Amount      int        `json:"amount"`
+	Status      HoldStatus `json:"status" gorm:"not null;default:HELD;index"`
+	ExpiresAt   time.Time  `json:"expiresAt" gorm:"index"`
+	// Ledger entry created when the hold was captured.
+	TransferID *uint `json:"transferId"`
+}
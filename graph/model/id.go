@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MarshalUintID serializes database ids as GraphQL IDs, which are always strings.
+func MarshalUintID(id uint) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(strconv.FormatUint(uint64(id), 10)))
+	})
+}
+
+// UnmarshalUintID parses a database id from a GraphQL ID, given as a string or a number.
+func UnmarshalUintID(v any) (uint, error) {
+	switch v := v.(type) {
+	case string:
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid ID", v)
+		}
+		return uint(id), nil
+	case json.Number:
+		return UnmarshalUintID(string(v))
+	case int64:
+		if v < 0 {
+			return 0, fmt.Errorf("%d is not a valid ID", v)
+		}
+		return uint(v), nil
+	case int:
+		return UnmarshalUintID(int64(v))
+	default:
+		return 0, fmt.Errorf("%T is not a valid ID", v)
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUintID(t *testing.T) {
+	var buf bytes.Buffer
+	MarshalUintID(42).MarshalGQL(&buf)
+	require.Equal(t, `"42"`, buf.String())
+}
+
+func TestUnmarshalUintID(t *testing.T) {
+	for _, v := range []any{"42", json.Number("42"), int64(42), 42} {
+		id, err := UnmarshalUintID(v)
+		require.NoError(t, err)
+		require.Equal(t, uint(42), id)
+	}
+
+	for _, v := range []any{"abc", "-1", int64(-1), 4.2, nil} {
+		_, err := UnmarshalUintID(v)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,11 @@
+package model
+
+// ImportRow is a transfer read from an imported CSV file. Opening balances are imported as
+// transfers from the treasury.
+type ImportRow struct {
+	// Line of the file the row was read from.
+	Line        int
+	FromAddress string
+	ToAddress   string
+	Amount      int
+}
@@ -2,8 +2,669 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Balance of a wallet at some time
+type BalancePoint struct {
+	At      time.Time `json:"at"`
+	Balance int       `json:"balance"`
+}
+
 type Mutation struct {
 }
 
+type PageInfo struct {
+	// Whether there are more items after this page
+	HasNextPage bool `json:"hasNextPage"`
+	// Cursor of the last item of this page, to be passed as `after` to fetch the next page
+	EndCursor *string `json:"endCursor,omitempty"`
+}
+
 type Query struct {
 }
+
+type Subscription struct {
+}
+
+type TransferFilter struct {
+	// Only transfers whose metadata has this key
+	MetadataKey *string `json:"metadataKey,omitempty"`
+	// Only transfers whose metadata has `metadataKey` set to this string
+	MetadataValue *string `json:"metadataValue,omitempty"`
+}
+
+// Result of a transfer
+type TransferResult struct {
+	// The ledger entry of the transfer
+	Transfer *Transfer `json:"transfer"`
+	// The sender's wallet after the transfer
+	FromWallet *Wallet `json:"fromWallet"`
+	// The recipient's wallet after the transfer
+	ToWallet *Wallet `json:"toWallet"`
+	// Whether the recipient's wallet was created by the transfer
+	RecipientCreated bool `json:"recipientCreated"`
+}
+
+type WalletConnection struct {
+	Edges    []*WalletEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
+}
+
+type WalletEdge struct {
+	Cursor string  `json:"cursor"`
+	Node   *Wallet `json:"node"`
+}
+
+type WalletFilter struct {
+	// Only wallets with at least this balance
+	MinBalance *int `json:"minBalance,omitempty"`
+	// Only wallets with at most this balance
+	MaxBalance *int `json:"maxBalance,omitempty"`
+	// Only wallets created after this time
+	CreatedAfter *time.Time `json:"createdAfter,omitempty"`
+	// Only wallets with this status
+	Status *WalletStatus `json:"status,omitempty"`
+}
+
+type WalletPolicyInput struct {
+	MaxTransferAmount     *int     `json:"maxTransferAmount,omitempty"`
+	MaxDailyOutflow       *int     `json:"maxDailyOutflow,omitempty"`
+	AllowedCounterparties []string `json:"allowedCounterparties,omitempty"`
+}
+
+type BalanceInterval string
+
+const (
+	BalanceIntervalHour  BalanceInterval = "HOUR"
+	BalanceIntervalDay   BalanceInterval = "DAY"
+	BalanceIntervalWeek  BalanceInterval = "WEEK"
+	BalanceIntervalMonth BalanceInterval = "MONTH"
+)
+
+var AllBalanceInterval = []BalanceInterval{
+	BalanceIntervalHour,
+	BalanceIntervalDay,
+	BalanceIntervalWeek,
+	BalanceIntervalMonth,
+}
+
+func (e BalanceInterval) IsValid() bool {
+	switch e {
+	case BalanceIntervalHour, BalanceIntervalDay, BalanceIntervalWeek, BalanceIntervalMonth:
+		return true
+	}
+	return false
+}
+
+func (e BalanceInterval) String() string {
+	return string(e)
+}
+
+func (e *BalanceInterval) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BalanceInterval(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BalanceInterval", str)
+	}
+	return nil
+}
+
+func (e BalanceInterval) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BalanceInterval) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BalanceInterval) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type HoldStatus string
+
+const (
+	// The tokens are reserved and waiting to be captured or voided
+	HoldStatusHeld HoldStatus = "HELD"
+	// The tokens were transferred to the recipient
+	HoldStatusCaptured HoldStatus = "CAPTURED"
+	// The hold was cancelled and the tokens released
+	HoldStatusVoided HoldStatus = "VOIDED"
+	// The hold wasn't captured before it expired and the tokens were released
+	HoldStatusExpired HoldStatus = "EXPIRED"
+)
+
+var AllHoldStatus = []HoldStatus{
+	HoldStatusHeld,
+	HoldStatusCaptured,
+	HoldStatusVoided,
+	HoldStatusExpired,
+}
+
+func (e HoldStatus) IsValid() bool {
+	switch e {
+	case HoldStatusHeld, HoldStatusCaptured, HoldStatusVoided, HoldStatusExpired:
+		return true
+	}
+	return false
+}
+
+func (e HoldStatus) String() string {
+	return string(e)
+}
+
+func (e *HoldStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = HoldStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid HoldStatus", str)
+	}
+	return nil
+}
+
+func (e HoldStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *HoldStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e HoldStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Recurrence string
+
+const (
+	RecurrenceDaily   Recurrence = "DAILY"
+	RecurrenceWeekly  Recurrence = "WEEKLY"
+	RecurrenceMonthly Recurrence = "MONTHLY"
+)
+
+var AllRecurrence = []Recurrence{
+	RecurrenceDaily,
+	RecurrenceWeekly,
+	RecurrenceMonthly,
+}
+
+func (e Recurrence) IsValid() bool {
+	switch e {
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly:
+		return true
+	}
+	return false
+}
+
+func (e Recurrence) String() string {
+	return string(e)
+}
+
+func (e *Recurrence) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Recurrence(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Recurrence", str)
+	}
+	return nil
+}
+
+func (e Recurrence) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Recurrence) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Recurrence) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// Roles of API callers. Each role includes the permissions of the roles before it.
+type Role string
+
+const (
+	// Can query wallets
+	RoleViewer Role = "VIEWER"
+	// Can also transfer tokens
+	RoleOperator Role = "OPERATOR"
+	// Can also manage wallets and their policies
+	RoleAdmin Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleViewer,
+	RoleOperator,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleViewer, RoleOperator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Role) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Role) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type RunOutcome string
+
+const (
+	RunOutcomeSucceeded RunOutcome = "SUCCEEDED"
+	RunOutcomeFailed    RunOutcome = "FAILED"
+)
+
+var AllRunOutcome = []RunOutcome{
+	RunOutcomeSucceeded,
+	RunOutcomeFailed,
+}
+
+func (e RunOutcome) IsValid() bool {
+	switch e {
+	case RunOutcomeSucceeded, RunOutcomeFailed:
+		return true
+	}
+	return false
+}
+
+func (e RunOutcome) String() string {
+	return string(e)
+}
+
+func (e *RunOutcome) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RunOutcome(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RunOutcome", str)
+	}
+	return nil
+}
+
+func (e RunOutcome) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *RunOutcome) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e RunOutcome) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ScheduleStatus string
+
+const (
+	// The transfer will be executed at `nextRunAt`
+	ScheduleStatusActive ScheduleStatus = "ACTIVE"
+	// The one-off transfer was executed
+	ScheduleStatusCompleted ScheduleStatus = "COMPLETED"
+	// The schedule was cancelled
+	ScheduleStatusCancelled ScheduleStatus = "CANCELLED"
+)
+
+var AllScheduleStatus = []ScheduleStatus{
+	ScheduleStatusActive,
+	ScheduleStatusCompleted,
+	ScheduleStatusCancelled,
+}
+
+func (e ScheduleStatus) IsValid() bool {
+	switch e {
+	case ScheduleStatusActive, ScheduleStatusCompleted, ScheduleStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e ScheduleStatus) String() string {
+	return string(e)
+}
+
+func (e *ScheduleStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScheduleStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScheduleStatus", str)
+	}
+	return nil
+}
+
+func (e ScheduleStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ScheduleStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ScheduleStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WalletOrder string
+
+const (
+	WalletOrderBalanceDesc   WalletOrder = "BALANCE_DESC"
+	WalletOrderBalanceAsc    WalletOrder = "BALANCE_ASC"
+	WalletOrderCreatedAtDesc WalletOrder = "CREATED_AT_DESC"
+	WalletOrderCreatedAtAsc  WalletOrder = "CREATED_AT_ASC"
+)
+
+var AllWalletOrder = []WalletOrder{
+	WalletOrderBalanceDesc,
+	WalletOrderBalanceAsc,
+	WalletOrderCreatedAtDesc,
+	WalletOrderCreatedAtAsc,
+}
+
+func (e WalletOrder) IsValid() bool {
+	switch e {
+	case WalletOrderBalanceDesc, WalletOrderBalanceAsc, WalletOrderCreatedAtDesc, WalletOrderCreatedAtAsc:
+		return true
+	}
+	return false
+}
+
+func (e WalletOrder) String() string {
+	return string(e)
+}
+
+func (e *WalletOrder) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WalletOrder(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WalletOrder", str)
+	}
+	return nil
+}
+
+func (e WalletOrder) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WalletOrder) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WalletOrder) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WalletStatus string
+
+const (
+	// The wallet can send and receive tokens
+	WalletStatusActive WalletStatus = "ACTIVE"
+	// The wallet can't send tokens, and may be prevented from receiving them
+	WalletStatusFrozen WalletStatus = "FROZEN"
+	// The wallet can neither send nor receive tokens
+	WalletStatusClosed WalletStatus = "CLOSED"
+)
+
+var AllWalletStatus = []WalletStatus{
+	WalletStatusActive,
+	WalletStatusFrozen,
+	WalletStatusClosed,
+}
+
+func (e WalletStatus) IsValid() bool {
+	switch e {
+	case WalletStatusActive, WalletStatusFrozen, WalletStatusClosed:
+		return true
+	}
+	return false
+}
+
+func (e WalletStatus) String() string {
+	return string(e)
+}
+
+func (e *WalletStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WalletStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WalletStatus", str)
+	}
+	return nil
+}
+
+func (e WalletStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WalletStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WalletStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	// The delivery is waiting for its first attempt or a retry
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = "PENDING"
+	// The endpoint responded with a 2xx status
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "DELIVERED"
+	// All attempts failed, or the webhook was deleted
+	WebhookDeliveryStatusFailed WebhookDeliveryStatus = "FAILED"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusDelivered,
+	WebhookDeliveryStatusFailed,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusDelivered, WebhookDeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WebhookDeliveryStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WebhookEvent string
+
+const (
+	// A transfer was sent from one of the wallets
+	WebhookEventOutgoingTransfer WebhookEvent = "OUTGOING_TRANSFER"
+	// A transfer was received by one of the wallets
+	WebhookEventIncomingTransfer WebhookEvent = "INCOMING_TRANSFER"
+)
+
+var AllWebhookEvent = []WebhookEvent{
+	WebhookEventOutgoingTransfer,
+	WebhookEventIncomingTransfer,
+}
+
+func (e WebhookEvent) IsValid() bool {
+	switch e {
+	case WebhookEventOutgoingTransfer, WebhookEventIncomingTransfer:
+		return true
+	}
+	return false
+}
+
+func (e WebhookEvent) String() string {
+	return string(e)
+}
+
+func (e *WebhookEvent) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEvent(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEvent", str)
+	}
+	return nil
+}
+
+func (e WebhookEvent) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WebhookEvent) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WebhookEvent) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
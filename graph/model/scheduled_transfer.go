@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScheduledTransfer is a transfer executed by the scheduler at NextRunAt, once or
+// repeatedly if Recurrence is set.
+type ScheduledTransfer struct {
+	gorm.Model
+	FromAddress string         `json:"from_address" gorm:"index"`
+	ToAddress   string         `json:"to_address"`
+	Amount      int            `json:"amount"`
+	Recurrence  *Recurrence    `json:"recurrence"`
+	Status      ScheduleStatus `json:"status" gorm:"not null;default:ACTIVE"`
+	NextRunAt   time.Time      `json:"nextRunAt" gorm:"index"`
+	// Time of the first run, from which the times of recurring runs are computed.
+	FirstRunAt time.Time `json:"firstRunAt"`
+}
+
+// ScheduledTransferRun records the outcome of a single execution of a scheduled transfer.
+type ScheduledTransferRun struct {
+	gorm.Model
+	ScheduledTransferID uint       `json:"scheduledTransferId" gorm:"index"`
+	ScheduledAt         time.Time  `json:"scheduledAt"`
+	ExecutedAt          time.Time  `json:"executedAt"`
+	Outcome             RunOutcome `json:"outcome"`
+	// Reason of the failure, if the transfer failed.
+	Error *string `json:"error"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Format of the Time scalar: RFC 3339 in UTC with millisecond precision, so that all
+// times have the same length and sort lexicographically.
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func MarshalTime(t time.Time) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(FormatTime(t)))
+	})
+}
+
+// FormatTime formats t the same way as the Time scalar, for APIs other than GraphQL.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// UnmarshalTime parses an RFC 3339 time. The offset from UTC is required.
+func UnmarshalTime(v any) (time.Time, error) {
+	text, ok := v.(string)
+	if !ok {
+		return time.Time{}, errors.New("time must be an RFC 3339 string")
+	}
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time", text)
+	}
+	return t, nil
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalTime(t *testing.T) {
+	var buf bytes.Buffer
+	warsaw := time.FixedZone("CET", 3600)
+	MarshalTime(time.Date(2026, 1, 2, 4, 5, 6, 789_123_000, warsaw)).MarshalGQL(&buf)
+	require.Equal(t, `"2026-01-02T03:05:06.789Z"`, buf.String())
+}
+
+func TestUnmarshalTime(t *testing.T) {
+	parsed, err := UnmarshalTime("2026-01-02T04:05:06+01:00")
+	require.NoError(t, err)
+	require.True(t, parsed.Equal(time.Date(2026, 1, 2, 3, 5, 6, 0, time.UTC)))
+
+	for _, v := range []any{"2026-01-02T04:05:06", "2026-01-02", "yesterday", 1767322800} {
+		_, err := UnmarshalTime(v)
+		require.Error(t, err)
+	}
+}
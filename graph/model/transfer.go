@@ -0,0 +1,24 @@
+package model
+
+import "gorm.io/gorm"
+
+// Transfer is a ledger entry recording a single movement of tokens between wallets.
+type Transfer struct {
+	gorm.Model
+	FromAddress string `json:"from_address" gorm:"index;uniqueIndex:idx_transfers_sender_idempotency_key,priority:1"`
+	ToAddress   string `json:"to_address" gorm:"index"`
+	Amount      int    `json:"amount"`
+	// Free-form note attached by the sender.
+	Memo *string `json:"memo"`
+	// Arbitrary JSON object attached by the sender, e.g. invoice or order identifiers.
+	Metadata map[string]any `json:"metadata" gorm:"type:jsonb;serializer:json;index:,type:gin"`
+	// Transfer compensated by this one, if this is a reversal. The unique index makes sure
+	// that a transfer is reversed at most once.
+	ReversalOfID *uint `json:"reversalOfId" gorm:"uniqueIndex"`
+	// Reason given for the reversal.
+	Reason *string `json:"reason"`
+	// Key chosen by the sender to make retries of the transfer safe. A transfer with the
+	// key of an earlier one of the same sender is not executed again; keys of different
+	// senders don't clash.
+	IdempotencyKey *string `json:"idempotencyKey" gorm:"uniqueIndex:idx_transfers_sender_idempotency_key,priority:2"`
+}
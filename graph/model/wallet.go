@@ -5,5 +5,15 @@ import "gorm.io/gorm"
 type Wallet struct {
 	gorm.Model
 	Address string `json:"address" gorm:"unique"`
-	Tokens  int    `json:"tokens"`
+	// Total balance of the wallet, including reserved tokens.
+	Tokens int `json:"tokens"`
+	// Part of the balance reserved by holds that are not yet captured or released.
+	Reserved     int          `json:"reserved" gorm:"not null;default:0"`
+	Status       WalletStatus `json:"status" gorm:"not null;default:ACTIVE"`
+	StatusReason *string      `json:"statusReason"`
+}
+
+// Available returns the part of the balance that can be transferred or held.
+func (w *Wallet) Available() int {
+	return w.Tokens - w.Reserved
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// WalletActivity summarizes the transfers sent and received by a single wallet.
+type WalletActivity struct {
+	Address           string
+	IncomingTransfers int
+	OutgoingTransfers int
+	TotalReceived     int
+	TotalSent         int
+	// Time of the latest transfer, or nil if the wallet has no transfers.
+	LastActivityAt *time.Time
+}
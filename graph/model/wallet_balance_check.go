@@ -0,0 +1,21 @@
+package model
+
+// WalletBalanceCheck compares the balance of a wallet with the balance derived from the
+// postings to its account and the held amount with its reserved balance.
+type WalletBalanceCheck struct {
+	Address  string
+	Tokens   int
+	Reserved int
+	// Sum of the postings to the account of the wallet.
+	LedgerBalance int
+	// Total amount of the holds of the wallet that are still HELD.
+	HeldAmount int
+}
+
+// TransferEntryCheck compares the amount of a transfer with the amount its journal entry
+// posted to the recipient's account.
+type TransferEntryCheck struct {
+	TransferID   uint
+	Amount       int
+	PostedAmount int
+}
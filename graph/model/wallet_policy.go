@@ -0,0 +1,13 @@
+package model
+
+import "gorm.io/gorm"
+
+// WalletPolicy restricts outgoing transfers of a single wallet. Nil limits and an empty
+// counterparty list mean that the corresponding rule is not enforced.
+type WalletPolicy struct {
+	gorm.Model
+	Address               string   `json:"address" gorm:"unique"`
+	MaxTransferAmount     *int     `json:"maxTransferAmount"`
+	MaxDailyOutflow       *int     `json:"maxDailyOutflow"`
+	AllowedCounterparties []string `json:"allowedCounterparties" gorm:"type:jsonb;serializer:json"`
+}
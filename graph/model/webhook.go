@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook is an endpoint notified about events of specific wallets.
+type Webhook struct {
+	gorm.Model
+	// Subject of the caller who registered the webhook.
+	Owner     string         `json:"owner" gorm:"index"`
+	URL       string         `json:"url"`
+	Events    []WebhookEvent `json:"events" gorm:"type:jsonb;serializer:json"`
+	Addresses []string       `json:"addresses" gorm:"type:jsonb;serializer:json;index:,type:gin"`
+	// Key of the HMAC signatures of requests sent to the webhook.
+	Secret string `json:"-"`
+}
+
+// WebhookRegistration is a newly registered webhook together with its secret.
+type WebhookRegistration struct {
+	Webhook *Webhook `json:"webhook"`
+	Secret  string   `json:"secret"`
+}
+
+// WebhookDelivery is a notification of a single event sent to a webhook. There is at most
+// one delivery of an event to a webhook.
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID uint         `json:"webhookId" gorm:"uniqueIndex:idx_webhook_deliveries_event"`
+	EventID   uint         `json:"eventId" gorm:"uniqueIndex:idx_webhook_deliveries_event"`
+	Event     WebhookEvent `json:"event"`
+	Address   string       `json:"address"`
+	// Request body, kept as sent so that its signature can be checked.
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null;default:PENDING;index"`
+	Attempts       int32                 `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time            `json:"nextAttemptAt" gorm:"index"`
+	LastAttemptAt  *time.Time            `json:"lastAttemptAt"`
+	ResponseStatus *int32                `json:"responseStatus"`
+	Error          *string               `json:"error"`
+	DeliveredAt    *time.Time            `json:"deliveredAt"`
+}
@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Mutation fields that move tokens, mapped to the argument holding the sender's address.
+var rateLimitedMutations = map[string]string{
+	"transfer":       "from_address",
+	"transferTokens": "from_address",
+	"holdTokens":     "from_address",
+}
+
+// RateLimit limits how often mutations moving tokens can be executed, both per caller
+// and per sending wallet. Every such mutation field in an operation takes one token from
+// the caller's bucket and one from the sender's bucket.
+type RateLimit struct {
+	Store       ratelimit.Store
+	ClientLimit ratelimit.Limit
+	WalletLimit ratelimit.Limit
+	// Returns the current time; defaults to time.Now.
+	Now func() time.Time
+}
+
+var _ interface {
+	graphql.OperationInterceptor
+	graphql.HandlerExtension
+} = RateLimit{}
+
+func (r RateLimit) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (r RateLimit) Validate(_ graphql.ExecutableSchema) error {
+	if r.Store == nil {
+		return errors.New("RateLimit Store can not be nil")
+	}
+	return nil
+}
+
+func (r RateLimit) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+
+	for _, field := range graphql.CollectFields(oc, oc.Operation.SelectionSet, []string{"Mutation"}) {
+		fromArgument, ok := rateLimitedMutations[field.Name]
+		if !ok {
+			continue
+		}
+
+		fromAddress, _ := field.ArgumentMap(oc.Variables)[fromArgument].(string)
+		result, key, err := ratelimit.TakeTransfer(ctx, r.Store, r.ClientLimit, r.WalletLimit,
+			caller_helper.FromContext(ctx), fromAddress, now)
+		if err != nil {
+			logging.FromContext(ctx).Error("rate limit store failed", "error", err)
+			return graphql.OneShot(graphql.ErrorResponse(ctx, "internal system error"))
+		}
+		if !result.Allowed {
+			logging.FromContext(ctx).Warn("rate limit exceeded", "key", key)
+			return graphql.OneShot(&graphql.Response{
+				Errors: gqlerror.List{PresentError(ctx, ratelimit.NewError(result.RetryAfter))},
+			})
+		}
+	}
+
+	return next(ctx)
+}
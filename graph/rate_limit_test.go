@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	const mutation = `mutation($from: Address!) {
+		transfer(from_address: $from, to_address: "0x0000000000000000000000000000000000000002", amount: 1)
+	}`
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newClient := func(clientLimit ratelimit.Limit, walletLimit ratelimit.Limit) *client.Client {
+		srv := newTestServer()
+		srv.SetErrorPresenter(PresentError)
+		srv.Use(RateLimit{
+			Store:       ratelimit.NewMemoryStore(),
+			ClientLimit: clientLimit,
+			WalletLimit: walletLimit,
+			Now:         func() time.Time { return now },
+		})
+		return newTestClient(srv, auth.RoleAdmin)
+	}
+
+	t.Run("limits transfers from a single wallet", func(t *testing.T) {
+		c := newClient(ratelimit.Limit{Rate: 1, Burst: 10}, ratelimit.Limit{Rate: 0.5, Burst: 1})
+		var resp map[string]any
+
+		err := c.Post(mutation, &resp, client.Var("from", "0x0000000000000000000000000000000000000001"))
+		require.NoError(t, err)
+
+		raw, err := c.RawPost(mutation, client.Var("from", "0x0000000000000000000000000000000000000001"))
+		require.NoError(t, err)
+
+		var errs []map[string]any
+		require.NoError(t, json.Unmarshal(raw.Errors, &errs))
+		require.Len(t, errs, 1)
+		require.Equal(t, "RATE_LIMITED", errs[0]["extensions"].(map[string]any)["code"])
+		require.EqualValues(t, 2, errs[0]["extensions"].(map[string]any)["retryAfter"])
+
+		err = c.Post(mutation, &resp, client.Var("from", "0x0000000000000000000000000000000000000003"))
+		require.NoError(t, err)
+	})
+
+	t.Run("limits transfers of a single client", func(t *testing.T) {
+		c := newClient(ratelimit.Limit{Rate: 1, Burst: 1}, ratelimit.Limit{Rate: 1, Burst: 10})
+		var resp map[string]any
+
+		err := c.Post(mutation, &resp, client.Var("from", "0x0000000000000000000000000000000000000001"))
+		require.NoError(t, err)
+
+		err = c.Post(mutation, &resp, client.Var("from", "0x0000000000000000000000000000000000000003"))
+		require.ErrorContains(t, err, "rate limit exceeded")
+	})
+
+	t.Run("holds share the wallet limit with transfers", func(t *testing.T) {
+		c := newClient(ratelimit.Limit{Rate: 1, Burst: 10}, ratelimit.Limit{Rate: 1, Burst: 1})
+		var resp map[string]any
+
+		err := c.Post(mutation, &resp, client.Var("from", "0x0000000000000000000000000000000000000001"))
+		require.NoError(t, err)
+
+		err = c.Post(`mutation($from: Address!) {
+			holdTokens(from_address: $from, to_address: "0x0000000000000000000000000000000000000002", amount: 1, expires_at: "2026-01-02T00:00:00Z") { id }
+		}`, &resp, client.Var("from", "0x0000000000000000000000000000000000000001"))
+		require.ErrorContains(t, err, "rate limit exceeded")
+	})
+
+	t.Run("does not limit queries", func(t *testing.T) {
+		c := newClient(ratelimit.Limit{Rate: 0, Burst: 0}, ratelimit.Limit{Rate: 0, Burst: 0})
+		var resp map[string]any
+
+		err := c.Post(`query { wallet(address: "0x0000000000000000000000000000000000000001") { tokens } }`, &resp)
+		require.NoError(t, err)
+	})
+}
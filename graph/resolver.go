@@ -1,6 +1,10 @@
 package graph
 
-import "github.com/kamil7430/TokenTransferAPI/service"
+import (
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/service"
+)
 
 // This file will not be regenerated automatically.
 //
@@ -8,5 +12,11 @@ import "github.com/kamil7430/TokenTransferAPI/service"
 // here.
 
 type Resolver struct {
-	WalletService service.WalletServicer
+	WalletService            service.WalletServicer
+	WalletPolicyService      service.WalletPolicyServicer
+	ScheduledTransferService service.ScheduledTransferServicer
+	BalanceHistoryService    service.BalanceHistoryServicer
+	WebhookService           service.WebhookServicer
+	// How often subscriptions check for new transfers.
+	SubscriptionInterval time.Duration
 }
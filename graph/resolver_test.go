@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/service"
+	"gorm.io/gorm"
+)
+
+type fakeWalletService struct{}
+
+func (f *fakeWalletService) GetWallet(_ context.Context, address string) (*model.Wallet, error) {
+	return &model.Wallet{Address: address, Tokens: 100, Status: model.WalletStatusActive}, nil
+}
+
+func (f *fakeWalletService) Transfer(_ context.Context, _ string, _ string, _ int, _ *string, _ map[string]any) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeWalletService) CreateWallet(_ context.Context, address string) (*model.Wallet, error) {
+	return &model.Wallet{Address: address, Status: model.WalletStatusActive}, nil
+}
+
+func (f *fakeWalletService) FreezeWallet(_ context.Context, address string, reason string) (*model.Wallet, error) {
+	return &model.Wallet{Address: address, Status: model.WalletStatusFrozen, StatusReason: &reason}, nil
+}
+
+func (f *fakeWalletService) UnfreezeWallet(_ context.Context, address string, reason string) (*model.Wallet, error) {
+	return &model.Wallet{Address: address, Status: model.WalletStatusActive, StatusReason: &reason}, nil
+}
+
+func (f *fakeWalletService) CloseWallet(_ context.Context, address string, reason string) (*model.Wallet, error) {
+	return &model.Wallet{Address: address, Status: model.WalletStatusClosed, StatusReason: &reason}, nil
+}
+
+func (f *fakeWalletService) TransferTokens(_ context.Context, fromAddress string, toAddress string, amount int, _ *string, _ map[string]any, _ *string) (*model.TransferResult, error) {
+	return &model.TransferResult{
+		Transfer:         &model.Transfer{Model: gorm.Model{ID: 1}, FromAddress: fromAddress, ToAddress: toAddress, Amount: amount},
+		FromWallet:       &model.Wallet{Address: fromAddress, Tokens: 100 - amount, Status: model.WalletStatusActive},
+		ToWallet:         &model.Wallet{Address: toAddress, Tokens: amount, Status: model.WalletStatusActive},
+		RecipientCreated: true,
+	}, nil
+}
+
+// unknownAddress has no wallet in fakeWalletService.GetWalletsByAddresses.
+const unknownAddress = "0x000000000000000000000000000000000000dead"
+
+func (f *fakeWalletService) GetWalletsByAddresses(_ context.Context, addresses []string) (map[string]*model.Wallet, error) {
+	wallets := make(map[string]*model.Wallet, len(addresses))
+	for _, address := range addresses {
+		wallets[address] = nil
+		if address != unknownAddress {
+			wallets[address] = &model.Wallet{Address: address, Tokens: 100, Status: model.WalletStatusActive}
+		}
+	}
+	return wallets, nil
+}
+
+func (f *fakeWalletService) GetWallets(_ context.Context, _ *model.WalletFilter, _ model.WalletOrder, _ int, _ *string) (*model.WalletConnection, error) {
+	return &model.WalletConnection{Edges: []*model.WalletEdge{}, PageInfo: &model.PageInfo{}}, nil
+}
+
+func (f *fakeWalletService) GetRichList(_ context.Context, _ int) ([]*model.Wallet, error) {
+	return []*model.Wallet{}, nil
+}
+
+func (f *fakeWalletService) GetWalletActivities(_ context.Context, addresses []string) (map[string]*model.WalletActivity, error) {
+	activities := make(map[string]*model.WalletActivity, len(addresses))
+	for _, address := range addresses {
+		activities[address] = &model.WalletActivity{Address: address, IncomingTransfers: 2, TotalReceived: 30}
+	}
+	return activities, nil
+}
+
+func (f *fakeWalletService) GetTransfer(_ context.Context, id uint) (*model.Transfer, error) {
+	return &model.Transfer{Model: gorm.Model{ID: id}, Amount: 10}, nil
+}
+
+func (f *fakeWalletService) ExportTransfers(_ context.Context, _ *string, _ *time.Time, _ *time.Time, _ func(batch []model.Transfer) error) error {
+	return nil
+}
+
+func (f *fakeWalletService) GetTransfers(_ context.Context, address string, _ *model.TransferFilter, _ int, _ *uint) ([]*model.Transfer, error) {
+	return []*model.Transfer{{Model: gorm.Model{ID: 1}, FromAddress: address, Amount: 10}}, nil
+}
+
+func (f *fakeWalletService) GetReversalOf(_ context.Context, _ uint) (*model.Transfer, error) {
+	return nil, nil
+}
+
+func (f *fakeWalletService) ReverseTransfer(_ context.Context, transferID uint, reason string, _ bool) (*model.Transfer, error) {
+	return &model.Transfer{Model: gorm.Model{ID: transferID + 1}, Amount: 10, ReversalOfID: &transferID, Reason: &reason}, nil
+}
+
+func (f *fakeWalletService) GetHold(_ context.Context, id uint) (*model.Hold, error) {
+	return &model.Hold{Model: gorm.Model{ID: id}, Status: model.HoldStatusHeld}, nil
+}
+
+func (f *fakeWalletService) HoldTokens(_ context.Context, fromAddress string, toAddress string, amount int, expiresAt time.Time) (*model.Hold, error) {
+	return &model.Hold{
+		Model:       gorm.Model{ID: 1},
+		FromAddress: fromAddress,
+		ToAddress:   toAddress,
+		Amount:      amount,
+		Status:      model.HoldStatusHeld,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (f *fakeWalletService) CaptureHold(_ context.Context, id uint) (*model.Hold, error) {
+	return &model.Hold{Model: gorm.Model{ID: id}, Status: model.HoldStatusCaptured}, nil
+}
+
+func (f *fakeWalletService) VoidHold(_ context.Context, id uint) (*model.Hold, error) {
+	return &model.Hold{Model: gorm.Model{ID: id}, Status: model.HoldStatusVoided}, nil
+}
+
+func newTestServer() *handler.Server {
+	return newTestServerWith(&fakeWalletService{})
+}
+
+func newTestServerWith(walletService service.WalletServicer) *handler.Server {
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  &Resolver{WalletService: walletService},
+		Directives: NewDirectiveRoot(),
+		Complexity: NewComplexityRoot(),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(DataLoaders{WalletService: walletService})
+	return srv
+}
+
+// newTestClient returns a client whose requests are made by a principal with the given role.
+func newTestClient(srv http.Handler, role auth.Role) *client.Client {
+	return client.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := auth.NewContext(r.Context(), &auth.Principal{Subject: "test", Role: role})
+		srv.ServeHTTP(w, r.WithContext(ctx))
+	}))
+}
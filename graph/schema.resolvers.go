@@ -7,13 +7,93 @@ package graph
 
 import (
 	"context"
+	"time"
 
+	"github.com/kamil7430/TokenTransferAPI/apperror"
 	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/service"
 )
 
+// TransferTokens is the resolver for the transferTokens field.
+func (r *mutationResolver) TransferTokens(ctx context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any, idempotencyKey *string) (*model.TransferResult, error) {
+	return r.WalletService.TransferTokens(ctx, fromAddress, toAddress, amount, memo, metadata, idempotencyKey)
+}
+
 // Transfer is the resolver for the transfer field.
-func (r *mutationResolver) Transfer(ctx context.Context, fromAddress string, toAddress string, amount int) (int, error) {
-	return r.WalletService.Transfer(ctx, fromAddress, toAddress, amount)
+func (r *mutationResolver) Transfer(ctx context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any) (int, error) {
+	logging.FromContext(ctx).Warn("deprecated field used", "field", "Mutation.transfer")
+	return r.WalletService.Transfer(ctx, fromAddress, toAddress, amount, memo, metadata)
+}
+
+// ReverseTransfer is the resolver for the reverseTransfer field.
+func (r *mutationResolver) ReverseTransfer(ctx context.Context, transferID uint, reason string, force bool) (*model.Transfer, error) {
+	return r.WalletService.ReverseTransfer(ctx, transferID, reason, force)
+}
+
+// HoldTokens is the resolver for the holdTokens field.
+func (r *mutationResolver) HoldTokens(ctx context.Context, fromAddress string, toAddress string, amount int, expiresAt time.Time) (*model.Hold, error) {
+	return r.WalletService.HoldTokens(ctx, fromAddress, toAddress, amount, expiresAt)
+}
+
+// CaptureHold is the resolver for the captureHold field.
+func (r *mutationResolver) CaptureHold(ctx context.Context, id uint) (*model.Hold, error) {
+	return r.WalletService.CaptureHold(ctx, id)
+}
+
+// VoidHold is the resolver for the voidHold field.
+func (r *mutationResolver) VoidHold(ctx context.Context, id uint) (*model.Hold, error) {
+	return r.WalletService.VoidHold(ctx, id)
+}
+
+// ScheduleTransfer is the resolver for the scheduleTransfer field.
+func (r *mutationResolver) ScheduleTransfer(ctx context.Context, fromAddress string, toAddress string, amount int, runAt time.Time, recurrence *model.Recurrence) (*model.ScheduledTransfer, error) {
+	return r.ScheduledTransferService.ScheduleTransfer(ctx, fromAddress, toAddress, amount, runAt, recurrence)
+}
+
+// CancelScheduledTransfer is the resolver for the cancelScheduledTransfer field.
+func (r *mutationResolver) CancelScheduledTransfer(ctx context.Context, id uint) (*model.ScheduledTransfer, error) {
+	return r.ScheduledTransferService.CancelScheduledTransfer(ctx, id)
+}
+
+// RegisterWebhook is the resolver for the registerWebhook field.
+func (r *mutationResolver) RegisterWebhook(ctx context.Context, url string, events []model.WebhookEvent, addresses []string) (*model.WebhookRegistration, error) {
+	return r.WebhookService.RegisterWebhook(ctx, url, events, addresses)
+}
+
+// DeleteWebhook is the resolver for the deleteWebhook field.
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, id uint) (bool, error) {
+	return r.WebhookService.DeleteWebhook(ctx, id)
+}
+
+// SetWalletPolicy is the resolver for the setWalletPolicy field.
+func (r *mutationResolver) SetWalletPolicy(ctx context.Context, address string, policy model.WalletPolicyInput) (*model.WalletPolicy, error) {
+	return r.WalletPolicyService.SetWalletPolicy(ctx, address, policy)
+}
+
+// RemoveWalletPolicy is the resolver for the removeWalletPolicy field.
+func (r *mutationResolver) RemoveWalletPolicy(ctx context.Context, address string) (bool, error) {
+	return r.WalletPolicyService.RemoveWalletPolicy(ctx, address)
+}
+
+// CreateWallet is the resolver for the createWallet field.
+func (r *mutationResolver) CreateWallet(ctx context.Context, address string) (*model.Wallet, error) {
+	return r.WalletService.CreateWallet(ctx, address)
+}
+
+// FreezeWallet is the resolver for the freezeWallet field.
+func (r *mutationResolver) FreezeWallet(ctx context.Context, address string, reason string) (*model.Wallet, error) {
+	return r.WalletService.FreezeWallet(ctx, address, reason)
+}
+
+// UnfreezeWallet is the resolver for the unfreezeWallet field.
+func (r *mutationResolver) UnfreezeWallet(ctx context.Context, address string, reason string) (*model.Wallet, error) {
+	return r.WalletService.UnfreezeWallet(ctx, address, reason)
+}
+
+// CloseWallet is the resolver for the closeWallet field.
+func (r *mutationResolver) CloseWallet(ctx context.Context, address string, reason string) (*model.Wallet, error) {
+	return r.WalletService.CloseWallet(ctx, address, reason)
 }
 
 // Wallet is the resolver for the wallet field.
@@ -21,11 +101,182 @@ func (r *queryResolver) Wallet(ctx context.Context, address string) (*model.Wall
 	return r.WalletService.GetWallet(ctx, address)
 }
 
+// Wallets is the resolver for the wallets field.
+func (r *queryResolver) Wallets(ctx context.Context, filter *model.WalletFilter, orderBy model.WalletOrder, first int32, after *string) (*model.WalletConnection, error) {
+	return r.WalletService.GetWallets(ctx, filter, orderBy, int(first), after)
+}
+
+// WalletsByAddresses is the resolver for the walletsByAddresses field.
+func (r *queryResolver) WalletsByAddresses(ctx context.Context, addresses []string) ([]*model.Wallet, error) {
+	wallets, err := loadersFor(ctx).wallet.LoadAll(ctx, addresses)
+	return wallets, firstError(err)
+}
+
+// BalanceAt is the resolver for the balanceAt field.
+func (r *queryResolver) BalanceAt(ctx context.Context, address string, at time.Time) (int, error) {
+	return r.BalanceHistoryService.GetBalanceAt(ctx, address, at)
+}
+
+// RichList is the resolver for the richList field.
+func (r *queryResolver) RichList(ctx context.Context, limit int32) ([]*model.Wallet, error) {
+	return r.WalletService.GetRichList(ctx, int(limit))
+}
+
+// Transfer is the resolver for the transfer field.
+func (r *queryResolver) Transfer(ctx context.Context, id uint) (*model.Transfer, error) {
+	return r.WalletService.GetTransfer(ctx, id)
+}
+
+// Transfers is the resolver for the transfers field.
+func (r *queryResolver) Transfers(ctx context.Context, address string, filter *model.TransferFilter, limit int32, before *uint) ([]*model.Transfer, error) {
+	return r.WalletService.GetTransfers(ctx, address, filter, int(limit), before)
+}
+
+// Hold is the resolver for the hold field.
+func (r *queryResolver) Hold(ctx context.Context, id uint) (*model.Hold, error) {
+	return r.WalletService.GetHold(ctx, id)
+}
+
+// ScheduledTransfer is the resolver for the scheduledTransfer field.
+func (r *queryResolver) ScheduledTransfer(ctx context.Context, id uint) (*model.ScheduledTransfer, error) {
+	return r.ScheduledTransferService.GetScheduledTransfer(ctx, id)
+}
+
+// ScheduledTransfers is the resolver for the scheduledTransfers field.
+func (r *queryResolver) ScheduledTransfers(ctx context.Context, address string, status *model.ScheduleStatus) ([]*model.ScheduledTransfer, error) {
+	return r.ScheduledTransferService.GetScheduledTransfers(ctx, address, status)
+}
+
+// Webhook is the resolver for the webhook field.
+func (r *queryResolver) Webhook(ctx context.Context, id uint) (*model.Webhook, error) {
+	return r.WebhookService.GetWebhook(ctx, id)
+}
+
+// Webhooks is the resolver for the webhooks field.
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*model.Webhook, error) {
+	return r.WebhookService.GetWebhooks(ctx)
+}
+
+// WebhookDeliveries is the resolver for the webhookDeliveries field.
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, webhookID uint, status *model.WebhookDeliveryStatus, limit int32) ([]*model.WebhookDelivery, error) {
+	return r.WebhookService.GetDeliveries(ctx, webhookID, status, int(limit))
+}
+
+// WalletPolicy is the resolver for the walletPolicy field.
+func (r *queryResolver) WalletPolicy(ctx context.Context, address string) (*model.WalletPolicy, error) {
+	return r.WalletPolicyService.GetWalletPolicy(ctx, address)
+}
+
+// Runs is the resolver for the runs field.
+func (r *scheduledTransferResolver) Runs(ctx context.Context, obj *model.ScheduledTransfer, limit int32) ([]*model.ScheduledTransferRun, error) {
+	return r.ScheduledTransferService.GetRuns(ctx, obj.ID, int(limit))
+}
+
+// WalletTransfers is the resolver for the walletTransfers field.
+func (r *subscriptionResolver) WalletTransfers(ctx context.Context, address string) (<-chan *model.Transfer, error) {
+	wallet, err := r.WalletService.GetWallet(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, apperror.New(apperror.CodeWalletNotFound, "wallet does not exist")
+	}
+	lastTransferID, err := service.LatestTransferID(ctx, r.WalletService, address)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make(chan *model.Transfer)
+	go r.watchTransfers(ctx, address, lastTransferID, transfers)
+	return transfers, nil
+}
+
+// ReversalOf is the resolver for the reversalOf field.
+func (r *transferResolver) ReversalOf(ctx context.Context, obj *model.Transfer) (*model.Transfer, error) {
+	if obj.ReversalOfID == nil {
+		return nil, nil
+	}
+	return r.WalletService.GetTransfer(ctx, *obj.ReversalOfID)
+}
+
+// ReversedBy is the resolver for the reversedBy field.
+func (r *transferResolver) ReversedBy(ctx context.Context, obj *model.Transfer) (*model.Transfer, error) {
+	return r.WalletService.GetReversalOf(ctx, obj.ID)
+}
+
+// LastActivityAt is the resolver for the lastActivityAt field.
+func (r *walletResolver) LastActivityAt(ctx context.Context, obj *model.Wallet) (*time.Time, error) {
+	activity, err := loadersFor(ctx).walletActivity.Load(ctx, obj.Address)
+	if err != nil {
+		return nil, err
+	}
+	return activity.LastActivityAt, nil
+}
+
+// IncomingTransfers is the resolver for the incomingTransfers field.
+func (r *walletResolver) IncomingTransfers(ctx context.Context, obj *model.Wallet) (int32, error) {
+	activity, err := loadersFor(ctx).walletActivity.Load(ctx, obj.Address)
+	if err != nil {
+		return 0, err
+	}
+	return int32(activity.IncomingTransfers), nil
+}
+
+// OutgoingTransfers is the resolver for the outgoingTransfers field.
+func (r *walletResolver) OutgoingTransfers(ctx context.Context, obj *model.Wallet) (int32, error) {
+	activity, err := loadersFor(ctx).walletActivity.Load(ctx, obj.Address)
+	if err != nil {
+		return 0, err
+	}
+	return int32(activity.OutgoingTransfers), nil
+}
+
+// TotalReceived is the resolver for the totalReceived field.
+func (r *walletResolver) TotalReceived(ctx context.Context, obj *model.Wallet) (int, error) {
+	activity, err := loadersFor(ctx).walletActivity.Load(ctx, obj.Address)
+	if err != nil {
+		return 0, err
+	}
+	return activity.TotalReceived, nil
+}
+
+// TotalSent is the resolver for the totalSent field.
+func (r *walletResolver) TotalSent(ctx context.Context, obj *model.Wallet) (int, error) {
+	activity, err := loadersFor(ctx).walletActivity.Load(ctx, obj.Address)
+	if err != nil {
+		return 0, err
+	}
+	return activity.TotalSent, nil
+}
+
+// BalanceHistory is the resolver for the balanceHistory field.
+func (r *walletResolver) BalanceHistory(ctx context.Context, obj *model.Wallet, from time.Time, to time.Time, interval model.BalanceInterval) ([]*model.BalancePoint, error) {
+	return r.BalanceHistoryService.GetBalanceHistory(ctx, obj.Address, from, to, interval)
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// ScheduledTransfer returns ScheduledTransferResolver implementation.
+func (r *Resolver) ScheduledTransfer() ScheduledTransferResolver {
+	return &scheduledTransferResolver{r}
+}
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// Transfer returns TransferResolver implementation.
+func (r *Resolver) Transfer() TransferResolver { return &transferResolver{r} }
+
+// Wallet returns WalletResolver implementation.
+func (r *Resolver) Wallet() WalletResolver { return &walletResolver{r} }
+
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type scheduledTransferResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type transferResolver struct{ *Resolver }
+type walletResolver struct{ *Resolver }
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/service"
+)
+
+// watchTransfers sends the transfers of the wallet made after the transfer with ID
+// afterID, checking for new ones every SubscriptionInterval, until ctx is done. Polling
+// the wallet service, instead of being notified by it, makes subscriptions see transfers
+// made by every replica of the server. The channel is closed when watching stops.
+func (r *Resolver) watchTransfers(ctx context.Context, address string, afterID uint, transfers chan<- *model.Transfer) {
+	defer close(transfers)
+
+	ticker := time.NewTicker(r.SubscriptionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		page, err := service.TransfersAfter(ctx, r.WalletService, address, afterID)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.FromContext(ctx).Error("watching transfers failed", "address", address, "error", err)
+			}
+			return
+		}
+		for _, transfer := range page {
+			select {
+			case transfers <- transfer:
+			case <-ctx.Done():
+				return
+			}
+			afterID = transfer.ID
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// watchedWalletService keeps the transfers of a wallet in memory, so that they can be
+// made while a subscription watches the wallet.
+type watchedWalletService struct {
+	fakeWalletService
+
+	mu        sync.Mutex
+	transfers []*model.Transfer
+	polls     int
+}
+
+func (w *watchedWalletService) GetTransfers(_ context.Context, address string, _ *model.TransferFilter, limit int, before *uint) ([]*model.Transfer, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.polls++
+	var transfers []*model.Transfer
+	for i := len(w.transfers) - 1; i >= 0 && len(transfers) < limit; i-- {
+		if before == nil || w.transfers[i].ID < *before {
+			transfers = append(transfers, w.transfers[i])
+		}
+	}
+	return transfers, nil
+}
+
+func (w *watchedWalletService) addTransfer(amount int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := uint(len(w.transfers) + 1)
+	w.transfers = append(w.transfers, &model.Transfer{Model: gorm.Model{ID: id}, FromAddress: testAddress, Amount: amount})
+}
+
+func (w *watchedWalletService) pollCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.polls
+}
+
+const testAddress = "0x0000000000000000000000000000000000000001"
+
+func TestWalletTransfersSubscription(t *testing.T) {
+	walletService := &watchedWalletService{}
+	walletService.addTransfer(5)
+	srv := NewHandler(HandlerConfig{
+		Resolvers:       &Resolver{WalletService: walletService, SubscriptionInterval: 10 * time.Millisecond},
+		ComplexityLimit: 200,
+		MaxDepth:        10,
+	})
+
+	t.Run("streams new transfers", func(t *testing.T) {
+		c := newTestClient(srv, auth.RoleViewer)
+		subscription := c.Websocket(`subscription { walletTransfers(address: "` + testAddress + `") { id amount } }`)
+		defer subscription.Close()
+
+		// Wait until the wallet is polled, so that the transfers are made after subscribing.
+		require.Eventually(t, func() bool { return walletService.pollCount() >= 2 }, time.Second, time.Millisecond)
+		walletService.addTransfer(10)
+		walletService.addTransfer(20)
+
+		for _, expected := range []struct {
+			ID     string
+			Amount int
+		}{{"2", 10}, {"3", 20}} {
+			var resp struct {
+				WalletTransfers struct {
+					ID     string
+					Amount int
+				}
+			}
+			require.NoError(t, subscription.Next(&resp))
+			require.Equal(t, expected.ID, resp.WalletTransfers.ID)
+			require.Equal(t, expected.Amount, resp.WalletTransfers.Amount)
+		}
+	})
+
+	t.Run("requires the viewer role", func(t *testing.T) {
+		c := newTestClient(srv, "")
+		subscription := c.Websocket(`subscription { walletTransfers(address: "` + testAddress + `") { id } }`)
+		defer subscription.Close()
+
+		var resp struct{}
+		require.ErrorContains(t, subscription.Next(&resp), "FORBIDDEN")
+	})
+}
@@ -0,0 +1,153 @@
+// Package grpcapi serves the wallet service over gRPC for service-to-service calls. It is
+// built on the same services as the GraphQL API, and enforces the same roles and rate
+// limits.
+package grpcapi
+
+//go:generate protoc -I walletpb --go_out=walletpb --go_opt=paths=source_relative --go-grpc_out=walletpb --go-grpc_opt=paths=source_relative walletpb/wallet_service.proto
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/grpcapi/walletpb"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys of incoming calls, the lowercase equivalents of the HTTP headers.
+const (
+	apiKeyMetadata        = "x-api-key"
+	authorizationMetadata = "authorization"
+	requestIDMetadata     = "x-request-id"
+)
+
+// NewServer returns a gRPC server with the wallet service, the health service and server
+// reflection. Calls of the wallet service are authenticated by the authenticator and
+// logged with the logger, like requests to the HTTP APIs.
+func NewServer(logger *slog.Logger, authenticator *auth.Authenticator, walletServer *WalletServer) *grpc.Server {
+	i := &interceptor{logger: logger, authenticator: authenticator}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(i.unary),
+		grpc.ChainStreamInterceptor(i.stream),
+	)
+
+	walletpb.RegisterWalletServiceServer(server, walletServer)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(walletpb.WalletService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+// interceptor authenticates and logs calls of the wallet service. Health checks and
+// reflection are left alone, so that they need no credentials and don't flood the logs.
+type interceptor struct {
+	logger        *slog.Logger
+	authenticator *auth.Authenticator
+}
+
+func (i *interceptor) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !isWalletServiceMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	start := time.Now()
+	ctx, requestID, err := i.newContext(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+	var resp any
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (i *interceptor) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !isWalletServiceMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	start := time.Now()
+	ctx, requestID, err := i.newContext(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(requestIDMetadata, requestID))
+	if err == nil {
+		err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// newContext returns a copy of ctx identifying the caller, as the middleware of the HTTP
+// APIs does, together with the ID of the call. It fails if the credentials are invalid.
+func (i *interceptor) newContext(ctx context.Context) (context.Context, string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	caller := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		caller = p.Addr.String()
+		if host, _, err := net.SplitHostPort(caller); err == nil {
+			caller = host
+		}
+	}
+	ctx = caller_helper.NewContext(ctx, caller)
+	ctx, requestID := logging.NewRequestContext(ctx, i.logger, firstValue(md, requestIDMetadata))
+
+	principal, err := i.authenticator.Authenticate(firstValue(md, apiKeyMetadata), firstValue(md, authorizationMetadata))
+	if err != nil {
+		logging.FromContext(ctx).Warn("authentication failed", "error", err)
+		return ctx, requestID, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	if principal != nil {
+		ctx = auth.NewContext(ctx, principal)
+		if !principal.Anonymous {
+			ctx = caller_helper.NewContext(ctx, principal.Subject)
+		}
+		ctx = logging.With(ctx, "principal", principal.Subject, "role", principal.Role)
+	}
+	return ctx, requestID, nil
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	logging.FromContext(ctx).Info("rpc handled",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+func isWalletServiceMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+walletpb.WalletService_ServiceDesc.ServiceName+"/")
+}
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// serverStream replaces the context of a stream with the one made by the interceptor.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,315 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/grpcapi/walletpb"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
+	"github.com/kamil7430/TokenTransferAPI/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Domain of the ErrorInfo details of errors with an application error code.
+const errorDomain = "tokentransfer"
+
+// WalletServer implements the gRPC wallet service on top of the wallet service used by
+// the GraphQL API.
+type WalletServer struct {
+	walletpb.UnimplementedWalletServiceServer
+
+	WalletService service.WalletServicer
+	// Store of the token buckets of transfers, shared with the other APIs so that all of
+	// them count against the same limits; nil disables rate limiting.
+	RateLimitStore ratelimit.Store
+	ClientLimit    ratelimit.Limit
+	WalletLimit    ratelimit.Limit
+	// How often watched wallets are checked for changes.
+	WatchInterval time.Duration
+}
+
+func (s *WalletServer) GetWallet(ctx context.Context, req *walletpb.GetWalletRequest) (*walletpb.Wallet, error) {
+	err := checkRole(ctx, auth.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAddress("address", req.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.WalletService.GetWallet(ctx, req.GetAddress())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if wallet == nil {
+		return nil, status.Error(codes.NotFound, "wallet does not exist")
+	}
+	return newWallet(wallet), nil
+}
+
+func (s *WalletServer) Transfer(ctx context.Context, req *walletpb.TransferRequest) (*walletpb.TransferResponse, error) {
+	err := checkRole(ctx, auth.RoleOperator)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAddress("from_address", req.GetFromAddress())
+	if err != nil {
+		return nil, err
+	}
+	err = checkAddress("to_address", req.GetToAddress())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.takeRateLimit(ctx, req.GetFromAddress())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	var metadata map[string]any
+	if req.Metadata != nil {
+		metadata = req.Metadata.AsMap()
+	}
+	result, err := s.WalletService.TransferTokens(ctx, req.GetFromAddress(), req.GetToAddress(), int(req.GetAmount()), req.Memo, metadata, nil)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	transfer, err := newTransfer(result.Transfer)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &walletpb.TransferResponse{
+		Transfer:         transfer,
+		FromWallet:       newWallet(result.FromWallet),
+		ToWallet:         newWallet(result.ToWallet),
+		RecipientCreated: result.RecipientCreated,
+	}, nil
+}
+
+// WatchWallet polls the wallet and its transfers every WatchInterval, and sends an update
+// whenever either changed, until the call is cancelled.
+func (s *WalletServer) WatchWallet(req *walletpb.WatchWalletRequest, stream grpc.ServerStreamingServer[walletpb.WalletUpdate]) error {
+	ctx := stream.Context()
+	address := req.GetAddress()
+
+	err := checkRole(ctx, auth.RoleViewer)
+	if err != nil {
+		return err
+	}
+	err = checkAddress("address", address)
+	if err != nil {
+		return err
+	}
+
+	wallet, err := s.WalletService.GetWallet(ctx, address)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	if wallet == nil {
+		return status.Error(codes.NotFound, "wallet does not exist")
+	}
+	lastTransferID, err := service.LatestTransferID(ctx, s.WalletService, address)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+
+	err = stream.Send(&walletpb.WalletUpdate{Wallet: newWallet(wallet)})
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+
+		transfers, err := service.TransfersAfter(ctx, s.WalletService, address, lastTransferID)
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+		current, err := s.WalletService.GetWallet(ctx, address)
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+		if current == nil {
+			return status.Error(codes.NotFound, "wallet does not exist")
+		}
+		if len(transfers) == 0 && !walletChanged(wallet, current) {
+			continue
+		}
+
+		update := &walletpb.WalletUpdate{Wallet: newWallet(current)}
+		for _, transfer := range transfers {
+			converted, err := newTransfer(transfer)
+			if err != nil {
+				return toStatus(ctx, err)
+			}
+			update.Transfers = append(update.Transfers, converted)
+		}
+		err = stream.Send(update)
+		if err != nil {
+			return err
+		}
+
+		wallet = current
+		if len(transfers) > 0 {
+			lastTransferID = transfers[len(transfers)-1].ID
+		}
+	}
+}
+
+// takeRateLimit takes a token from the caller's and the sender's buckets, the same ones
+// as the transfer mutations of the GraphQL API take from.
+func (s *WalletServer) takeRateLimit(ctx context.Context, fromAddress string) error {
+	if s.RateLimitStore == nil {
+		return nil
+	}
+
+	result, key, err := ratelimit.TakeTransfer(ctx, s.RateLimitStore, s.ClientLimit, s.WalletLimit,
+		caller_helper.FromContext(ctx), fromAddress, time.Now())
+	if err != nil {
+		return fmt.Errorf("rate limit store failed: %w", err)
+	}
+	if !result.Allowed {
+		logging.FromContext(ctx).Warn("rate limit exceeded", "key", key)
+		return ratelimit.NewError(result.RetryAfter)
+	}
+	return nil
+}
+
+func walletChanged(previous *model.Wallet, current *model.Wallet) bool {
+	return previous.Tokens != current.Tokens ||
+		previous.Reserved != current.Reserved ||
+		previous.Status != current.Status ||
+		!previous.UpdatedAt.Equal(current.UpdatedAt)
+}
+
+// checkRole returns an error unless the caller has at least the role, like the @hasRole
+// directive does.
+func checkRole(ctx context.Context, role auth.Role) error {
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if !principal.Role.Includes(role) {
+		return status.Errorf(codes.PermissionDenied, "role %s required", role)
+	}
+	return nil
+}
+
+func checkAddress(field string, address string) error {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s: %s", field, err)
+	}
+	return nil
+}
+
+// Codes of statuses of application errors with the given codes.
+var statusCodes = map[apperror.Code]codes.Code{
+	apperror.CodeRateLimited:         codes.ResourceExhausted,
+	apperror.CodePolicyViolation:     codes.FailedPrecondition,
+	apperror.CodeWalletFrozen:        codes.FailedPrecondition,
+	apperror.CodeWalletClosed:        codes.FailedPrecondition,
+	apperror.CodeWalletNotFound:      codes.FailedPrecondition,
+	apperror.CodeUnauthenticated:     codes.Unauthenticated,
+	apperror.CodeForbidden:           codes.PermissionDenied,
+	apperror.CodeInvalidInput:        codes.InvalidArgument,
+	apperror.CodeInsufficientBalance: codes.FailedPrecondition,
+}
+
+// toStatus converts an error returned by a service into a gRPC status. Errors without
+// a code are reported with their message, like in the GraphQL API, as failed
+// preconditions. The code of application errors is given in an ErrorInfo detail, and
+// the time to wait before retrying in a RetryInfo detail.
+func toStatus(ctx context.Context, err error) error {
+	logging.FromContext(ctx).Warn("rpc failed", "error", err)
+
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	code, ok := statusCodes[appErr.Code]
+	if !ok {
+		code = codes.FailedPrecondition
+	}
+	st := status.New(code, appErr.Message)
+
+	info := &errdetails.ErrorInfo{Reason: string(appErr.Code), Domain: errorDomain}
+	for key, value := range appErr.Extensions {
+		if info.Metadata == nil {
+			info.Metadata = make(map[string]string, len(appErr.Extensions))
+		}
+		info.Metadata[key] = fmt.Sprint(value)
+	}
+	details := []protoadapt.MessageV1{info}
+	if seconds, ok := appErr.Extensions["retryAfter"].(int); ok {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(seconds) * time.Second)})
+	}
+
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+func newWallet(wallet *model.Wallet) *walletpb.Wallet {
+	return &walletpb.Wallet{
+		Address:      wallet.Address,
+		CreatedAt:    timestamppb.New(wallet.CreatedAt),
+		UpdatedAt:    timestamppb.New(wallet.UpdatedAt),
+		Tokens:       int64(wallet.Tokens),
+		Available:    int64(wallet.Available()),
+		Reserved:     int64(wallet.Reserved),
+		Status:       walletStatuses[wallet.Status],
+		StatusReason: wallet.StatusReason,
+	}
+}
+
+var walletStatuses = map[model.WalletStatus]walletpb.WalletStatus{
+	model.WalletStatusActive: walletpb.WalletStatus_WALLET_STATUS_ACTIVE,
+	model.WalletStatusFrozen: walletpb.WalletStatus_WALLET_STATUS_FROZEN,
+	model.WalletStatusClosed: walletpb.WalletStatus_WALLET_STATUS_CLOSED,
+}
+
+func newTransfer(transfer *model.Transfer) (*walletpb.Transfer, error) {
+	result := &walletpb.Transfer{
+		Id:          uint64(transfer.ID),
+		FromAddress: transfer.FromAddress,
+		ToAddress:   transfer.ToAddress,
+		Amount:      int64(transfer.Amount),
+		CreatedAt:   timestamppb.New(transfer.CreatedAt),
+		Memo:        transfer.Memo,
+	}
+	if transfer.Metadata != nil {
+		metadata, err := structpb.NewStruct(transfer.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		result.Metadata = metadata
+	}
+	return result, nil
+}
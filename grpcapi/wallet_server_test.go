@@ -0,0 +1,375 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/grpcapi/walletpb"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
+	"github.com/kamil7430/TokenTransferAPI/service"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gorm.io/gorm"
+)
+
+const (
+	alice = "0x1111111111111111111111111111111111111111"
+	bob   = "0x2222222222222222222222222222222222222222"
+	carol = "0x3333333333333333333333333333333333333333"
+)
+
+const apiKeys = `
+viewer-key dashboard viewer
+operator-key payments operator
+`
+
+var createdAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeWalletService keeps wallets and transfers in memory, so that watched wallets can
+// be changed while a test runs.
+type fakeWalletService struct {
+	service.WalletServicer
+
+	mu          sync.Mutex
+	wallets     map[string]*model.Wallet
+	transfers   []*model.Transfer
+	transferErr error
+}
+
+func newFakeWalletService() *fakeWalletService {
+	return &fakeWalletService{
+		wallets: map[string]*model.Wallet{
+			alice: {Model: gorm.Model{ID: 1, CreatedAt: createdAt, UpdatedAt: createdAt}, Address: alice, Tokens: 100, Reserved: 30, Status: model.WalletStatusActive},
+			bob:   {Model: gorm.Model{ID: 2, CreatedAt: createdAt, UpdatedAt: createdAt}, Address: bob, Tokens: 10, Status: model.WalletStatusActive},
+		},
+	}
+}
+
+func (f *fakeWalletService) GetWallet(_ context.Context, address string) (*model.Wallet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wallet, ok := f.wallets[address]
+	if !ok {
+		return nil, nil
+	}
+	copied := *wallet
+	return &copied, nil
+}
+
+func (f *fakeWalletService) GetTransfers(_ context.Context, address string, _ *model.TransferFilter, limit int, before *uint) ([]*model.Transfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var transfers []*model.Transfer
+	for i := len(f.transfers) - 1; i >= 0 && len(transfers) < limit; i-- {
+		transfer := f.transfers[i]
+		if before != nil && transfer.ID >= *before {
+			continue
+		}
+		if transfer.FromAddress == address || transfer.ToAddress == address {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
+
+func (f *fakeWalletService) TransferTokens(_ context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any, _ *string) (*model.TransferResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.transferErr != nil {
+		return nil, f.transferErr
+	}
+	recipientCreated := false
+	if _, ok := f.wallets[toAddress]; !ok {
+		f.wallets[toAddress] = &model.Wallet{Model: gorm.Model{CreatedAt: createdAt}, Address: toAddress, Status: model.WalletStatusActive}
+		recipientCreated = true
+	}
+	f.wallets[fromAddress].Tokens -= amount
+	f.wallets[toAddress].Tokens += amount
+
+	transfer := &model.Transfer{
+		Model:       gorm.Model{ID: uint(len(f.transfers) + 1), CreatedAt: createdAt},
+		FromAddress: fromAddress,
+		ToAddress:   toAddress,
+		Amount:      amount,
+		Memo:        memo,
+		Metadata:    metadata,
+	}
+	f.transfers = append(f.transfers, transfer)
+
+	from := *f.wallets[fromAddress]
+	to := *f.wallets[toAddress]
+	return &model.TransferResult{Transfer: transfer, FromWallet: &from, ToWallet: &to, RecipientCreated: recipientCreated}, nil
+}
+
+// dial serves the wallet server over an in-memory listener and returns a connection to it.
+func dial(t *testing.T, walletServer *WalletServer) *grpc.ClientConn {
+	t.Helper()
+
+	keys, err := auth.ParseAPIKeys(strings.NewReader(apiKeys))
+	require.NoError(t, err)
+	if walletServer.WatchInterval == 0 {
+		walletServer.WatchInterval = 10 * time.Millisecond
+	}
+	server := NewServer(slog.Default(), &auth.Authenticator{APIKeys: keys}, walletServer)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func withAPIKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, key)
+}
+
+func TestWalletServer_GetWallet(t *testing.T) {
+	client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{WalletService: newFakeWalletService()}))
+
+	t.Run("returns the wallet", func(t *testing.T) {
+		var header metadata.MD
+		wallet, err := client.GetWallet(withAPIKey("viewer-key"), &walletpb.GetWalletRequest{Address: alice}, grpc.Header(&header))
+
+		require.NoError(t, err)
+		require.Equal(t, alice, wallet.Address)
+		require.Equal(t, int64(100), wallet.Tokens)
+		require.Equal(t, int64(70), wallet.Available)
+		require.Equal(t, int64(30), wallet.Reserved)
+		require.Equal(t, walletpb.WalletStatus_WALLET_STATUS_ACTIVE, wallet.Status)
+		require.True(t, createdAt.Equal(wallet.CreatedAt.AsTime()))
+		require.NotEmpty(t, header.Get(requestIDMetadata))
+	})
+
+	t.Run("keeps the request id of the caller", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(withAPIKey("viewer-key"), requestIDMetadata, "req-42")
+		var header metadata.MD
+		_, err := client.GetWallet(ctx, &walletpb.GetWalletRequest{Address: alice}, grpc.Header(&header))
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"req-42"}, header.Get(requestIDMetadata))
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		_, err := client.GetWallet(withAPIKey("viewer-key"), &walletpb.GetWalletRequest{Address: carol})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := client.GetWallet(withAPIKey("viewer-key"), &walletpb.GetWalletRequest{Address: "0x12"})
+
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("without credentials", func(t *testing.T) {
+		_, err := client.GetWallet(context.Background(), &walletpb.GetWalletRequest{Address: alice})
+
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("with invalid credentials", func(t *testing.T) {
+		_, err := client.GetWallet(withAPIKey("wrong-key"), &walletpb.GetWalletRequest{Address: alice})
+
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestWalletServer_Transfer(t *testing.T) {
+	t.Run("transfers tokens", func(t *testing.T) {
+		client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{WalletService: newFakeWalletService()}))
+		memo := "invoice 17"
+		metadata, err := structpb.NewStruct(map[string]any{"orderId": "A-1", "lines": 3.0})
+		require.NoError(t, err)
+
+		resp, err := client.Transfer(withAPIKey("operator-key"), &walletpb.TransferRequest{
+			FromAddress: alice,
+			ToAddress:   carol,
+			Amount:      25,
+			Memo:        &memo,
+			Metadata:    metadata,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), resp.Transfer.Id)
+		require.Equal(t, int64(25), resp.Transfer.Amount)
+		require.Equal(t, memo, resp.Transfer.GetMemo())
+		require.Equal(t, map[string]any{"orderId": "A-1", "lines": 3.0}, resp.Transfer.Metadata.AsMap())
+		require.Equal(t, int64(75), resp.FromWallet.Tokens)
+		require.Equal(t, int64(25), resp.ToWallet.Tokens)
+		require.True(t, resp.RecipientCreated)
+	})
+
+	t.Run("requires the operator role", func(t *testing.T) {
+		client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{WalletService: newFakeWalletService()}))
+
+		_, err := client.Transfer(withAPIKey("viewer-key"), &walletpb.TransferRequest{FromAddress: alice, ToAddress: bob, Amount: 1})
+
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("reports application errors with their code", func(t *testing.T) {
+		walletService := newFakeWalletService()
+		walletService.transferErr = apperror.New(apperror.CodeWalletFrozen, "sender wallet is frozen")
+		client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{WalletService: walletService}))
+
+		_, err := client.Transfer(withAPIKey("operator-key"), &walletpb.TransferRequest{FromAddress: alice, ToAddress: bob, Amount: 1})
+
+		st := status.Convert(err)
+		require.Equal(t, codes.FailedPrecondition, st.Code())
+		require.Equal(t, "sender wallet is frozen", st.Message())
+		require.Len(t, st.Details(), 1)
+		info := st.Details()[0].(*errdetails.ErrorInfo)
+		require.Equal(t, string(apperror.CodeWalletFrozen), info.Reason)
+		require.Equal(t, errorDomain, info.Domain)
+	})
+
+	t.Run("reports uncoded errors as failed preconditions", func(t *testing.T) {
+		walletService := newFakeWalletService()
+		walletService.transferErr = errors.New("insufficient balance")
+		client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{WalletService: walletService}))
+
+		_, err := client.Transfer(withAPIKey("operator-key"), &walletpb.TransferRequest{FromAddress: alice, ToAddress: bob, Amount: 1})
+
+		st := status.Convert(err)
+		require.Equal(t, codes.FailedPrecondition, st.Code())
+		require.Equal(t, "insufficient balance", st.Message())
+		require.Empty(t, st.Details())
+	})
+
+	t.Run("is rate limited", func(t *testing.T) {
+		client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{
+			WalletService:  newFakeWalletService(),
+			RateLimitStore: ratelimit.NewMemoryStore(),
+			ClientLimit:    ratelimit.Limit{Rate: 0.1, Burst: 1},
+			WalletLimit:    ratelimit.Limit{Rate: 1, Burst: 10},
+		}))
+		req := &walletpb.TransferRequest{FromAddress: alice, ToAddress: bob, Amount: 1}
+
+		_, err := client.Transfer(withAPIKey("operator-key"), req)
+		require.NoError(t, err)
+		_, err = client.Transfer(withAPIKey("operator-key"), req)
+
+		st := status.Convert(err)
+		require.Equal(t, codes.ResourceExhausted, st.Code())
+		require.Len(t, st.Details(), 2)
+		info := st.Details()[0].(*errdetails.ErrorInfo)
+		require.Equal(t, string(apperror.CodeRateLimited), info.Reason)
+		require.Equal(t, "10", info.Metadata["retryAfter"])
+		retry := st.Details()[1].(*errdetails.RetryInfo)
+		require.Equal(t, 10*time.Second, retry.RetryDelay.AsDuration())
+	})
+}
+
+func TestWalletServer_WatchWallet(t *testing.T) {
+	walletService := newFakeWalletService()
+	_, err := walletService.TransferTokens(context.Background(), bob, alice, 5, nil, nil, nil)
+	require.NoError(t, err)
+	client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{WalletService: walletService}))
+
+	ctx, cancel := context.WithCancel(withAPIKey("viewer-key"))
+	defer cancel()
+	stream, err := client.WatchWallet(ctx, &walletpb.WatchWalletRequest{Address: alice})
+	require.NoError(t, err)
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, int64(105), update.Wallet.Tokens)
+	require.Empty(t, update.Transfers)
+
+	_, err = walletService.TransferTokens(context.Background(), alice, bob, 20, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = walletService.TransferTokens(context.Background(), bob, alice, 3, nil, nil, nil)
+	require.NoError(t, err)
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, int64(88), update.Wallet.Tokens)
+	require.Len(t, update.Transfers, 2)
+	require.Equal(t, uint64(2), update.Transfers[0].Id)
+	require.Equal(t, uint64(3), update.Transfers[1].Id)
+
+	walletService.mu.Lock()
+	walletService.wallets[alice].Status = model.WalletStatusFrozen
+	walletService.mu.Unlock()
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, walletpb.WalletStatus_WALLET_STATUS_FROZEN, update.Wallet.Status)
+	require.Empty(t, update.Transfers)
+
+	cancel()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestWalletServer_WatchWalletUnknown(t *testing.T) {
+	client := walletpb.NewWalletServiceClient(dial(t, &WalletServer{WalletService: newFakeWalletService()}))
+
+	stream, err := client.WatchWallet(withAPIKey("viewer-key"), &walletpb.WatchWalletRequest{Address: carol})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestNewServer_HealthAndReflection(t *testing.T) {
+	conn := dial(t, &WalletServer{WalletService: newFakeWalletService()})
+
+	t.Run("health", func(t *testing.T) {
+		resp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{
+			Service: walletpb.WalletService_ServiceDesc.ServiceName,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	})
+
+	t.Run("reflection", func(t *testing.T) {
+		stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		require.NoError(t, err)
+		err = stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+			MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+		})
+		require.NoError(t, err)
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+
+		var services []string
+		for _, service := range resp.GetListServicesResponse().Service {
+			services = append(services, service.Name)
+		}
+		require.Contains(t, services, walletpb.WalletService_ServiceDesc.ServiceName)
+		require.Contains(t, services, grpc_health_v1.Health_ServiceDesc.ServiceName)
+	})
+}
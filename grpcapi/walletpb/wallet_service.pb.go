@@ -0,0 +1,706 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: wallet_service.proto
+
+package walletpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WalletStatus int32
+
+const (
+	WalletStatus_WALLET_STATUS_UNSPECIFIED WalletStatus = 0
+	// The wallet can send and receive tokens.
+	WalletStatus_WALLET_STATUS_ACTIVE WalletStatus = 1
+	// The wallet can't send tokens, and may be prevented from receiving them.
+	WalletStatus_WALLET_STATUS_FROZEN WalletStatus = 2
+	// The wallet can neither send nor receive tokens.
+	WalletStatus_WALLET_STATUS_CLOSED WalletStatus = 3
+)
+
+// Enum value maps for WalletStatus.
+var (
+	WalletStatus_name = map[int32]string{
+		0: "WALLET_STATUS_UNSPECIFIED",
+		1: "WALLET_STATUS_ACTIVE",
+		2: "WALLET_STATUS_FROZEN",
+		3: "WALLET_STATUS_CLOSED",
+	}
+	WalletStatus_value = map[string]int32{
+		"WALLET_STATUS_UNSPECIFIED": 0,
+		"WALLET_STATUS_ACTIVE":      1,
+		"WALLET_STATUS_FROZEN":      2,
+		"WALLET_STATUS_CLOSED":      3,
+	}
+)
+
+func (x WalletStatus) Enum() *WalletStatus {
+	p := new(WalletStatus)
+	*p = x
+	return p
+}
+
+func (x WalletStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WalletStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_wallet_service_proto_enumTypes[0].Descriptor()
+}
+
+func (WalletStatus) Type() protoreflect.EnumType {
+	return &file_wallet_service_proto_enumTypes[0]
+}
+
+func (x WalletStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WalletStatus.Descriptor instead.
+func (WalletStatus) EnumDescriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{0}
+}
+
+type Wallet struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Address   string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Total balance, including reserved tokens.
+	Tokens int64 `protobuf:"varint,4,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	// Tokens that can be transferred or held.
+	Available int64 `protobuf:"varint,5,opt,name=available,proto3" json:"available,omitempty"`
+	// Tokens reserved by active holds.
+	Reserved int64        `protobuf:"varint,6,opt,name=reserved,proto3" json:"reserved,omitempty"`
+	Status   WalletStatus `protobuf:"varint,7,opt,name=status,proto3,enum=tokentransfer.v1.WalletStatus" json:"status,omitempty"`
+	// Reason given for the last status change.
+	StatusReason  *string `protobuf:"bytes,8,opt,name=status_reason,json=statusReason,proto3,oneof" json:"status_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Wallet) Reset() {
+	*x = Wallet{}
+	mi := &file_wallet_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Wallet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wallet) ProtoMessage() {}
+
+func (x *Wallet) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wallet.ProtoReflect.Descriptor instead.
+func (*Wallet) Descriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Wallet) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Wallet) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Wallet) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Wallet) GetTokens() int64 {
+	if x != nil {
+		return x.Tokens
+	}
+	return 0
+}
+
+func (x *Wallet) GetAvailable() int64 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *Wallet) GetReserved() int64 {
+	if x != nil {
+		return x.Reserved
+	}
+	return 0
+}
+
+func (x *Wallet) GetStatus() WalletStatus {
+	if x != nil {
+		return x.Status
+	}
+	return WalletStatus_WALLET_STATUS_UNSPECIFIED
+}
+
+func (x *Wallet) GetStatusReason() string {
+	if x != nil && x.StatusReason != nil {
+		return *x.StatusReason
+	}
+	return ""
+}
+
+// A ledger entry recording a single movement of tokens between wallets.
+type Transfer struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	FromAddress string                 `protobuf:"bytes,2,opt,name=from_address,json=fromAddress,proto3" json:"from_address,omitempty"`
+	ToAddress   string                 `protobuf:"bytes,3,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
+	Amount      int64                  `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Note attached by the sender.
+	Memo *string `protobuf:"bytes,6,opt,name=memo,proto3,oneof" json:"memo,omitempty"`
+	// JSON object attached by the sender.
+	Metadata      *structpb.Struct `protobuf:"bytes,7,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transfer) Reset() {
+	*x = Transfer{}
+	mi := &file_wallet_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transfer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transfer) ProtoMessage() {}
+
+func (x *Transfer) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transfer.ProtoReflect.Descriptor instead.
+func (*Transfer) Descriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Transfer) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transfer) GetFromAddress() string {
+	if x != nil {
+		return x.FromAddress
+	}
+	return ""
+}
+
+func (x *Transfer) GetToAddress() string {
+	if x != nil {
+		return x.ToAddress
+	}
+	return ""
+}
+
+func (x *Transfer) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Transfer) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Transfer) GetMemo() string {
+	if x != nil && x.Memo != nil {
+		return *x.Memo
+	}
+	return ""
+}
+
+func (x *Transfer) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GetWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletRequest) Reset() {
+	*x = GetWalletRequest{}
+	mi := &file_wallet_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletRequest) ProtoMessage() {}
+
+func (x *GetWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletRequest.ProtoReflect.Descriptor instead.
+func (*GetWalletRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetWalletRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type TransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromAddress   string                 `protobuf:"bytes,1,opt,name=from_address,json=fromAddress,proto3" json:"from_address,omitempty"`
+	ToAddress     string                 `protobuf:"bytes,2,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
+	Amount        int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Memo          *string                `protobuf:"bytes,4,opt,name=memo,proto3,oneof" json:"memo,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferRequest) Reset() {
+	*x = TransferRequest{}
+	mi := &file_wallet_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferRequest) ProtoMessage() {}
+
+func (x *TransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferRequest.ProtoReflect.Descriptor instead.
+func (*TransferRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TransferRequest) GetFromAddress() string {
+	if x != nil {
+		return x.FromAddress
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetToAddress() string {
+	if x != nil {
+		return x.ToAddress
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *TransferRequest) GetMemo() string {
+	if x != nil && x.Memo != nil {
+		return *x.Memo
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type TransferResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The ledger entry of the transfer.
+	Transfer *Transfer `protobuf:"bytes,1,opt,name=transfer,proto3" json:"transfer,omitempty"`
+	// The sender's wallet after the transfer.
+	FromWallet *Wallet `protobuf:"bytes,2,opt,name=from_wallet,json=fromWallet,proto3" json:"from_wallet,omitempty"`
+	// The recipient's wallet after the transfer.
+	ToWallet *Wallet `protobuf:"bytes,3,opt,name=to_wallet,json=toWallet,proto3" json:"to_wallet,omitempty"`
+	// Whether the recipient's wallet was created by the transfer.
+	RecipientCreated bool `protobuf:"varint,4,opt,name=recipient_created,json=recipientCreated,proto3" json:"recipient_created,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TransferResponse) Reset() {
+	*x = TransferResponse{}
+	mi := &file_wallet_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferResponse) ProtoMessage() {}
+
+func (x *TransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferResponse.ProtoReflect.Descriptor instead.
+func (*TransferResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TransferResponse) GetTransfer() *Transfer {
+	if x != nil {
+		return x.Transfer
+	}
+	return nil
+}
+
+func (x *TransferResponse) GetFromWallet() *Wallet {
+	if x != nil {
+		return x.FromWallet
+	}
+	return nil
+}
+
+func (x *TransferResponse) GetToWallet() *Wallet {
+	if x != nil {
+		return x.ToWallet
+	}
+	return nil
+}
+
+func (x *TransferResponse) GetRecipientCreated() bool {
+	if x != nil {
+		return x.RecipientCreated
+	}
+	return false
+}
+
+type WatchWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchWalletRequest) Reset() {
+	*x = WatchWalletRequest{}
+	mi := &file_wallet_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchWalletRequest) ProtoMessage() {}
+
+func (x *WatchWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchWalletRequest.ProtoReflect.Descriptor instead.
+func (*WatchWalletRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchWalletRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type WalletUpdate struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Wallet *Wallet                `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	// Transfers sent or received by the wallet since the previous update, oldest first.
+	// Empty in the first update.
+	Transfers     []*Transfer `protobuf:"bytes,2,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletUpdate) Reset() {
+	*x = WalletUpdate{}
+	mi := &file_wallet_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletUpdate) ProtoMessage() {}
+
+func (x *WalletUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletUpdate.ProtoReflect.Descriptor instead.
+func (*WalletUpdate) Descriptor() ([]byte, []int) {
+	return file_wallet_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WalletUpdate) GetWallet() *Wallet {
+	if x != nil {
+		return x.Wallet
+	}
+	return nil
+}
+
+func (x *WalletUpdate) GetTransfers() []*Transfer {
+	if x != nil {
+		return x.Transfers
+	}
+	return nil
+}
+
+var File_wallet_service_proto protoreflect.FileDescriptor
+
+const file_wallet_service_proto_rawDesc = "" +
+	"\n" +
+	"\x14wallet_service.proto\x12\x10tokentransfer.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xde\x02\n" +
+	"\x06Wallet\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x16\n" +
+	"\x06tokens\x18\x04 \x01(\x03R\x06tokens\x12\x1c\n" +
+	"\tavailable\x18\x05 \x01(\x03R\tavailable\x12\x1a\n" +
+	"\breserved\x18\x06 \x01(\x03R\breserved\x126\n" +
+	"\x06status\x18\a \x01(\x0e2\x1e.tokentransfer.v1.WalletStatusR\x06status\x12(\n" +
+	"\rstatus_reason\x18\b \x01(\tH\x00R\fstatusReason\x88\x01\x01B\x10\n" +
+	"\x0e_status_reason\"\x86\x02\n" +
+	"\bTransfer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12!\n" +
+	"\ffrom_address\x18\x02 \x01(\tR\vfromAddress\x12\x1d\n" +
+	"\n" +
+	"to_address\x18\x03 \x01(\tR\ttoAddress\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x03R\x06amount\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x17\n" +
+	"\x04memo\x18\x06 \x01(\tH\x00R\x04memo\x88\x01\x01\x123\n" +
+	"\bmetadata\x18\a \x01(\v2\x17.google.protobuf.StructR\bmetadataB\a\n" +
+	"\x05_memo\",\n" +
+	"\x10GetWalletRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"\xc2\x01\n" +
+	"\x0fTransferRequest\x12!\n" +
+	"\ffrom_address\x18\x01 \x01(\tR\vfromAddress\x12\x1d\n" +
+	"\n" +
+	"to_address\x18\x02 \x01(\tR\ttoAddress\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x03R\x06amount\x12\x17\n" +
+	"\x04memo\x18\x04 \x01(\tH\x00R\x04memo\x88\x01\x01\x123\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadataB\a\n" +
+	"\x05_memo\"\xe9\x01\n" +
+	"\x10TransferResponse\x126\n" +
+	"\btransfer\x18\x01 \x01(\v2\x1a.tokentransfer.v1.TransferR\btransfer\x129\n" +
+	"\vfrom_wallet\x18\x02 \x01(\v2\x18.tokentransfer.v1.WalletR\n" +
+	"fromWallet\x125\n" +
+	"\tto_wallet\x18\x03 \x01(\v2\x18.tokentransfer.v1.WalletR\btoWallet\x12+\n" +
+	"\x11recipient_created\x18\x04 \x01(\bR\x10recipientCreated\".\n" +
+	"\x12WatchWalletRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"z\n" +
+	"\fWalletUpdate\x120\n" +
+	"\x06wallet\x18\x01 \x01(\v2\x18.tokentransfer.v1.WalletR\x06wallet\x128\n" +
+	"\ttransfers\x18\x02 \x03(\v2\x1a.tokentransfer.v1.TransferR\ttransfers*{\n" +
+	"\fWalletStatus\x12\x1d\n" +
+	"\x19WALLET_STATUS_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14WALLET_STATUS_ACTIVE\x10\x01\x12\x18\n" +
+	"\x14WALLET_STATUS_FROZEN\x10\x02\x12\x18\n" +
+	"\x14WALLET_STATUS_CLOSED\x10\x032\x84\x02\n" +
+	"\rWalletService\x12I\n" +
+	"\tGetWallet\x12\".tokentransfer.v1.GetWalletRequest\x1a\x18.tokentransfer.v1.Wallet\x12Q\n" +
+	"\bTransfer\x12!.tokentransfer.v1.TransferRequest\x1a\".tokentransfer.v1.TransferResponse\x12U\n" +
+	"\vWatchWallet\x12$.tokentransfer.v1.WatchWalletRequest\x1a\x1e.tokentransfer.v1.WalletUpdate0\x01B8Z6github.com/kamil7430/TokenTransferAPI/grpcapi/walletpbb\x06proto3"
+
+var (
+	file_wallet_service_proto_rawDescOnce sync.Once
+	file_wallet_service_proto_rawDescData []byte
+)
+
+func file_wallet_service_proto_rawDescGZIP() []byte {
+	file_wallet_service_proto_rawDescOnce.Do(func() {
+		file_wallet_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_wallet_service_proto_rawDesc), len(file_wallet_service_proto_rawDesc)))
+	})
+	return file_wallet_service_proto_rawDescData
+}
+
+var file_wallet_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_wallet_service_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_wallet_service_proto_goTypes = []any{
+	(WalletStatus)(0),             // 0: tokentransfer.v1.WalletStatus
+	(*Wallet)(nil),                // 1: tokentransfer.v1.Wallet
+	(*Transfer)(nil),              // 2: tokentransfer.v1.Transfer
+	(*GetWalletRequest)(nil),      // 3: tokentransfer.v1.GetWalletRequest
+	(*TransferRequest)(nil),       // 4: tokentransfer.v1.TransferRequest
+	(*TransferResponse)(nil),      // 5: tokentransfer.v1.TransferResponse
+	(*WatchWalletRequest)(nil),    // 6: tokentransfer.v1.WatchWalletRequest
+	(*WalletUpdate)(nil),          // 7: tokentransfer.v1.WalletUpdate
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 9: google.protobuf.Struct
+}
+var file_wallet_service_proto_depIdxs = []int32{
+	8,  // 0: tokentransfer.v1.Wallet.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 1: tokentransfer.v1.Wallet.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: tokentransfer.v1.Wallet.status:type_name -> tokentransfer.v1.WalletStatus
+	8,  // 3: tokentransfer.v1.Transfer.created_at:type_name -> google.protobuf.Timestamp
+	9,  // 4: tokentransfer.v1.Transfer.metadata:type_name -> google.protobuf.Struct
+	9,  // 5: tokentransfer.v1.TransferRequest.metadata:type_name -> google.protobuf.Struct
+	2,  // 6: tokentransfer.v1.TransferResponse.transfer:type_name -> tokentransfer.v1.Transfer
+	1,  // 7: tokentransfer.v1.TransferResponse.from_wallet:type_name -> tokentransfer.v1.Wallet
+	1,  // 8: tokentransfer.v1.TransferResponse.to_wallet:type_name -> tokentransfer.v1.Wallet
+	1,  // 9: tokentransfer.v1.WalletUpdate.wallet:type_name -> tokentransfer.v1.Wallet
+	2,  // 10: tokentransfer.v1.WalletUpdate.transfers:type_name -> tokentransfer.v1.Transfer
+	3,  // 11: tokentransfer.v1.WalletService.GetWallet:input_type -> tokentransfer.v1.GetWalletRequest
+	4,  // 12: tokentransfer.v1.WalletService.Transfer:input_type -> tokentransfer.v1.TransferRequest
+	6,  // 13: tokentransfer.v1.WalletService.WatchWallet:input_type -> tokentransfer.v1.WatchWalletRequest
+	1,  // 14: tokentransfer.v1.WalletService.GetWallet:output_type -> tokentransfer.v1.Wallet
+	5,  // 15: tokentransfer.v1.WalletService.Transfer:output_type -> tokentransfer.v1.TransferResponse
+	7,  // 16: tokentransfer.v1.WalletService.WatchWallet:output_type -> tokentransfer.v1.WalletUpdate
+	14, // [14:17] is the sub-list for method output_type
+	11, // [11:14] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_wallet_service_proto_init() }
+func file_wallet_service_proto_init() {
+	if File_wallet_service_proto != nil {
+		return
+	}
+	file_wallet_service_proto_msgTypes[0].OneofWrappers = []any{}
+	file_wallet_service_proto_msgTypes[1].OneofWrappers = []any{}
+	file_wallet_service_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wallet_service_proto_rawDesc), len(file_wallet_service_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wallet_service_proto_goTypes,
+		DependencyIndexes: file_wallet_service_proto_depIdxs,
+		EnumInfos:         file_wallet_service_proto_enumTypes,
+		MessageInfos:      file_wallet_service_proto_msgTypes,
+	}.Build()
+	File_wallet_service_proto = out.File
+	file_wallet_service_proto_goTypes = nil
+	file_wallet_service_proto_depIdxs = nil
+}
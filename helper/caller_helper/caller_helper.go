@@ -0,0 +1,37 @@
+package caller_helper
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx identifying the caller of the current request.
+func NewContext(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, contextKey{}, caller)
+}
+
+// FromContext returns the caller stored in ctx, or an empty string if there is none.
+func FromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(contextKey{}).(string)
+	return caller
+}
+
+// FromRequest identifies an unauthenticated caller by the host part of its address.
+func FromRequest(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware stores the caller's address in the request context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(r.Context(), FromRequest(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/kamil7430/TokenTransferAPI/service"
+)
+
+// Exit codes of the import command, which exits with exitError if the import couldn't be
+// made.
+const (
+	exitImported       = 0
+	exitImportRejected = 1
+)
+
+// runImport imports the CSV file given in args (or the standard input, if it's "-"),
+// writes the import report to w as JSON and returns the exit code of the import command.
+func runImport(ctx context.Context, importService *service.ImportService, args []string, stdin io.Reader, w io.Writer) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without importing it")
+	err := flags.Parse(args)
+	if err != nil {
+		return exitError
+	}
+	if flags.NArg() != 1 {
+		slog.Error("usage: import [-dry-run] FILE")
+		return exitError
+	}
+
+	r := stdin
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			slog.Error("opening import file failed", "error", err)
+			return exitError
+		}
+		defer file.Close()
+		r = file
+	}
+
+	report, err := importService.Import(ctx, r, *dryRun)
+	if err != nil {
+		slog.Error("import failed", "error", err)
+		return exitError
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		slog.Error("writing import report failed", "error", err)
+		return exitError
+	}
+
+	if len(report.Errors) > 0 {
+		return exitImportRejected
+	}
+	return exitImported
+}
@@ -0,0 +1,48 @@
+// Package testdb starts Postgres databases for tests.
+package testdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// New starts a Postgres container for the test and migrates the given models.
+func New(t *testing.T, dbname string, models ...any) *gorm.DB {
+	ctx := context.Background()
+	dbuser := "user"
+	dbpassword := "password"
+
+	ctr, err := postgres.Run(
+		ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase(dbname),
+		postgres.WithUsername(dbuser),
+		postgres.WithPassword(dbpassword),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+	)
+	testcontainers.CleanupContainer(t, ctr)
+	require.NoError(t, err)
+
+	err = ctr.Snapshot(ctx)
+	require.NoError(t, err)
+
+	dbURL, err := ctr.ConnectionString(ctx)
+	require.NoError(t, err)
+
+	db, err := gorm.Open(gormpostgres.Open(dbURL), &gorm.Config{
+		TranslateError: true,
+	})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(models...)
+	require.NoError(t, err)
+
+	return db
+}
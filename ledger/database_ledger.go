@@ -0,0 +1,160 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+)
+
+type DatabaseLedger struct {
+}
+
+// Migrate creates the tables of the ledger, together with triggers guaranteeing that
+// every journal entry sums up to zero when its transaction commits, and that postings are
+// never changed afterwards.
+func Migrate(db *gorm.DB) error {
+	err := db.AutoMigrate(&JournalEntry{}, &Posting{})
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`
+		CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+		BEGIN
+			IF (SELECT SUM(amount) FROM postings WHERE journal_entry_id = NEW.journal_entry_id) <> 0 THEN
+				RAISE EXCEPTION 'journal entry % does not balance', NEW.journal_entry_id;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql;
+
+		CREATE OR REPLACE FUNCTION reject_posting_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'postings can not be changed';
+		END
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS postings_balanced ON postings;
+		CREATE CONSTRAINT TRIGGER postings_balanced AFTER INSERT ON postings
+			DEFERRABLE INITIALLY DEFERRED
+			FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+		DROP TRIGGER IF EXISTS postings_immutable ON postings;
+		CREATE TRIGGER postings_immutable BEFORE UPDATE OR DELETE ON postings
+			FOR EACH ROW EXECUTE FUNCTION reject_posting_change();`).Error
+}
+
+// Backfill records the balances of a database created before the ledger in the journal,
+// so that they can be reconciled with it. Transfers without a journal entry get one, and
+// every wallet whose balance then differs from its postings gets an opening entry issuing
+// the difference. It does nothing once tokens were issued by the journal, so it runs only
+// once; later differences are drift to be found by reconciliation, not opening balances.
+func Backfill(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var issued bool
+		err := tx.Raw("SELECT EXISTS (SELECT 1 FROM postings WHERE account = ?)", IssuanceAccount).Scan(&issued).Error
+		if err != nil || issued {
+			return err
+		}
+
+		err = tx.Exec(`
+			INSERT INTO journal_entries (created_at, updated_at, transfer_id, description)
+			SELECT transfers.created_at, now(), transfers.id,
+				CASE WHEN transfers.reversal_of_id IS NULL THEN 'transfer' ELSE 'reversal' END
+			FROM transfers
+			WHERE transfers.deleted_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM journal_entries WHERE journal_entries.transfer_id = transfers.id)`).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			INSERT INTO postings (journal_entry_id, account, amount)
+			SELECT journal_entries.id, transfers.from_address, -transfers.amount
+			FROM journal_entries JOIN transfers ON transfers.id = journal_entries.transfer_id
+			WHERE NOT EXISTS (SELECT 1 FROM postings WHERE postings.journal_entry_id = journal_entries.id)
+			UNION ALL
+			SELECT journal_entries.id, transfers.to_address, transfers.amount
+			FROM journal_entries JOIN transfers ON transfers.id = journal_entries.transfer_id
+			WHERE NOT EXISTS (SELECT 1 FROM postings WHERE postings.journal_entry_id = journal_entries.id)`).Error
+		if err != nil {
+			return err
+		}
+
+		var openings []struct {
+			Address string
+			Amount  int
+		}
+		err = tx.Raw(`
+			SELECT wallets.address, wallets.tokens - COALESCE(posted.amount, 0) AS amount
+			FROM wallets
+			LEFT JOIN (
+				SELECT account, SUM(amount) AS amount
+				FROM postings
+				GROUP BY account
+			) AS posted ON posted.account = wallets.address
+			WHERE wallets.deleted_at IS NULL AND wallets.tokens <> COALESCE(posted.amount, 0)
+			ORDER BY wallets.address`).Scan(&openings).Error
+		if err != nil || len(openings) == 0 {
+			return err
+		}
+
+		entries := make([]*JournalEntry, 0, len(openings))
+		for _, opening := range openings {
+			entry := NewIssuanceEntry(opening.Address, opening.Amount)
+			entry.Description = "opening balance"
+			entries = append(entries, entry)
+		}
+		err = tx.CreateInBatches(entries, 1000).Error
+		if err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("journal backfilled", "opening_entries", len(entries))
+		return nil
+	})
+}
+
+func (d *DatabaseLedger) Post(ctx context.Context, tx *gorm.DB, entry *JournalEntry) error {
+	err := Check(entry)
+	if err != nil {
+		return err
+	}
+
+	err = gorm.G[JournalEntry](tx).Create(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	for _, posting := range entry.Postings {
+		if !IsWalletAccount(posting.Account) {
+			continue
+		}
+		rows, err := gorm.G[model.Wallet](tx).
+			Where("Address = ?", posting.Account).
+			Update(ctx, "Tokens", gorm.Expr("Tokens + ?", posting.Amount))
+		if err != nil {
+			return err
+		}
+		if rows != 1 {
+			return errors.New(fmt.Sprintf("affected %d rows, expected 1", rows))
+		}
+	}
+
+	logging.FromContext(ctx).Debug("journal entry posted", "journal_entry_id", entry.ID)
+	return nil
+}
+
+func (d *DatabaseLedger) Balance(ctx context.Context, tx *gorm.DB, account string) (int, error) {
+	var balance int
+	err := tx.WithContext(ctx).Model(&Posting{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("account = ?", account).
+		Scan(&balance).Error
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
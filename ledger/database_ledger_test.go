@@ -0,0 +1,104 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseLedger(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "ledgerTests", &model.Wallet{}, &model.Transfer{})
+	require.NoError(t, Migrate(db))
+
+	d := DatabaseLedger{}
+
+	t.Run("post entries", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Journal_Entries, Postings")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 0)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 0)
+
+		err := d.Post(ctx, db, NewIssuanceEntry("0x0000000000000000000000000000000000000001", 100))
+		require.NoError(t, err)
+		err = d.Post(ctx, db, NewTransferEntry(&model.Transfer{
+			FromAddress: "0x0000000000000000000000000000000000000001",
+			ToAddress:   "0x0000000000000000000000000000000000000002",
+			Amount:      60,
+		}))
+		require.NoError(t, err)
+
+		balance, err := d.Balance(ctx, db, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 40, balance)
+		balance, err = d.Balance(ctx, db, IssuanceAccount)
+		require.NoError(t, err)
+		require.Equal(t, -100, balance)
+
+		var wallet model.Wallet
+		require.NoError(t, db.Where("Address = ?", "0x0000000000000000000000000000000000000002").First(&wallet).Error)
+		require.Equal(t, 60, wallet.Tokens)
+	})
+
+	t.Run("backfill balances recorded before the ledger", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Journal_Entries, Postings")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000000", 700)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 300)
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount) VALUES ($1, $2, $3)",
+			"0x0000000000000000000000000000000000000000", "0x0000000000000000000000000000000000000001", 300)
+
+		require.NoError(t, Backfill(ctx, db))
+
+		for account, expected := range map[string]int{
+			"0x0000000000000000000000000000000000000000": 700,
+			"0x0000000000000000000000000000000000000001": 300,
+			IssuanceAccount: -1000,
+		} {
+			balance, err := d.Balance(ctx, db, account)
+			require.NoError(t, err)
+			require.Equal(t, expected, balance, account)
+		}
+
+		// Once tokens are issued by the journal, differences are drift and aren't backfilled.
+		db.Exec("UPDATE Wallets SET Tokens = Tokens + 10 WHERE Address = $1", "0x0000000000000000000000000000000000000001")
+		require.NoError(t, Backfill(ctx, db))
+		var entries int64
+		db.Model(&JournalEntry{}).Count(&entries)
+		require.Equal(t, int64(2), entries)
+	})
+
+	t.Run("post to non-existing wallet", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Journal_Entries, Postings")
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return d.Post(ctx, tx, NewIssuanceEntry("0x0000000000000000000000000000000000000001", 100))
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("unbalanced postings are rejected by the database", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Journal_Entries, Postings")
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			entry := &JournalEntry{Description: "manual edit"}
+			err := tx.Create(entry).Error
+			if err != nil {
+				return err
+			}
+			return tx.Create(&Posting{JournalEntryID: entry.ID, Account: IssuanceAccount, Amount: -10}).Error
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("postings are immutable", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Journal_Entries, Postings")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 0)
+		require.NoError(t, d.Post(ctx, db, NewIssuanceEntry("0x0000000000000000000000000000000000000001", 100)))
+
+		err := db.Exec("UPDATE Postings SET Amount = 200 WHERE Account = ?", "0x0000000000000000000000000000000000000001").Error
+		require.Error(t, err)
+	})
+}
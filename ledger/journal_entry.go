@@ -0,0 +1,90 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+// Prefix of accounts that don't belong to wallets. The accounts of wallets are their
+// addresses.
+const systemAccountPrefix = "system:"
+
+// IssuanceAccount is debited with the tokens issued to wallets, so its balance is the
+// negated total supply.
+const IssuanceAccount = systemAccountPrefix + "issuance"
+
+var ErrUnbalanced = errors.New("journal entry does not balance")
+
+// JournalEntry records a single movement of tokens as postings to accounts, which always
+// sum up to zero.
+type JournalEntry struct {
+	gorm.Model
+	// Transfer recorded by the entry, if any.
+	TransferID  *uint `gorm:"uniqueIndex"`
+	Description string
+	Postings    []Posting
+}
+
+// Posting credits an account with a positive amount or debits it with a negative amount.
+type Posting struct {
+	ID             uint   `gorm:"primarykey"`
+	JournalEntryID uint   `gorm:"index"`
+	Account        string `gorm:"index"`
+	Amount         int
+}
+
+// NewTransferEntry returns the entry moving the tokens of the transfer from the sender's
+// account to the recipient's account.
+func NewTransferEntry(transfer *model.Transfer) *JournalEntry {
+	description := "transfer"
+	if transfer.ReversalOfID != nil {
+		description = "reversal"
+	}
+	return &JournalEntry{
+		TransferID:  &transfer.ID,
+		Description: description,
+		Postings: []Posting{
+			{Account: transfer.FromAddress, Amount: -transfer.Amount},
+			{Account: transfer.ToAddress, Amount: transfer.Amount},
+		},
+	}
+}
+
+// NewIssuanceEntry returns the entry issuing amount new tokens to the account.
+func NewIssuanceEntry(account string, amount int) *JournalEntry {
+	return &JournalEntry{
+		Description: "issuance",
+		Postings: []Posting{
+			{Account: IssuanceAccount, Amount: -amount},
+			{Account: account, Amount: amount},
+		},
+	}
+}
+
+// Check verifies that the entry has at least two non-zero postings summing up to zero.
+func Check(entry *JournalEntry) error {
+	if len(entry.Postings) < 2 {
+		return fmt.Errorf("%w: it must have at least two postings", ErrUnbalanced)
+	}
+	sum := 0
+	for _, posting := range entry.Postings {
+		if posting.Amount == 0 {
+			return fmt.Errorf("posting to %s has no amount", posting.Account)
+		}
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("%w: postings sum up to %d", ErrUnbalanced, sum)
+	}
+	return nil
+}
+
+// IsWalletAccount reports whether the account belongs to a wallet, so that postings to it
+// change the wallet's balance.
+func IsWalletAccount(account string) bool {
+	return !strings.HasPrefix(account, systemAccountPrefix)
+}
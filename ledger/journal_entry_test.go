@@ -0,0 +1,33 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	transfer := &model.Transfer{
+		FromAddress: "0x0000000000000000000000000000000000000001",
+		ToAddress:   "0x0000000000000000000000000000000000000002",
+		Amount:      60,
+	}
+	require.NoError(t, Check(NewTransferEntry(transfer)))
+	require.NoError(t, Check(NewIssuanceEntry("0x0000000000000000000000000000000000000001", 100)))
+
+	err := Check(&JournalEntry{Postings: []Posting{
+		{Account: "0x0000000000000000000000000000000000000001", Amount: -60},
+		{Account: "0x0000000000000000000000000000000000000002", Amount: 50},
+	}})
+	require.True(t, errors.Is(err, ErrUnbalanced))
+
+	err = Check(&JournalEntry{Postings: []Posting{{Account: IssuanceAccount, Amount: 0}}})
+	require.Error(t, err)
+}
+
+func TestIsWalletAccount(t *testing.T) {
+	require.True(t, IsWalletAccount("0x0000000000000000000000000000000000000001"))
+	require.False(t, IsWalletAccount(IssuanceAccount))
+}
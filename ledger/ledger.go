@@ -0,0 +1,17 @@
+package ledger
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Ledger records movements of tokens as balanced journal entries. Balances of wallets are
+// a projection of the postings to their accounts, kept up to date by Post.
+type Ledger interface {
+	// Post records the entry and applies its postings to the balances of wallets. Wallets
+	// must be locked by the caller.
+	Post(ctx context.Context, tx *gorm.DB, entry *JournalEntry) error
+	// Balance returns the balance of the account derived from all its postings.
+	Balance(ctx context.Context, tx *gorm.DB, account string) (int, error)
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+type contextRequestIDKey struct{}
+
+// Middleware assigns every request an ID (taken from the X-Request-ID header or generated)
+// and stores a logger carrying that ID and the caller in the request context. The caller
+// is taken from caller_helper.Middleware, which has to run first.
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		ctx, requestID := NewRequestContext(r.Context(), logger, r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, requestID)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		FromContext(ctx).Info("request handled",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+// NewRequestContext returns a copy of ctx carrying the request ID and a logger with that
+// ID and the caller stored by caller_helper. The ID is requestID if it is valid, or a
+// generated one otherwise; it is also returned.
+func NewRequestContext(ctx context.Context, logger *slog.Logger, requestID string) (context.Context, string) {
+	if !isValidRequestID(requestID) {
+		requestID = newRequestID()
+	}
+
+	requestLogger := logger.With(
+		"request_id", requestID,
+		"caller", caller_helper.FromContext(ctx),
+	)
+	ctx = NewContext(ctx, requestLogger)
+	ctx = context.WithValue(ctx, contextRequestIDKey{}, requestID)
+	return ctx, requestID
+}
+
+// RequestID returns the ID assigned to the request by Middleware, or an empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextRequestIDKey{}).(string)
+	return requestID
+}
+
+func isValidRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		// Only visible ASCII characters, so that the ID can't break log lines.
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf) // never returns an error
+	return hex.EncodeToString(buf)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Hijack allows the connection to be upgraded, e.g. to a websocket for GraphQL
+// subscriptions.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(s.ResponseWriter).Hijack()
+	if err == nil {
+		s.status = http.StatusSwitchingProtocols
+		s.wroteHeader = true
+	}
+	return conn, rw, err
+}
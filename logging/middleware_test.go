@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var seenRequestID string
+	handler := caller_helper.Middleware(Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenRequestID = RequestID(r.Context())
+		FromContext(r.Context()).Info("inside handler")
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	t.Run("accepts request id from header", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		req.Header.Set(RequestIDHeader, "abc-123")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, "abc-123", seenRequestID)
+		require.Equal(t, "abc-123", rec.Header().Get(RequestIDHeader))
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		require.Len(t, lines, 2)
+		for _, line := range lines {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(line, &entry))
+			require.Equal(t, "abc-123", entry["request_id"])
+			require.Equal(t, "192.0.2.1", entry["caller"])
+		}
+
+		var last map[string]any
+		require.NoError(t, json.Unmarshal(lines[1], &last))
+		require.EqualValues(t, http.StatusTeapot, last["status"])
+	})
+
+	t.Run("generates request id when missing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Len(t, seenRequestID, 32)
+		require.Equal(t, seenRequestID, rec.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("replaces invalid request id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.NotEqual(t, "bad id\nwith newline", seenRequestID)
+		require.Len(t, seenRequestID, 32)
+	})
+}
+
+// hijackableRecorder records responses of handlers that may take over the connection.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestMiddleware_Hijack_ShouldLogSwitchingProtocols(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+	}))
+	rec := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/query", nil))
+
+	require.True(t, rec.hijacked)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "request handled", entry["msg"])
+	require.Equal(t, float64(http.StatusSwitchingProtocols), entry["status"])
+}
+
+func TestFromContext_NoLogger_ShouldReturnDefault(t *testing.T) {
+	require.Equal(t, slog.Default(), FromContext(t.Context()))
+}
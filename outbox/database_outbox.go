@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Key of the advisory lock taken by the relay.
+const relayLockKey = 7430_0046
+
+type DatabaseOutbox struct {
+}
+
+// Maximum number of events inserted by a single statement.
+const addBatchSize = 1000
+
+func (d *DatabaseOutbox) Add(ctx context.Context, tx *gorm.DB, events ...*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, event := range events {
+		event.NextAttemptAt = now
+	}
+	// Rows of a single INSERT get their IDs in order, so events keep the order they
+	// were passed in.
+	return tx.WithContext(ctx).CreateInBatches(events, addBatchSize).Error
+}
+
+func (d *DatabaseOutbox) TryLockRelay(ctx context.Context, db *gorm.DB) (bool, error) {
+	var locked bool
+	err := db.WithContext(ctx).Raw("SELECT pg_try_advisory_lock(?)", relayLockKey).Scan(&locked).Error
+	if err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func (d *DatabaseOutbox) UnlockRelay(ctx context.Context, db *gorm.DB) error {
+	var unlocked bool
+	err := db.WithContext(ctx).Raw("SELECT pg_advisory_unlock(?)", relayLockKey).Scan(&unlocked).Error
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return errors.New("relay lock was not held")
+	}
+	return nil
+}
+
+func (d *DatabaseOutbox) GetDeliverableEvents(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]Event, error) {
+	return gorm.G[Event](tx).
+		Where("Delivered_At IS NULL AND Next_Attempt_At <= ?", now).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox_events AS earlier
+			WHERE earlier.address = outbox_events.address AND earlier.id < outbox_events.id
+				AND earlier.delivered_at IS NULL AND earlier.next_attempt_at > ?)`, now).
+		Order("ID").
+		Limit(limit).
+		Find(ctx)
+}
+
+func (d *DatabaseOutbox) MarkDelivered(ctx context.Context, tx *gorm.DB, id uint, deliveredAt time.Time) error {
+	rows, err := gorm.G[Event](tx).
+		Where("ID = ?", id).
+		Update(ctx, "Delivered_At", deliveredAt)
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return errors.New(fmt.Sprintf("affected %d rows, expected 1", rows))
+	}
+	return nil
+}
+
+func (d *DatabaseOutbox) MarkFailed(ctx context.Context, tx *gorm.DB, id uint, deliveryError string, nextAttemptAt time.Time) error {
+	result := tx.WithContext(ctx).Model(&Event{}).
+		Where("ID = ?", id).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      deliveryError,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errors.New(fmt.Sprintf("affected %d rows, expected 1", result.RowsAffected))
+	}
+	return nil
+}
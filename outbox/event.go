@@ -0,0 +1,73 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+// Types of events.
+const (
+	// EventTransferOutgoing is recorded for the sender of every transfer.
+	EventTransferOutgoing = "transfer.outgoing"
+	// EventTransferIncoming is recorded for the recipient of every transfer.
+	EventTransferIncoming = "transfer.incoming"
+)
+
+// Event is a change waiting in the outbox to be delivered downstream. Events of the same
+// wallet are delivered in the order they were recorded.
+type Event struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	Type      string    `json:"type"`
+	// Address of the wallet the event concerns.
+	Address string          `json:"address" gorm:"index"`
+	Data    json.RawMessage `json:"data" gorm:"type:jsonb;serializer:json"`
+
+	// Time the event was accepted by the sink, nil until then.
+	DeliveredAt *time.Time `json:"-" gorm:"index"`
+	// Number of failed deliveries, and the time after which the next one is attempted.
+	Attempts      int       `json:"-" gorm:"not null;default:0"`
+	NextAttemptAt time.Time `json:"-"`
+	LastError     *string   `json:"-"`
+}
+
+func (Event) TableName() string {
+	return "outbox_events"
+}
+
+// TransferData is the data of transfer events.
+type TransferData struct {
+	ID           uint           `json:"id"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	FromAddress  string         `json:"fromAddress"`
+	ToAddress    string         `json:"toAddress"`
+	Amount       int            `json:"amount"`
+	Memo         *string        `json:"memo"`
+	Metadata     map[string]any `json:"metadata"`
+	ReversalOfID *uint          `json:"reversalOfId"`
+}
+
+// NewTransferEvents returns the events recorded for the transfer: an outgoing one for the
+// sender and an incoming one for the recipient.
+func NewTransferEvents(transfer *model.Transfer) ([]*Event, error) {
+	data, err := json.Marshal(TransferData{
+		ID:           transfer.ID,
+		CreatedAt:    transfer.CreatedAt,
+		FromAddress:  transfer.FromAddress,
+		ToAddress:    transfer.ToAddress,
+		Amount:       transfer.Amount,
+		Memo:         transfer.Memo,
+		Metadata:     transfer.Metadata,
+		ReversalOfID: transfer.ReversalOfID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []*Event{
+		{Type: EventTransferOutgoing, Address: transfer.FromAddress, Data: data},
+		{Type: EventTransferIncoming, Address: transfer.ToAddress, Data: data},
+	}, nil
+}
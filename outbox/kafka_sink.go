@@ -0,0 +1,32 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink writes events to Kafka, keyed by the wallet address, so that all events of a
+// wallet land in the same partition and keep their order. The writer should use a hashing
+// balancer, wait for all replicas to acknowledge and write synchronously.
+type KafkaSink struct {
+	Writer *kafka.Writer
+}
+
+func (s *KafkaSink) Deliver(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.Writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Address),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "event-id", Value: []byte(strconv.FormatUint(uint64(event.ID), 10))},
+			{Key: "event-type", Value: []byte(event.Type)},
+		},
+	})
+}
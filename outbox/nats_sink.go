@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSSink publishes events to JetStream, on the subject Subject followed by the type of
+// the event, e.g. "tokens.transfer.incoming". A stream has to capture these subjects. The
+// event ID is the message ID, so JetStream drops duplicates within its deduplication window.
+type NATSSink struct {
+	JetStream jetstream.JetStream
+	Subject   string
+}
+
+func (s *NATSSink) Deliver(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(s.Subject + "." + event.Type)
+	msg.Data = data
+	msg.Header.Set("Event-Address", event.Address)
+	_, err = s.JetStream.PublishMsg(ctx, msg, jetstream.WithMsgID(strconv.FormatUint(uint64(event.ID), 10)))
+	return err
+}
@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Outbox keeps events recorded in the same transaction as the changes they describe, so
+// that an event is delivered if and only if its change is committed.
+type Outbox interface {
+	// Add records the events in the transaction of tx.
+	Add(ctx context.Context, tx *gorm.DB, events ...*Event) error
+	// TryLockRelay takes a lock held by the database session until UnlockRelay, reporting
+	// false if another relay holds it. db must be bound to a single connection.
+	TryLockRelay(ctx context.Context, db *gorm.DB) (bool, error)
+	// UnlockRelay releases the lock taken by TryLockRelay on the same connection.
+	UnlockRelay(ctx context.Context, db *gorm.DB) error
+	// GetDeliverableEvents returns up to limit undelivered events due for delivery at now,
+	// ordered by ID, leaving out events of wallets whose earlier events aren't due yet.
+	GetDeliverableEvents(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]Event, error)
+	MarkDelivered(ctx context.Context, tx *gorm.DB, id uint, deliveredAt time.Time) error
+	MarkFailed(ctx context.Context, tx *gorm.DB, id uint, deliveryError string, nextAttemptAt time.Time) error
+}
@@ -0,0 +1,110 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+)
+
+// Relay delivers events from the outbox to a sink. Events are delivered at least once and,
+// for every wallet, in the order they were recorded: after a failed delivery, later events
+// of the wallet wait until the failed one is delivered.
+type Relay struct {
+	Outbox   Outbox
+	Sink     Sink
+	Database *gorm.DB
+	// Maximum number of events delivered by a single DeliverEvents call.
+	BatchSize int
+	// Delay before retrying a failed delivery, doubled with every further failure up to
+	// MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+}
+
+// DeliverEvents delivers the events due for delivery and returns how many were delivered.
+// Only one relay delivers events at a time, even with many replicas of the server. Events
+// are sent with no transaction open, and every event is marked delivered or failed on its
+// own, so a slow sink doesn't hold database locks.
+func (r *Relay) DeliverEvents(ctx context.Context) (int, error) {
+	// The relay lock belongs to the database session, so it's taken on a dedicated
+	// connection and held until all events of the batch are handled.
+	sqlDB, err := r.Database.DB()
+	if err != nil {
+		return 0, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	session := r.Database.WithContext(ctx)
+	session.Statement.ConnPool = conn
+
+	locked, err := r.Outbox.TryLockRelay(ctx, session)
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer func() {
+		err := r.Outbox.UnlockRelay(context.WithoutCancel(ctx), session)
+		if err != nil {
+			logging.FromContext(ctx).Error("relay unlock failed", "error", err)
+			// Closing the session releases the lock, so the connection isn't returned
+			// to the pool.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	now := time.Now()
+	events, err := r.Outbox.GetDeliverableEvents(ctx, r.Database, now, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	failedAddresses := make(map[string]bool)
+	for i := range events {
+		event := &events[i]
+		if failedAddresses[event.Address] {
+			continue
+		}
+
+		deliveryErr := r.Sink.Deliver(ctx, event)
+		if deliveryErr != nil {
+			failedAddresses[event.Address] = true
+			logging.FromContext(ctx).Warn("event delivery failed",
+				"event_id", event.ID, "attempts", event.Attempts+1, "error", deliveryErr)
+			err = r.Outbox.MarkFailed(ctx, r.Database, event.ID, deliveryErr.Error(), time.Now().Add(r.retryDelay(event.Attempts)))
+			if err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		err = r.Outbox.MarkDelivered(ctx, r.Database, event.ID, time.Now())
+		if err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	if delivered > 0 {
+		logging.FromContext(ctx).Debug("events delivered", "count", delivered)
+	}
+	return delivered, nil
+}
+
+// retryDelay returns the delay before the next delivery of an event that failed attempts
+// times before.
+func (r *Relay) retryDelay(attempts int) time.Duration {
+	delay := r.RetryDelay
+	for range attempts {
+		delay *= 2
+		if delay >= r.MaxRetryDelay {
+			return r.MaxRetryDelay
+		}
+	}
+	return min(delay, r.MaxRetryDelay)
+}
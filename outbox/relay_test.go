@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "outboxTests", &Event{})
+
+	d := &DatabaseOutbox{}
+	sink := &MemorySink{}
+	relay := &Relay{
+		Outbox:        d,
+		Sink:          sink,
+		Database:      db,
+		BatchSize:     10,
+		RetryDelay:    time.Hour,
+		MaxRetryDelay: time.Hour,
+	}
+
+	addTransfer := func(t *testing.T, id uint, from string, to string) {
+		events, err := NewTransferEvents(&model.Transfer{Model: gorm.Model{ID: id}, FromAddress: from, ToAddress: to, Amount: 10})
+		require.NoError(t, err)
+		require.NoError(t, d.Add(ctx, db, events...))
+	}
+	deliveredTo := func(address string) []string {
+		var types []string
+		for _, event := range sink.Events() {
+			if event.Address == address {
+				types = append(types, event.Type)
+			}
+		}
+		return types
+	}
+
+	t.Run("deliver events in order", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Outbox_Events")
+		sink.Fail = nil
+		addTransfer(t, 1, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002")
+		addTransfer(t, 2, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003")
+
+		delivered, err := relay.DeliverEvents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 4, delivered)
+		require.Equal(t, []string{EventTransferIncoming, EventTransferOutgoing}, deliveredTo("0x0000000000000000000000000000000000000002"))
+
+		delivered, err = relay.DeliverEvents(ctx)
+		require.NoError(t, err)
+		require.Zero(t, delivered)
+	})
+
+	t.Run("failed delivery holds back later events of the wallet only", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Outbox_Events")
+		*sink = MemorySink{}
+		failing := true
+		sink.Fail = func(event *Event) error {
+			if failing && event.Address == "0x0000000000000000000000000000000000000002" {
+				return errors.New("unavailable")
+			}
+			return nil
+		}
+		addTransfer(t, 1, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002")
+		addTransfer(t, 2, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003")
+
+		delivered, err := relay.DeliverEvents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, delivered)
+		require.Empty(t, deliveredTo("0x0000000000000000000000000000000000000002"))
+
+		var failed Event
+		require.NoError(t, db.Where("Address = ? AND Type = ?", "0x0000000000000000000000000000000000000002", EventTransferIncoming).First(&failed).Error)
+		require.Equal(t, 1, failed.Attempts)
+		require.Equal(t, "unavailable", *failed.LastError)
+		require.True(t, failed.NextAttemptAt.After(time.Now()))
+
+		// The retry isn't due yet, so neither event of the wallet is delivered.
+		failing = false
+		delivered, err = relay.DeliverEvents(ctx)
+		require.NoError(t, err)
+		require.Zero(t, delivered)
+
+		db.Exec("UPDATE Outbox_Events SET Next_Attempt_At = now() WHERE ID = ?", failed.ID)
+		delivered, err = relay.DeliverEvents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, delivered)
+		require.Equal(t, []string{EventTransferIncoming, EventTransferOutgoing}, deliveredTo("0x0000000000000000000000000000000000000002"))
+	})
+
+	t.Run("only one relay delivers at a time", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Outbox_Events")
+		*sink = MemorySink{}
+		addTransfer(t, 1, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002")
+
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		conn, err := sqlDB.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+		session := db.WithContext(ctx)
+		session.Statement.ConnPool = conn
+
+		locked, err := d.TryLockRelay(ctx, session)
+		require.NoError(t, err)
+		require.True(t, locked)
+
+		delivered, err := relay.DeliverEvents(ctx)
+		require.NoError(t, err)
+		require.Zero(t, delivered)
+
+		require.NoError(t, d.UnlockRelay(ctx, session))
+
+		delivered, err = relay.DeliverEvents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, delivered)
+	})
+}
@@ -0,0 +1,110 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Sink delivers events downstream. Deliver returns once the event is accepted; an event
+// may be delivered again if the relay fails to record that, so consumers should ignore
+// events with IDs they have already seen.
+type Sink interface {
+	Deliver(ctx context.Context, event *Event) error
+}
+
+// WriterSink writes events to a writer, such as the standard output, as JSON Lines.
+type WriterSink struct {
+	Writer io.Writer
+}
+
+func (s *WriterSink) Deliver(ctx context.Context, event *Event) error {
+	return json.NewEncoder(s.Writer).Encode(event)
+}
+
+// HTTPSink posts events as JSON to a URL, expecting a 2xx response.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *HTTPSink) Deliver(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatUint(uint64(event.ID), 10))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// MultiSink delivers events to each of its sinks in turn, failing on the first sink that
+// fails. The event is then delivered again to all of them, including those that have
+// already accepted it.
+type MultiSink []Sink
+
+func (s MultiSink) Deliver(ctx context.Context, event *Event) error {
+	for _, sink := range s {
+		err := sink.Deliver(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemorySink keeps delivered events in memory. It stands in for real sinks in tests.
+type MemorySink struct {
+	// Fail, if set, is called before an event is delivered, and its error fails the delivery.
+	Fail func(event *Event) error
+
+	mutex  sync.Mutex
+	events []Event
+}
+
+func (s *MemorySink) Deliver(ctx context.Context, event *Event) error {
+	if s.Fail != nil {
+		err := s.Fail(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, *event)
+	return nil
+}
+
+// Events returns the events delivered so far, in the order of delivery.
+func (s *MemorySink) Events() []Event {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Event(nil), s.events...)
+}
@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEvent() *Event {
+	return &Event{
+		ID:        7,
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Type:      EventTransferIncoming,
+		Address:   "0x0000000000000000000000000000000000000002",
+		Data:      json.RawMessage(`{"amount":60}`),
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buffer bytes.Buffer
+	sink := &WriterSink{Writer: &buffer}
+
+	require.NoError(t, sink.Deliver(context.Background(), newTestEvent()))
+	require.Equal(t, `{"id":7,"createdAt":"2026-01-02T03:04:05Z","type":"transfer.incoming",`+
+		`"address":"0x0000000000000000000000000000000000000002","data":{"amount":60}}`+"\n", buffer.String())
+}
+
+func TestHTTPSink(t *testing.T) {
+	status := http.StatusNoContent
+	var request *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := &HTTPSink{URL: server.URL}
+
+	t.Run("accepted", func(t *testing.T) {
+		require.NoError(t, sink.Deliver(context.Background(), newTestEvent()))
+		require.Equal(t, http.MethodPost, request.Method)
+		require.Equal(t, "application/json", request.Header.Get("Content-Type"))
+		require.Equal(t, "7", request.Header.Get("X-Event-ID"))
+		require.Equal(t, EventTransferIncoming, request.Header.Get("X-Event-Type"))
+
+		var event Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		require.Equal(t, "0x0000000000000000000000000000000000000002", event.Address)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		require.Error(t, sink.Deliver(context.Background(), newTestEvent()))
+	})
+}
+
+func TestMemorySink(t *testing.T) {
+	sink := &MemorySink{}
+	require.NoError(t, sink.Deliver(context.Background(), newTestEvent()))
+	require.Len(t, sink.Events(), 1)
+
+	sink.Fail = func(event *Event) error {
+		return errors.New("unavailable")
+	}
+	require.Error(t, sink.Deliver(context.Background(), newTestEvent()))
+	require.Len(t, sink.Events(), 1)
+}
+
+func TestMultiSink(t *testing.T) {
+	first := &MemorySink{}
+	second := &MemorySink{}
+	sink := MultiSink{first, second}
+
+	require.NoError(t, sink.Deliver(context.Background(), newTestEvent()))
+	require.Len(t, first.Events(), 1)
+	require.Len(t, second.Events(), 1)
+
+	first.Fail = func(event *Event) error {
+		return errors.New("unavailable")
+	}
+	require.Error(t, sink.Deliver(context.Background(), newTestEvent()))
+	require.Len(t, second.Events(), 1)
+}
+
+func TestRelay_RetryDelay(t *testing.T) {
+	r := &Relay{RetryDelay: time.Second, MaxRetryDelay: time.Minute}
+
+	require.Equal(t, time.Second, r.retryDelay(0))
+	require.Equal(t, 2*time.Second, r.retryDelay(1))
+	require.Equal(t, 32*time.Second, r.retryDelay(5))
+	require.Equal(t, time.Minute, r.retryDelay(6))
+	require.Equal(t, time.Minute, r.retryDelay(1000))
+}
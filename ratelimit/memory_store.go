@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Number of Take calls between sweeps of buckets that have refilled completely.
+const sweepInterval = 10_000
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+// MemoryStore keeps buckets in process memory. Limits are enforced per server replica.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.takes++
+	if m.takes%sweepInterval == 0 {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		m.buckets[key] = b
+	}
+
+	var result Result
+	b.tokens, result = take(b.tokens, b.updatedAt, limit, now)
+	b.updatedAt = now
+	b.limit = limit
+	return result, nil
+}
+
+func (m *MemoryStore) Refund(_ context.Context, key string, limit Limit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A missing bucket is full.
+	if b, ok := m.buckets[key]; ok {
+		b.tokens = min(float64(limit.Burst), b.tokens+1)
+	}
+	return nil
+}
+
+// sweep forgets buckets that are full, since a missing bucket is equivalent to a full one.
+func (m *MemoryStore) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if b.limit.Rate <= 0 {
+			continue
+		}
+		refillTime := time.Duration((float64(b.limit.Burst) - b.tokens) / b.limit.Rate * float64(time.Second))
+		if now.Sub(b.updatedAt) >= refillTime {
+			delete(m.buckets, key)
+		}
+	}
+}
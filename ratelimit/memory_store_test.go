@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 2, Burst: 3}
+
+	t.Run("allows burst and then rejects", func(t *testing.T) {
+		m := NewMemoryStore()
+
+		for i := 0; i < 3; i++ {
+			result, err := m.Take(ctx, "key", limit, start)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+		}
+
+		result, err := m.Take(ctx, "key", limit, start)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		m := NewMemoryStore()
+
+		for i := 0; i < 3; i++ {
+			_, err := m.Take(ctx, "key", limit, start)
+			require.NoError(t, err)
+		}
+
+		result, err := m.Take(ctx, "key", limit, start.Add(500*time.Millisecond))
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, err = m.Take(ctx, "key", limit, start.Add(500*time.Millisecond))
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		m := NewMemoryStore()
+		single := Limit{Rate: 1, Burst: 1}
+
+		result, err := m.Take(ctx, "a", single, start)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, err = m.Take(ctx, "b", single, start)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, err = m.Take(ctx, "a", single, start)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+	})
+
+	t.Run("sweep forgets only full buckets", func(t *testing.T) {
+		m := NewMemoryStore()
+
+		_, err := m.Take(ctx, "old", limit, start)
+		require.NoError(t, err)
+		_, err = m.Take(ctx, "recent", limit, start.Add(10*time.Second))
+		require.NoError(t, err)
+
+		m.sweep(start.Add(10 * time.Second))
+		require.NotContains(t, m.buckets, "old")
+		require.Contains(t, m.buckets, "recent")
+	})
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Bucket is the persisted state of a single token bucket.
+type Bucket struct {
+	Key       string `gorm:"primaryKey"`
+	Tokens    float64
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false"`
+}
+
+func (Bucket) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// PostgresStore keeps buckets in the database, so that limits are shared by all
+// server replicas.
+type PostgresStore struct {
+	Database *gorm.DB
+}
+
+func (p *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	var result Result
+
+	err := p.Database.Transaction(func(tx *gorm.DB) error {
+		err := gorm.G[Bucket](tx, clause.OnConflict{DoNothing: true}).Create(ctx, &Bucket{
+			Key:       key,
+			Tokens:    float64(limit.Burst),
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		b, err := gorm.G[Bucket](tx, clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(ctx)
+		if err != nil {
+			return err
+		}
+
+		b.Tokens, result = take(b.Tokens, b.UpdatedAt, limit, now)
+		// Clocks of replicas may differ slightly, never move the bucket back in time.
+		if now.After(b.UpdatedAt) {
+			b.UpdatedAt = now
+		}
+		_, err = gorm.G[Bucket](tx).Where("key = ?", key).Select("Tokens", "UpdatedAt").Updates(ctx, b)
+		return err
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result, nil
+}
+
+func (p *PostgresStore) Refund(ctx context.Context, key string, limit Limit) error {
+	_, err := gorm.G[Bucket](p.Database).
+		Where("key = ?", key).
+		Update(ctx, "Tokens", gorm.Expr("LEAST(tokens + 1, ?)", limit.Burst))
+	return err
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "rateLimitTests", &Bucket{})
+
+	p := PostgresStore{Database: db}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 1, Burst: 2}
+
+	t.Run("allows burst and then rejects", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE rate_limit_buckets")
+
+		for i := 0; i < 2; i++ {
+			result, err := p.Take(ctx, "key", limit, start)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+		}
+
+		result, err := p.Take(ctx, "key", limit, start)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, time.Second, result.RetryAfter)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE rate_limit_buckets")
+
+		for i := 0; i < 2; i++ {
+			_, err := p.Take(ctx, "key", limit, start)
+			require.NoError(t, err)
+		}
+
+		result, err := p.Take(ctx, "key", limit, start.Add(time.Second))
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	})
+
+	t.Run("refund puts back a token up to the burst", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE rate_limit_buckets")
+
+		for i := 0; i < 2; i++ {
+			_, err := p.Take(ctx, "key", limit, start)
+			require.NoError(t, err)
+		}
+		for i := 0; i < 3; i++ {
+			require.NoError(t, p.Refund(ctx, "key", limit))
+		}
+
+		for i := 0; i < 2; i++ {
+			result, err := p.Take(ctx, "key", limit, start)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+		}
+		result, err := p.Take(ctx, "key", limit, start)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+	})
+}
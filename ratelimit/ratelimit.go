@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+)
+
+// Limit describes a token bucket: it holds at most Burst tokens and is refilled with
+// Rate tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed bool
+	// When the request was not allowed, how long until a token becomes available.
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Take removes a single token from the bucket identified by key, creating a full
+	// bucket if it does not exist yet.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Refund puts back a token taken from the bucket identified by key, without filling
+	// the bucket over its burst.
+	Refund(ctx context.Context, key string, limit Limit) error
+}
+
+// TakeTransfer takes a token for a transfer from the bucket of the caller and from the
+// bucket of the sending wallet, which all APIs moving tokens share. If a bucket has no
+// token left, it returns that bucket's result and key, and the tokens taken from the
+// other buckets are refunded, so a rejected transfer costs the caller nothing.
+func TakeTransfer(ctx context.Context, store Store, clientLimit Limit, walletLimit Limit, caller string, fromAddress string, now time.Time) (Result, string, error) {
+	keys := []struct {
+		key   string
+		limit Limit
+	}{
+		{"client:" + caller, clientLimit},
+		{"wallet:" + fromAddress, walletLimit},
+	}
+
+	for i, k := range keys {
+		result, err := store.Take(ctx, k.key, k.limit, now)
+		if err != nil || !result.Allowed {
+			for _, taken := range keys[:i] {
+				refundErr := store.Refund(ctx, taken.key, taken.limit)
+				if refundErr != nil {
+					return result, k.key, errors.Join(err, refundErr)
+				}
+			}
+			return result, k.key, err
+		}
+	}
+	return Result{Allowed: true}, "", nil
+}
+
+// NewError returns the error reported to callers that are not allowed to make a request
+// for retryAfter.
+func NewError(retryAfter time.Duration) *apperror.Error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return apperror.Newf(apperror.CodeRateLimited, "rate limit exceeded, retry in %d seconds", seconds).
+		WithExtension("retryAfter", seconds)
+}
+
+// take refills a bucket that held tokens at updatedAt and tries to remove one token
+// from it. It returns the number of tokens left in the bucket.
+func take(tokens float64, updatedAt time.Time, limit Limit, now time.Time) (float64, Result) {
+	elapsed := now.Sub(updatedAt).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+	}
+
+	if tokens >= 1 {
+		return tokens - 1, Result{Allowed: true}
+	}
+
+	if limit.Rate <= 0 {
+		return tokens, Result{Allowed: false, RetryAfter: time.Duration(math.MaxInt64)}
+	}
+	missing := 1 - tokens
+	retryAfter := time.Duration(math.Ceil(missing / limit.Rate * float64(time.Second)))
+	return tokens, Result{Allowed: false, RetryAfter: retryAfter}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeTransfer(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clientLimit := Limit{Rate: 1, Burst: 2}
+	walletLimit := Limit{Rate: 1, Burst: 1}
+
+	t.Run("denial by the wallet bucket refunds the client token", func(t *testing.T) {
+		m := NewMemoryStore()
+
+		result, _, err := TakeTransfer(ctx, m, clientLimit, walletLimit, "alice", "0x1", start)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, key, err := TakeTransfer(ctx, m, clientLimit, walletLimit, "alice", "0x1", start)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, "wallet:0x1", key)
+
+		result, _, err = TakeTransfer(ctx, m, clientLimit, walletLimit, "alice", "0x2", start)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, key, err = TakeTransfer(ctx, m, clientLimit, walletLimit, "alice", "0x3", start)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, "client:alice", key)
+	})
+
+	t.Run("refund does not fill the bucket over its burst", func(t *testing.T) {
+		m := NewMemoryStore()
+
+		_, err := m.Take(ctx, "key", walletLimit, start)
+		require.NoError(t, err)
+		require.NoError(t, m.Refund(ctx, "key", walletLimit))
+		require.NoError(t, m.Refund(ctx, "key", walletLimit))
+
+		result, err := m.Take(ctx, "key", walletLimit, start)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, err = m.Take(ctx, "key", walletLimit, start)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+	})
+}
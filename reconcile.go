@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+
+	"github.com/kamil7430/TokenTransferAPI/service"
+)
+
+// Exit codes of the reconcile command.
+const (
+	exitReconciled = 0
+	exitDrift      = 1
+	exitError      = 2
+)
+
+// runReconcile writes the reconciliation report to w as JSON and returns the exit code
+// of the reconcile command.
+func runReconcile(ctx context.Context, reconciliationService *service.ReconciliationService, w io.Writer) int {
+	report, err := reconciliationService.Reconcile(ctx)
+	if err != nil {
+		slog.Error("reconciliation failed", "error", err)
+		return exitError
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		slog.Error("writing reconciliation report failed", "error", err)
+		return exitError
+	}
+
+	if !report.OK {
+		return exitDrift
+	}
+	return exitReconciled
+}
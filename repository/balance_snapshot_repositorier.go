@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type BalanceSnapshotRepositorier interface {
+	GetLatestSnapshotAtOrBefore(ctx context.Context, tx *gorm.DB, address string, at time.Time) (*model.BalanceSnapshot, error)
+	GetEarliestSnapshotAfter(ctx context.Context, tx *gorm.DB, address string, at time.Time) (*model.BalanceSnapshot, error)
+	GetAddressesChangedSinceSnapshot(ctx context.Context, tx *gorm.DB, addresses []string) ([]string, error)
+	AddSnapshots(ctx context.Context, tx *gorm.DB, snapshots []model.BalanceSnapshot) error
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+)
+
+type DatabaseBalanceSnapshotRepository struct {
+}
+
+func (d *DatabaseBalanceSnapshotRepository) GetLatestSnapshotAtOrBefore(ctx context.Context, tx *gorm.DB, address string, at time.Time) (*model.BalanceSnapshot, error) {
+	snapshot, err := gorm.G[model.BalanceSnapshot](tx).
+		Where("Address = ? AND Taken_At <= ?", address, at).
+		Order("Taken_At DESC").
+		First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (d *DatabaseBalanceSnapshotRepository) GetEarliestSnapshotAfter(ctx context.Context, tx *gorm.DB, address string, at time.Time) (*model.BalanceSnapshot, error) {
+	snapshot, err := gorm.G[model.BalanceSnapshot](tx).
+		Where("Address = ? AND Taken_At > ?", address, at).
+		Order("Taken_At ASC").
+		First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetAddressesChangedSinceSnapshot returns those of the given wallets that sent or
+// received a transfer after their latest snapshot, or ever if they have none.
+func (d *DatabaseBalanceSnapshotRepository) GetAddressesChangedSinceSnapshot(ctx context.Context, tx *gorm.DB, addresses []string) ([]string, error) {
+	var changed []string
+	err := tx.WithContext(ctx).Raw(`
+		SELECT wallets.address
+		FROM wallets
+		LEFT JOIN LATERAL (
+			SELECT taken_at FROM balance_snapshots
+			WHERE balance_snapshots.address = wallets.address
+			ORDER BY taken_at DESC
+			LIMIT 1
+		) AS latest ON true
+		WHERE wallets.address IN ? AND (
+			EXISTS (
+				SELECT 1 FROM transfers
+				WHERE transfers.from_address = wallets.address
+				AND (latest.taken_at IS NULL OR transfers.created_at > latest.taken_at)
+			) OR EXISTS (
+				SELECT 1 FROM transfers
+				WHERE transfers.to_address = wallets.address
+				AND (latest.taken_at IS NULL OR transfers.created_at > latest.taken_at)
+			)
+		)
+		ORDER BY wallets.address`, addresses).
+		Scan(&changed).Error
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+func (d *DatabaseBalanceSnapshotRepository) AddSnapshots(ctx context.Context, tx *gorm.DB, snapshots []model.BalanceSnapshot) error {
+	err := gorm.G[model.BalanceSnapshot](tx).CreateInBatches(ctx, &snapshots, len(snapshots))
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("balance snapshots recorded", "count", len(snapshots))
+	return nil
+}
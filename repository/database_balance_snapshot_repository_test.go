@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseBalanceSnapshotRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "balanceSnapshotRepositoryTests", &model.BalanceSnapshot{}, &model.Wallet{}, &model.Transfer{})
+
+	d := DatabaseBalanceSnapshotRepository{}
+
+	t.Run("add and query snapshots", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Balance_Snapshots")
+		now := time.Now()
+
+		err := d.AddSnapshots(ctx, db, []model.BalanceSnapshot{
+			{Address: "0x0000000000000000000000000000000000000001", Tokens: 10, TakenAt: now.Add(-2 * time.Hour)},
+			{Address: "0x0000000000000000000000000000000000000001", Tokens: 20, TakenAt: now.Add(-time.Hour)},
+			{Address: "0x0000000000000000000000000000000000000002", Tokens: 30, TakenAt: now.Add(-time.Hour)},
+		})
+		require.NoError(t, err)
+
+		snapshot, err := d.GetLatestSnapshotAtOrBefore(ctx, db, "0x0000000000000000000000000000000000000001", now.Add(-90*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 10, snapshot.Tokens)
+
+		snapshot, err = d.GetEarliestSnapshotAfter(ctx, db, "0x0000000000000000000000000000000000000001", now.Add(-3*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 10, snapshot.Tokens)
+
+		_, err = d.GetEarliestSnapshotAfter(ctx, db, "0x0000000000000000000000000000000000000001", now)
+		require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("addresses changed since snapshot", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Balance_Snapshots, Wallets, Transfers")
+		now := time.Now()
+		for _, address := range []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+			"0x0000000000000000000000000000000000000003",
+			"0x0000000000000000000000000000000000000004",
+		} {
+			db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", address, 10)
+		}
+		err := d.AddSnapshots(ctx, db, []model.BalanceSnapshot{
+			{Address: "0x0000000000000000000000000000000000000001", Tokens: 10, TakenAt: now.Add(-time.Hour)},
+			{Address: "0x0000000000000000000000000000000000000002", Tokens: 10, TakenAt: now.Add(-time.Hour)},
+		})
+		require.NoError(t, err)
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000003", "0x0000000000000000000000000000000000000001", 5, now.Add(-2*time.Hour))
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000004", "0x0000000000000000000000000000000000000002", 5, now)
+
+		changed, err := d.GetAddressesChangedSinceSnapshot(ctx, db, []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+			"0x0000000000000000000000000000000000000003",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"0x0000000000000000000000000000000000000002",
+			"0x0000000000000000000000000000000000000003",
+		}, changed)
+	})
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DatabaseHoldRepository struct {
+}
+
+func (d *DatabaseHoldRepository) GetHoldByID(ctx context.Context, tx *gorm.DB, id uint) (*model.Hold, error) {
+	hold, err := gorm.G[model.Hold](tx).Where("ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (d *DatabaseHoldRepository) GetHoldByIDForUpdate(ctx context.Context, tx *gorm.DB, id uint) (*model.Hold, error) {
+	hold, err := gorm.G[model.Hold](tx, clause.Locking{Strength: "UPDATE"}).Where("ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// GetExpiredHoldsForUpdate locks up to limit holds that are still HELD after their expiry
+// time. Holds locked by other transactions are skipped. The holds are ordered by sender,
+// so that their wallets are locked in the same order as in transfers.
+func (d *DatabaseHoldRepository) GetExpiredHoldsForUpdate(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]model.Hold, error) {
+	return gorm.G[model.Hold](tx, clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("Status = ? AND Expires_At <= ?", model.HoldStatusHeld, now).
+		Order("From_Address, ID").
+		Limit(limit).
+		Find(ctx)
+}
+
+func (d *DatabaseHoldRepository) AddHold(ctx context.Context, tx *gorm.DB, hold *model.Hold) error {
+	err := gorm.G[model.Hold](tx).Create(ctx, hold)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("hold created", "hold_id", hold.ID)
+	return nil
+}
+
+// UpdateHoldStatus saves the status and the transfer id of the hold.
+func (d *DatabaseHoldRepository) UpdateHoldStatus(ctx context.Context, tx *gorm.DB, hold *model.Hold) error {
+	rows, err := gorm.G[model.Hold](tx).Where("ID = ?", hold.ID).Select("Status", "TransferID").
+		Updates(ctx, model.Hold{Status: hold.Status, TransferID: hold.TransferID})
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return errors.New(fmt.Sprintf("affected %d rows, expected 1", rows))
+	}
+	logging.FromContext(ctx).Debug("hold status updated", "hold_id", hold.ID, "status", hold.Status)
+	return nil
+}
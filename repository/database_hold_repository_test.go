@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseHoldRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "holdRepositoryTests", &model.Hold{})
+
+	d := DatabaseHoldRepository{}
+
+	t.Run("add and query hold", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Holds")
+
+		hold := &model.Hold{
+			FromAddress: "0x0000000000000000000000000000000000000001",
+			ToAddress:   "0x0000000000000000000000000000000000000002",
+			Amount:      10,
+			Status:      model.HoldStatusHeld,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		}
+		err := d.AddHold(ctx, db, hold)
+		require.NoError(t, err)
+		require.NotZero(t, hold.ID)
+
+		found, err := d.GetHoldByID(ctx, db, hold.ID)
+		require.NoError(t, err)
+		require.Equal(t, hold.FromAddress, found.FromAddress)
+		require.Equal(t, 10, found.Amount)
+		require.Equal(t, model.HoldStatusHeld, found.Status)
+	})
+
+	t.Run("update hold status", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Holds")
+		db.Exec("INSERT INTO Holds(ID, From_Address, To_Address, Amount, Status, Expires_At) VALUES ($1, $2, $3, $4, $5, $6)",
+			1, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, "HELD", time.Now())
+
+		transferID := uint(7)
+		hold, err := d.GetHoldByID(ctx, db, 1)
+		require.NoError(t, err)
+		hold.Status = model.HoldStatusCaptured
+		hold.TransferID = &transferID
+		err = d.UpdateHoldStatus(ctx, db, hold)
+		require.NoError(t, err)
+
+		hold, err = d.GetHoldByID(ctx, db, 1)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusCaptured, hold.Status)
+		require.Equal(t, transferID, *hold.TransferID)
+	})
+
+	t.Run("get expired holds", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Holds")
+		now := time.Now()
+		db.Exec("INSERT INTO Holds(From_Address, To_Address, Amount, Status, Expires_At) VALUES ($1, $2, $3, $4, $5)",
+			"0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003", 10, "HELD", now.Add(-time.Minute))
+		db.Exec("INSERT INTO Holds(From_Address, To_Address, Amount, Status, Expires_At) VALUES ($1, $2, $3, $4, $5)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 20, "HELD", now.Add(-time.Hour))
+		db.Exec("INSERT INTO Holds(From_Address, To_Address, Amount, Status, Expires_At) VALUES ($1, $2, $3, $4, $5)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 40, "HELD", now.Add(time.Hour))
+		db.Exec("INSERT INTO Holds(From_Address, To_Address, Amount, Status, Expires_At) VALUES ($1, $2, $3, $4, $5)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 80, "VOIDED", now.Add(-time.Hour))
+
+		holds, err := d.GetExpiredHoldsForUpdate(ctx, db, now, 10)
+		require.NoError(t, err)
+		require.Len(t, holds, 2)
+		require.Equal(t, 20, holds[0].Amount)
+		require.Equal(t, 10, holds[1].Amount)
+	})
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DatabaseImportRepository struct {
+}
+
+// Addresses of all wallets taking part in the imported rows.
+const importAddresses = "SELECT from_address FROM import_rows UNION SELECT to_address FROM import_rows"
+
+// CopyImportRows creates the temporary table import_rows and fills it with the rows using
+// COPY, assigning every row the ID of the transfer it will be recorded as.
+func (d *DatabaseImportRepository) CopyImportRows(ctx context.Context, tx *gorm.DB, conn *sql.Conn, rows []model.ImportRow) error {
+	err := tx.WithContext(ctx).Exec(`
+		CREATE TEMPORARY TABLE import_rows (
+			line bigint PRIMARY KEY,
+			from_address text NOT NULL,
+			to_address text NOT NULL,
+			amount bigint NOT NULL,
+			transfer_id bigint
+		) ON COMMIT DROP`).Error
+	if err != nil {
+		return err
+	}
+
+	// COPY isn't supported by database/sql, so the rows are copied with the pgx
+	// connection underneath, which takes part in the transaction of tx.
+	err = conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("COPY is not supported by %T", driverConn)
+		}
+		copied, err := pgxConn.Conn().CopyFrom(ctx, pgx.Identifier{"import_rows"},
+			[]string{"line", "from_address", "to_address", "amount"},
+			pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+				return []any{rows[i].Line, rows[i].FromAddress, rows[i].ToAddress, rows[i].Amount}, nil
+			}))
+		if err != nil {
+			return err
+		}
+		if copied != int64(len(rows)) {
+			return fmt.Errorf("copied %d rows, expected %d", copied, len(rows))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.WithContext(ctx).Exec(`
+		UPDATE import_rows SET transfer_id = ids.id
+		FROM (
+			SELECT line, nextval(pg_get_serial_sequence('transfers', 'id')) AS id
+			FROM import_rows
+			ORDER BY line
+		) AS ids
+		WHERE import_rows.line = ids.line`).Error
+}
+
+// CreateImportWallets creates the wallets of the imported rows that don't exist yet and
+// returns how many were created.
+func (d *DatabaseImportRepository) CreateImportWallets(ctx context.Context, tx *gorm.DB) (int, error) {
+	result := tx.WithContext(ctx).Exec(`
+		INSERT INTO wallets (created_at, updated_at, address, tokens, reserved, status)
+		SELECT now(), now(), addresses.from_address, 0, 0, ?
+		FROM (`+importAddresses+`) AS addresses
+		ON CONFLICT (address) DO NOTHING`, model.WalletStatusActive)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(result.RowsAffected), nil
+}
+
+// LockImportWallets locks the wallets of the imported rows, in the order of addresses.
+func (d *DatabaseImportRepository) LockImportWallets(ctx context.Context, tx *gorm.DB) ([]model.Wallet, error) {
+	return gorm.G[model.Wallet](tx, clause.Locking{Strength: "UPDATE"}).
+		Where("Address IN (" + importAddresses + ")").
+		Order("Address").
+		Find(ctx)
+}
+
+// ApplyImportRows records the imported rows as transfers with their journal entries and
+// updates the balances of wallets, the same way as WalletService does for single
+// transfers.
+func (d *DatabaseImportRepository) ApplyImportRows(ctx context.Context, tx *gorm.DB) error {
+	statements := []string{
+		`INSERT INTO transfers (id, created_at, updated_at, from_address, to_address, amount)
+		SELECT transfer_id, now(), now(), from_address, to_address, amount
+		FROM import_rows`,
+
+		`INSERT INTO journal_entries (created_at, updated_at, transfer_id, description)
+		SELECT now(), now(), transfer_id, 'import'
+		FROM import_rows`,
+
+		`INSERT INTO postings (journal_entry_id, account, amount)
+		SELECT journal_entries.id, import_rows.from_address, -import_rows.amount
+		FROM import_rows JOIN journal_entries ON journal_entries.transfer_id = import_rows.transfer_id
+		UNION ALL
+		SELECT journal_entries.id, import_rows.to_address, import_rows.amount
+		FROM import_rows JOIN journal_entries ON journal_entries.transfer_id = import_rows.transfer_id`,
+
+		`UPDATE wallets SET tokens = wallets.tokens + changes.amount, updated_at = now()
+		FROM (
+			SELECT address, SUM(amount) AS amount
+			FROM (
+				SELECT from_address AS address, -amount AS amount FROM import_rows
+				UNION ALL
+				SELECT to_address, amount FROM import_rows
+			) AS movements
+			GROUP BY address
+		) AS changes
+		WHERE wallets.address = changes.address`,
+	}
+	for _, statement := range statements {
+		err := tx.WithContext(ctx).Exec(statement).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetImportTransfers returns the transfers recorded for the imported rows, in the order
+// of rows.
+func (d *DatabaseImportRepository) GetImportTransfers(ctx context.Context, tx *gorm.DB) ([]model.Transfer, error) {
+	return gorm.G[model.Transfer](tx).
+		Where("ID IN (SELECT transfer_id FROM import_rows)").
+		Order("ID").
+		Find(ctx)
+}
+
+// GetOverdrawnImportWallets returns the wallets of the imported rows having less tokens
+// than reserved, including negative balances.
+func (d *DatabaseImportRepository) GetOverdrawnImportWallets(ctx context.Context, tx *gorm.DB) ([]model.Wallet, error) {
+	return gorm.G[model.Wallet](tx).
+		Where("Address IN (" + importAddresses + ") AND Tokens < Reserved").
+		Order("Address").
+		Find(ctx)
+}
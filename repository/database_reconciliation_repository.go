@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type DatabaseReconciliationRepository struct {
+}
+
+func (d *DatabaseReconciliationRepository) SumWalletTokens(ctx context.Context, tx *gorm.DB) (int, error) {
+	var sum int
+	err := tx.WithContext(ctx).Model(&model.Wallet{}).
+		Select("COALESCE(SUM(tokens), 0)").
+		Scan(&sum).Error
+	if err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+// GetInconsistentWallets returns up to limit wallets whose balance differs from the sum of
+// the postings to their account, whose reserved balance differs from the amount of their
+// active holds, or whose balances are negative.
+func (d *DatabaseReconciliationRepository) GetInconsistentWallets(ctx context.Context, tx *gorm.DB, limit int) ([]model.WalletBalanceCheck, error) {
+	var checks []model.WalletBalanceCheck
+	err := tx.WithContext(ctx).Raw(`
+		SELECT address, tokens, reserved, ledger_balance, held_amount
+		FROM (
+			SELECT wallets.address, wallets.tokens, wallets.reserved,
+				COALESCE(posted.amount, 0) AS ledger_balance,
+				COALESCE(held.amount, 0) AS held_amount
+			FROM wallets
+			LEFT JOIN (
+				SELECT account, SUM(amount) AS amount
+				FROM postings
+				GROUP BY account
+			) AS posted ON posted.account = wallets.address
+			LEFT JOIN (
+				SELECT from_address AS address, SUM(amount) AS amount
+				FROM holds WHERE status = ? AND deleted_at IS NULL
+				GROUP BY from_address
+			) AS held ON held.address = wallets.address
+			WHERE wallets.deleted_at IS NULL
+		) AS checks
+		WHERE tokens <> ledger_balance OR reserved <> held_amount OR tokens < 0 OR reserved < 0 OR reserved > tokens
+		ORDER BY address
+		LIMIT ?`, model.HoldStatusHeld, limit).
+		Scan(&checks).Error
+	if err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// GetInconsistentTransfers returns up to limit transfers that aren't recorded by exactly
+// one journal entry moving their amount from the sender's to the recipient's account.
+func (d *DatabaseReconciliationRepository) GetInconsistentTransfers(ctx context.Context, tx *gorm.DB, limit int) ([]model.TransferEntryCheck, error) {
+	var checks []model.TransferEntryCheck
+	err := tx.WithContext(ctx).Raw(`
+		SELECT transfers.id AS transfer_id, transfers.amount,
+			COALESCE(SUM(postings.amount) FILTER (WHERE postings.account = transfers.to_address), 0) AS posted_amount
+		FROM transfers
+		LEFT JOIN journal_entries ON journal_entries.transfer_id = transfers.id AND journal_entries.deleted_at IS NULL
+		LEFT JOIN postings ON postings.journal_entry_id = journal_entries.id
+		WHERE transfers.deleted_at IS NULL
+		GROUP BY transfers.id
+		HAVING COUNT(postings.id) <> 2
+			OR COALESCE(SUM(postings.amount) FILTER (WHERE postings.account = transfers.to_address), 0) <> transfers.amount
+			OR COALESCE(SUM(postings.amount) FILTER (WHERE postings.account = transfers.from_address), 0) <> -transfers.amount
+		ORDER BY transfers.id
+		LIMIT ?`, limit).
+		Scan(&checks).Error
+	if err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DatabaseScheduledTransferRepository struct {
+}
+
+func (d *DatabaseScheduledTransferRepository) GetScheduledTransferByID(ctx context.Context, tx *gorm.DB, id uint) (*model.ScheduledTransfer, error) {
+	schedule, err := gorm.G[model.ScheduledTransfer](tx).Where("ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (d *DatabaseScheduledTransferRepository) GetScheduledTransferByIDForUpdate(ctx context.Context, tx *gorm.DB, id uint) (*model.ScheduledTransfer, error) {
+	schedule, err := gorm.G[model.ScheduledTransfer](tx, clause.Locking{Strength: "UPDATE"}).Where("ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (d *DatabaseScheduledTransferRepository) GetScheduledTransfersByAddress(ctx context.Context, tx *gorm.DB, address string, status *model.ScheduleStatus) ([]*model.ScheduledTransfer, error) {
+	query := gorm.G[*model.ScheduledTransfer](tx).Where("From_Address = ?", address)
+	if status != nil {
+		query = query.Where("Status = ?", *status)
+	}
+	return query.Order("Next_Run_At, ID").Find(ctx)
+}
+
+// ClaimDueScheduledTransfer locks the active scheduled transfer that is due the longest.
+// Transfers locked by other transactions are skipped, so that every due transfer is
+// executed by only one instance of the service. Returns gorm.ErrRecordNotFound if no
+// transfer is due.
+func (d *DatabaseScheduledTransferRepository) ClaimDueScheduledTransfer(ctx context.Context, tx *gorm.DB, now time.Time) (*model.ScheduledTransfer, error) {
+	schedule, err := gorm.G[model.ScheduledTransfer](tx, clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("Status = ? AND Next_Run_At <= ?", model.ScheduleStatusActive, now).
+		Order("Next_Run_At, ID").
+		First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (d *DatabaseScheduledTransferRepository) AddScheduledTransfer(ctx context.Context, tx *gorm.DB, schedule *model.ScheduledTransfer) error {
+	err := gorm.G[model.ScheduledTransfer](tx).Create(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("scheduled transfer created", "schedule_id", schedule.ID)
+	return nil
+}
+
+// UpdateScheduledTransfer saves the status and the next run time of the scheduled transfer.
+func (d *DatabaseScheduledTransferRepository) UpdateScheduledTransfer(ctx context.Context, tx *gorm.DB, schedule *model.ScheduledTransfer) error {
+	rows, err := gorm.G[model.ScheduledTransfer](tx).Where("ID = ?", schedule.ID).Select("Status", "NextRunAt").
+		Updates(ctx, model.ScheduledTransfer{Status: schedule.Status, NextRunAt: schedule.NextRunAt})
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return errors.New(fmt.Sprintf("affected %d rows, expected 1", rows))
+	}
+	logging.FromContext(ctx).Debug("scheduled transfer updated", "schedule_id", schedule.ID,
+		"status", schedule.Status, "next_run_at", schedule.NextRunAt)
+	return nil
+}
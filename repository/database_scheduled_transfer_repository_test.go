@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseScheduledTransferRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "scheduledTransferRepositoryTests", &model.ScheduledTransfer{})
+
+	d := DatabaseScheduledTransferRepository{}
+	now := time.Now()
+
+	t.Run("add and query scheduled transfers", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Scheduled_Transfers")
+
+		schedule := &model.ScheduledTransfer{
+			FromAddress: "0x0000000000000000000000000000000000000001",
+			ToAddress:   "0x0000000000000000000000000000000000000002",
+			Amount:      10,
+			Status:      model.ScheduleStatusActive,
+			NextRunAt:   now,
+		}
+		err := d.AddScheduledTransfer(ctx, db, schedule)
+		require.NoError(t, err)
+		require.NotZero(t, schedule.ID)
+
+		schedules, err := d.GetScheduledTransfersByAddress(ctx, db, "0x0000000000000000000000000000000000000001", nil)
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		require.Equal(t, 10, schedules[0].Amount)
+
+		schedules, err = d.GetScheduledTransfersByAddress(ctx, db, "0x0000000000000000000000000000000000000002", nil)
+		require.NoError(t, err)
+		require.Empty(t, schedules)
+	})
+
+	t.Run("claim due scheduled transfer", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Scheduled_Transfers")
+		db.Exec("INSERT INTO Scheduled_Transfers(From_Address, To_Address, Amount, Status, Next_Run_At) VALUES ($1, $2, $3, $4, $5)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, "ACTIVE", now.Add(time.Hour))
+		db.Exec("INSERT INTO Scheduled_Transfers(From_Address, To_Address, Amount, Status, Next_Run_At) VALUES ($1, $2, $3, $4, $5)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 20, "CANCELLED", now.Add(-time.Hour))
+		db.Exec("INSERT INTO Scheduled_Transfers(From_Address, To_Address, Amount, Status, Next_Run_At) VALUES ($1, $2, $3, $4, $5)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 40, "ACTIVE", now.Add(-time.Minute))
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			schedule, err := d.ClaimDueScheduledTransfer(ctx, tx, now)
+			require.NoError(t, err)
+			require.Equal(t, 40, schedule.Amount)
+
+			// The only due transfer is locked by this transaction, so others can't claim it.
+			return db.Transaction(func(other *gorm.DB) error {
+				_, err := d.ClaimDueScheduledTransfer(ctx, other, now)
+				require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+				return nil
+			})
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("update scheduled transfer", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Scheduled_Transfers")
+		db.Exec("INSERT INTO Scheduled_Transfers(ID, From_Address, To_Address, Amount, Status, Next_Run_At) VALUES ($1, $2, $3, $4, $5, $6)",
+			1, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, "ACTIVE", now)
+
+		schedule, err := d.GetScheduledTransferByID(ctx, db, 1)
+		require.NoError(t, err)
+		schedule.Status = model.ScheduleStatusCancelled
+		err = d.UpdateScheduledTransfer(ctx, db, schedule)
+		require.NoError(t, err)
+
+		schedule, err = d.GetScheduledTransferByID(ctx, db, 1)
+		require.NoError(t, err)
+		require.Equal(t, model.ScheduleStatusCancelled, schedule.Status)
+	})
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+)
+
+type DatabaseScheduledTransferRunRepository struct {
+}
+
+// GetRunsByScheduledTransferID returns up to limit most recent runs, newest first.
+func (d *DatabaseScheduledTransferRunRepository) GetRunsByScheduledTransferID(ctx context.Context, tx *gorm.DB, scheduledTransferID uint, limit int) ([]*model.ScheduledTransferRun, error) {
+	return gorm.G[*model.ScheduledTransferRun](tx).
+		Where("Scheduled_Transfer_ID = ?", scheduledTransferID).
+		Order("ID DESC").
+		Limit(limit).
+		Find(ctx)
+}
+
+func (d *DatabaseScheduledTransferRunRepository) AddRun(ctx context.Context, tx *gorm.DB, run *model.ScheduledTransferRun) error {
+	err := gorm.G[model.ScheduledTransferRun](tx).Create(ctx, run)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("scheduled transfer run recorded", "schedule_id", run.ScheduledTransferID, "outcome", run.Outcome)
+	return nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseScheduledTransferRunRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "scheduledTransferRunRepositoryTests", &model.ScheduledTransferRun{})
+
+	d := DatabaseScheduledTransferRunRepository{}
+
+	t.Run("add and query runs", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Scheduled_Transfer_Runs")
+		now := time.Now()
+
+		for i, outcome := range []model.RunOutcome{model.RunOutcomeSucceeded, model.RunOutcomeFailed, model.RunOutcomeSucceeded} {
+			err := d.AddRun(ctx, db, &model.ScheduledTransferRun{
+				ScheduledTransferID: 1,
+				ScheduledAt:         now.AddDate(0, 0, 7*i),
+				ExecutedAt:          now.AddDate(0, 0, 7*i),
+				Outcome:             outcome,
+			})
+			require.NoError(t, err)
+		}
+		err := d.AddRun(ctx, db, &model.ScheduledTransferRun{ScheduledTransferID: 2, Outcome: model.RunOutcomeFailed})
+		require.NoError(t, err)
+
+		runs, err := d.GetRunsByScheduledTransferID(ctx, db, 1, 2)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		require.Equal(t, model.RunOutcomeSucceeded, runs[0].Outcome)
+		require.Equal(t, model.RunOutcomeFailed, runs[1].Outcome)
+	})
+}
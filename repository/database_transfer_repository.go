@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DatabaseTransferRepository struct {
+}
+
+func (d *DatabaseTransferRepository) GetTransferByID(ctx context.Context, tx *gorm.DB, id uint) (*model.Transfer, error) {
+	transfer, err := gorm.G[model.Transfer](tx).Where("ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (d *DatabaseTransferRepository) GetTransferByIDForUpdate(ctx context.Context, tx *gorm.DB, id uint) (*model.Transfer, error) {
+	transfer, err := gorm.G[model.Transfer](tx, clause.Locking{Strength: "UPDATE"}).Where("ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// GetTransfersByAddress returns up to limit transfers sent or received by the wallet,
+// newest first, starting after the transfer with id before, if it is set.
+func (d *DatabaseTransferRepository) GetTransfersByAddress(ctx context.Context, tx *gorm.DB, address string, filter *model.TransferFilter, limit int, before *uint) ([]*model.Transfer, error) {
+	query := gorm.G[*model.Transfer](tx).Where("(From_Address = ? OR To_Address = ?)", address, address)
+	if before != nil {
+		query = query.Where("ID < ?", *before)
+	}
+	if filter != nil && filter.MetadataKey != nil {
+		if filter.MetadataValue != nil {
+			// Containment can use the GIN index of the metadata.
+			value, err := json.Marshal(map[string]string{*filter.MetadataKey: *filter.MetadataValue})
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("Metadata @> ?::jsonb", string(value))
+		} else {
+			query = query.Where("Metadata -> ? IS NOT NULL", *filter.MetadataKey)
+		}
+	}
+	return query.Order("ID DESC").Limit(limit).Find(ctx)
+}
+
+// GetTransfersByAddressBetween returns the transfers sent or received by the wallet after
+// from and not after to, oldest first.
+func (d *DatabaseTransferRepository) GetTransfersByAddressBetween(ctx context.Context, tx *gorm.DB, address string, from time.Time, to time.Time) ([]model.Transfer, error) {
+	return gorm.G[model.Transfer](tx).
+		Where("(From_Address = ? OR To_Address = ?) AND Created_At > ? AND Created_At <= ?", address, address, from, to).
+		Order("Created_At, ID").
+		Find(ctx)
+}
+
+// SumNetIncomingBetween returns the amount received by the wallet minus the amount sent by
+// it after from and not after to.
+func (d *DatabaseTransferRepository) SumNetIncomingBetween(ctx context.Context, tx *gorm.DB, address string, from time.Time, to time.Time) (int, error) {
+	var sum int
+	err := tx.WithContext(ctx).Model(&model.Transfer{}).
+		Select("COALESCE(SUM(CASE WHEN to_address = ? THEN amount ELSE -amount END), 0)", address).
+		Where("(from_address = ? OR to_address = ?) AND created_at > ? AND created_at <= ?", address, address, from, to).
+		Scan(&sum).Error
+	if err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+// StreamTransfers reads the transfers sent or received by the wallet (or all transfers, if
+// address is nil) created since from and before to, ordered by ID, and passes them to
+// handle in batches of batchSize. The transfers are read with a server-side cursor, so
+// they are never all in memory. It must be called in a transaction.
+func (d *DatabaseTransferRepository) StreamTransfers(ctx context.Context, tx *gorm.DB, address *string, from *time.Time, to *time.Time, batchSize int, handle func(batch []model.Transfer) error) error {
+	query := "SELECT * FROM transfers WHERE deleted_at IS NULL"
+	var args []any
+	if address != nil {
+		query += " AND (from_address = ? OR to_address = ?)"
+		args = append(args, *address, *address)
+	}
+	if from != nil {
+		query += " AND created_at >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += " AND created_at < ?"
+		args = append(args, *to)
+	}
+	query += " ORDER BY id"
+
+	err := tx.WithContext(ctx).Exec("DECLARE transfers_stream NO SCROLL CURSOR FOR "+query, args...).Error
+	if err != nil {
+		return err
+	}
+	defer tx.WithContext(ctx).Exec("CLOSE transfers_stream")
+
+	for {
+		var batch []model.Transfer
+		err = tx.WithContext(ctx).Raw(fmt.Sprintf("FETCH FORWARD %d FROM transfers_stream", batchSize)).Scan(&batch).Error
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err = handle(batch)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// GetReversalOf returns the transfer reversing the transfer with the given id.
+func (d *DatabaseTransferRepository) GetReversalOf(ctx context.Context, tx *gorm.DB, id uint) (*model.Transfer, error) {
+	transfer, err := gorm.G[model.Transfer](tx).Where("Reversal_Of_ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// GetTransferByIdempotencyKey returns the transfer made by the sender with the given
+// idempotency key.
+func (d *DatabaseTransferRepository) GetTransferByIdempotencyKey(ctx context.Context, tx *gorm.DB, fromAddress string, key string) (*model.Transfer, error) {
+	transfer, err := gorm.G[model.Transfer](tx).Where("From_Address = ? AND Idempotency_Key = ?", fromAddress, key).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// GetActivityByAddresses summarizes the transfers of the given wallets. Wallets without
+// transfers are not included in the result.
+func (d *DatabaseTransferRepository) GetActivityByAddresses(ctx context.Context, tx *gorm.DB, addresses []string) ([]model.WalletActivity, error) {
+	var activities []model.WalletActivity
+	err := tx.WithContext(ctx).Raw(`
+		SELECT address,
+			COUNT(*) FILTER (WHERE incoming) AS incoming_transfers,
+			COUNT(*) FILTER (WHERE NOT incoming) AS outgoing_transfers,
+			COALESCE(SUM(amount) FILTER (WHERE incoming), 0) AS total_received,
+			COALESCE(SUM(amount) FILTER (WHERE NOT incoming), 0) AS total_sent,
+			MAX(created_at) AS last_activity_at
+		FROM (
+			SELECT to_address AS address, amount, created_at, TRUE AS incoming
+			FROM transfers WHERE to_address IN ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT from_address AS address, amount, created_at, FALSE AS incoming
+			FROM transfers WHERE from_address IN ? AND deleted_at IS NULL
+		) AS wallet_transfers
+		GROUP BY address`, addresses, addresses).
+		Scan(&activities).Error
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+func (d *DatabaseTransferRepository) AddTransfer(ctx context.Context, tx *gorm.DB, transfer *model.Transfer) error {
+	err := gorm.G[model.Transfer](tx).Create(ctx, transfer)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("transfer recorded", "transfer_id", transfer.ID)
+	return nil
+}
+
+func (d *DatabaseTransferRepository) SumOutgoingSince(ctx context.Context, tx *gorm.DB, address string, since time.Time) (int, error) {
+	var sum int
+	err := tx.WithContext(ctx).Model(&model.Transfer{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("from_address = ? AND created_at >= ?", address, since).
+		Scan(&sum).Error
+	if err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
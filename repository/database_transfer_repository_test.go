@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseTransferRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "transferRepositoryTests", &model.Transfer{})
+
+	d := DatabaseTransferRepository{}
+
+	t.Run("add transfer", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Transfers")
+
+		transfer := &model.Transfer{
+			FromAddress: "0x0000000000000000000000000000000000000001",
+			ToAddress:   "0x0000000000000000000000000000000000000002",
+			Amount:      10,
+		}
+
+		err := d.AddTransfer(ctx, db, transfer)
+		require.NoError(t, err)
+		require.NotZero(t, transfer.ID)
+	})
+
+	t.Run("query transfer and its reversal", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Transfers")
+		db.Exec("INSERT INTO Transfers(ID, From_Address, To_Address, Amount) VALUES ($1, $2, $3, $4)",
+			1, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10)
+
+		transfer, err := d.GetTransferByID(ctx, db, 1)
+		require.NoError(t, err)
+		require.Equal(t, 10, transfer.Amount)
+
+		_, err = d.GetReversalOf(ctx, db, 1)
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		reason := "mistake"
+		reversal := &model.Transfer{
+			FromAddress:  "0x0000000000000000000000000000000000000002",
+			ToAddress:    "0x0000000000000000000000000000000000000001",
+			Amount:       10,
+			ReversalOfID: &transfer.ID,
+			Reason:       &reason,
+		}
+		err = d.AddTransfer(ctx, db, reversal)
+		require.NoError(t, err)
+
+		found, err := d.GetReversalOf(ctx, db, 1)
+		require.NoError(t, err)
+		require.Equal(t, reversal.ID, found.ID)
+
+		reversal.ID = 0
+		err = d.AddTransfer(ctx, db, reversal)
+		require.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+	})
+
+	t.Run("query transfer by idempotency key", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Transfers")
+
+		key := "order-17"
+		transfer := &model.Transfer{
+			FromAddress:    "0x0000000000000000000000000000000000000001",
+			ToAddress:      "0x0000000000000000000000000000000000000002",
+			Amount:         10,
+			IdempotencyKey: &key,
+		}
+		err := d.AddTransfer(ctx, db, transfer)
+		require.NoError(t, err)
+
+		found, err := d.GetTransferByIdempotencyKey(ctx, db, "0x0000000000000000000000000000000000000001", key)
+		require.NoError(t, err)
+		require.Equal(t, transfer.ID, found.ID)
+
+		_, err = d.GetTransferByIdempotencyKey(ctx, db, "0x0000000000000000000000000000000000000001", "order-18")
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = d.GetTransferByIdempotencyKey(ctx, db, "0x0000000000000000000000000000000000000002", key)
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		transfer.ID = 0
+		err = d.AddTransfer(ctx, db, transfer)
+		require.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+
+		// Keys of different senders don't clash.
+		other := &model.Transfer{
+			FromAddress:    "0x0000000000000000000000000000000000000002",
+			ToAddress:      "0x0000000000000000000000000000000000000001",
+			Amount:         5,
+			IdempotencyKey: &key,
+		}
+		err = d.AddTransfer(ctx, db, other)
+		require.NoError(t, err)
+	})
+
+	t.Run("get activity by addresses", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Transfers")
+		now := time.Now()
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, now.Add(-time.Hour))
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 20, now.Add(-2*time.Hour))
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000001", 5, now)
+
+		activities, err := d.GetActivityByAddresses(ctx, db, []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+			"0x0000000000000000000000000000000000000004",
+		})
+		require.NoError(t, err)
+		require.Len(t, activities, 2)
+
+		byAddress := map[string]model.WalletActivity{}
+		for _, activity := range activities {
+			byAddress[activity.Address] = activity
+		}
+		first := byAddress["0x0000000000000000000000000000000000000001"]
+		require.Equal(t, 1, first.IncomingTransfers)
+		require.Equal(t, 2, first.OutgoingTransfers)
+		require.Equal(t, 5, first.TotalReceived)
+		require.Equal(t, 30, first.TotalSent)
+		require.WithinDuration(t, now, *first.LastActivityAt, time.Millisecond)
+
+		second := byAddress["0x0000000000000000000000000000000000000002"]
+		require.Equal(t, 1, second.IncomingTransfers)
+		require.Equal(t, 1, second.OutgoingTransfers)
+	})
+
+	t.Run("sum outgoing since", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Transfers")
+		now := time.Now()
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, now.Add(-time.Hour))
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 20, now.Add(-2*time.Hour))
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 40, now.Add(-48*time.Hour))
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000001", 80, now.Add(-time.Hour))
+
+		sum, err := d.SumOutgoingSince(ctx, db, "0x0000000000000000000000000000000000000001", now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 30, sum)
+	})
+
+	t.Run("sum outgoing without transfers", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Transfers")
+
+		sum, err := d.SumOutgoingSince(ctx, db, "0x0000000000000000000000000000000000000001", time.Now().Add(-24*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 0, sum)
+	})
+
+	t.Run("stream transfers in batches", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Transfers")
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 1; i <= 5; i++ {
+			db.Exec("INSERT INTO Transfers(ID, From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4, $5)",
+				i, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", i, start.Add(time.Duration(i)*time.Hour))
+		}
+		db.Exec("INSERT INTO Transfers(ID, From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4, $5)",
+			6, "0x0000000000000000000000000000000000000003", "0x0000000000000000000000000000000000000004", 6, start.Add(3*time.Hour))
+
+		stream := func(address *string, from *time.Time, to *time.Time) ([][]uint, error) {
+			var batches [][]uint
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return d.StreamTransfers(ctx, tx, address, from, to, 2, func(batch []model.Transfer) error {
+					var ids []uint
+					for _, transfer := range batch {
+						ids = append(ids, transfer.ID)
+					}
+					batches = append(batches, ids)
+					return nil
+				})
+			})
+			return batches, err
+		}
+
+		batches, err := stream(nil, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, [][]uint{{1, 2}, {3, 4}, {5, 6}}, batches)
+
+		address := "0x0000000000000000000000000000000000000001"
+		from, to := start.Add(2*time.Hour), start.Add(5*time.Hour)
+		batches, err = stream(&address, &from, &to)
+		require.NoError(t, err)
+		require.Equal(t, [][]uint{{2, 3}, {4}}, batches)
+
+		address = "0x0000000000000000000000000000000000000009"
+		batches, err = stream(&address, nil, nil)
+		require.NoError(t, err)
+		require.Empty(t, batches)
+	})
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DatabaseWalletPolicyRepository struct {
+}
+
+func (d *DatabaseWalletPolicyRepository) GetPolicyByAddress(ctx context.Context, tx *gorm.DB, address string) (*model.WalletPolicy, error) {
+	policy, err := gorm.G[model.WalletPolicy](tx).Where("Address = ?", address).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (d *DatabaseWalletPolicyRepository) UpsertPolicy(ctx context.Context, tx *gorm.DB, policy *model.WalletPolicy) error {
+	err := gorm.G[model.WalletPolicy](tx, clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_transfer_amount", "max_daily_outflow", "allowed_counterparties", "updated_at"}),
+	}).Create(ctx, policy)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("wallet policy saved", "address", policy.Address)
+	return nil
+}
+
+func (d *DatabaseWalletPolicyRepository) DeletePolicyByAddress(ctx context.Context, tx *gorm.DB, address string) (bool, error) {
+	// Policies are deleted permanently, so that the address can get a new policy later.
+	rows, err := gorm.G[model.WalletPolicy](tx).Scopes(func(s *gorm.Statement) {
+		s.Unscoped = true
+	}).Where("Address = ?", address).Delete(ctx)
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
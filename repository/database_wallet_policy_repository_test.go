@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseWalletPolicyRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "walletPolicyRepositoryTests", &model.WalletPolicy{})
+
+	d := DatabaseWalletPolicyRepository{}
+
+	t.Run("upsert and query policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallet_Policies")
+		maxAmount := 10
+		maxDaily := 100
+
+		err := d.UpsertPolicy(ctx, db, &model.WalletPolicy{
+			Address:           "0x0000000000000000000000000000000000000001",
+			MaxTransferAmount: &maxAmount,
+		})
+		require.NoError(t, err)
+
+		err = d.UpsertPolicy(ctx, db, &model.WalletPolicy{
+			Address:               "0x0000000000000000000000000000000000000001",
+			MaxDailyOutflow:       &maxDaily,
+			AllowedCounterparties: []string{"0x0000000000000000000000000000000000000002"},
+		})
+		require.NoError(t, err)
+
+		policy, err := d.GetPolicyByAddress(ctx, db, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Nil(t, policy.MaxTransferAmount)
+		require.Equal(t, 100, *policy.MaxDailyOutflow)
+		require.Equal(t, []string{"0x0000000000000000000000000000000000000002"}, policy.AllowedCounterparties)
+	})
+
+	t.Run("query non-existing policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallet_Policies")
+
+		_, err := d.GetPolicyByAddress(ctx, db, "0x0000000000000000000000000000000000000001")
+		require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("delete policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallet_Policies")
+		db.Exec("INSERT INTO Wallet_Policies(Address) VALUES ($1)", "0x0000000000000000000000000000000000000001")
+
+		deleted, err := d.DeletePolicyByAddress(ctx, db, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.True(t, deleted)
+
+		deleted, err = d.DeletePolicyByAddress(ctx, db, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.False(t, deleted)
+	})
+}
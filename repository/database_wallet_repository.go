@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -21,6 +22,85 @@ func (d *DatabaseWalletRepository) GetWalletByAddress(ctx context.Context, tx *g
 	return &wallet, nil
 }
 
+// GetWalletsByAddresses returns the wallets with the given addresses in a single query.
+// Addresses without a wallet are skipped.
+func (d *DatabaseWalletRepository) GetWalletsByAddresses(ctx context.Context, tx *gorm.DB, addresses []string) ([]*model.Wallet, error) {
+	return gorm.G[*model.Wallet](tx).Where("Address IN ?", addresses).Find(ctx)
+}
+
+// GetWallets returns up to limit wallets matching the filter in the given order. If after
+// is set, only wallets following it in that order are returned; only its fields used by
+// the order and its ID have to be set. Ties are broken by ID, so the order is total.
+func (d *DatabaseWalletRepository) GetWallets(ctx context.Context, tx *gorm.DB, filter *model.WalletFilter, order model.WalletOrder, limit int, after *model.Wallet) ([]*model.Wallet, error) {
+	query := gorm.G[*model.Wallet](tx).Limit(limit)
+	if filter != nil {
+		if filter.MinBalance != nil {
+			query = query.Where("Tokens >= ?", *filter.MinBalance)
+		}
+		if filter.MaxBalance != nil {
+			query = query.Where("Tokens <= ?", *filter.MaxBalance)
+		}
+		if filter.CreatedAfter != nil {
+			query = query.Where("Created_At > ?", *filter.CreatedAfter)
+		}
+		if filter.Status != nil {
+			query = query.Where("Status = ?", *filter.Status)
+		}
+	}
+
+	switch order {
+	case model.WalletOrderBalanceDesc:
+		if after != nil {
+			query = query.Where("(Tokens, ID) < (?, ?)", after.Tokens, after.ID)
+		}
+		query = query.Order("Tokens DESC, ID DESC")
+	case model.WalletOrderBalanceAsc:
+		if after != nil {
+			query = query.Where("(Tokens, ID) > (?, ?)", after.Tokens, after.ID)
+		}
+		query = query.Order("Tokens ASC, ID ASC")
+	case model.WalletOrderCreatedAtDesc:
+		if after != nil {
+			query = query.Where("(Created_At, ID) < (?, ?)", after.CreatedAt, after.ID)
+		}
+		query = query.Order("Created_At DESC, ID DESC")
+	case model.WalletOrderCreatedAtAsc:
+		if after != nil {
+			query = query.Where("(Created_At, ID) > (?, ?)", after.CreatedAt, after.ID)
+		}
+		query = query.Order("Created_At ASC, ID ASC")
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown wallet order %q", order))
+	}
+
+	return query.Find(ctx)
+}
+
+// GetWalletAddresses returns up to limit addresses of wallets greater than afterAddress,
+// in order, without locking the wallets.
+func (d *DatabaseWalletRepository) GetWalletAddresses(ctx context.Context, tx *gorm.DB, afterAddress string, limit int) ([]string, error) {
+	var addresses []string
+	err := tx.WithContext(ctx).Model(&model.Wallet{}).
+		Where("Address > ?", afterAddress).
+		Order("Address").
+		Limit(limit).
+		Pluck("Address", &addresses).Error
+	if err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// GetWalletsByAddressesForShare locks the wallets with the given addresses, so that no
+// transfer can change their balances until the transaction ends. Like transfers, it locks
+// them in the order of addresses, so that the two can't deadlock.
+func (d *DatabaseWalletRepository) GetWalletsByAddressesForShare(ctx context.Context, tx *gorm.DB, addresses []string) ([]model.Wallet, error) {
+	return gorm.G[model.Wallet](tx, clause.Locking{Strength: "SHARE"}).
+		Where("Address IN ?", addresses).
+		Order("Address").
+		Find(ctx)
+}
+
 func (d *DatabaseWalletRepository) GetWalletByAddressForUpdate(ctx context.Context, tx *gorm.DB, address string) (*model.Wallet, error) {
 	wallet, err := gorm.G[model.Wallet](tx, clause.Locking{Strength: "UPDATE"}).Where("Address = ?", address).First(ctx)
 	if err != nil {
@@ -29,14 +109,28 @@ func (d *DatabaseWalletRepository) GetWalletByAddressForUpdate(ctx context.Conte
 	return &wallet, nil
 }
 
-func (d *DatabaseWalletRepository) UpdateWalletTokensByAddress(ctx context.Context, tx *gorm.DB, address string, tokens int) error {
-	rows, err := gorm.G[model.Wallet](tx).Where("Address = ?", address).Update(ctx, "Tokens", tokens)
+func (d *DatabaseWalletRepository) UpdateWalletReservedByAddress(ctx context.Context, tx *gorm.DB, address string, reserved int) error {
+	rows, err := gorm.G[model.Wallet](tx).Where("Address = ?", address).Update(ctx, "Reserved", reserved)
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return errors.New(fmt.Sprintf("affected %d rows, expected 1", rows))
+	}
+	logging.FromContext(ctx).Debug("wallet reserved balance updated", "address", address, "reserved", reserved)
+	return nil
+}
+
+func (d *DatabaseWalletRepository) UpdateWalletStatusByAddress(ctx context.Context, tx *gorm.DB, address string, status model.WalletStatus, reason string) error {
+	rows, err := gorm.G[model.Wallet](tx).Where("Address = ?", address).Select("Status", "StatusReason").
+		Updates(ctx, model.Wallet{Status: status, StatusReason: &reason})
 	if err != nil {
 		return err
 	}
 	if rows != 1 {
 		return errors.New(fmt.Sprintf("affected %d rows, expected 1", rows))
 	}
+	logging.FromContext(ctx).Debug("wallet status updated", "address", address, "status", status)
 	return nil
 }
 
@@ -45,5 +139,6 @@ func (d *DatabaseWalletRepository) AddWallet(ctx context.Context, tx *gorm.DB, w
 	if err != nil {
 		return err
 	}
+	logging.FromContext(ctx).Debug("wallet created", "address", wallet.Address, "tokens", wallet.Tokens)
 	return nil
 }
@@ -5,42 +5,13 @@ import (
 	"testing"
 
 	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
 	"github.com/stretchr/testify/require"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	gormpostgres "gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 func TestDatabaseWalletRepository(t *testing.T) {
 	ctx := context.Background()
-	dbname := "repositoryTests"
-	dbuser := "user"
-	dbpassword := "password"
-
-	ctr, err := postgres.Run(
-		ctx,
-		"postgres:16-alpine",
-		postgres.WithDatabase(dbname),
-		postgres.WithUsername(dbuser),
-		postgres.WithPassword(dbpassword),
-		postgres.BasicWaitStrategies(),
-		postgres.WithSQLDriver("pgx"),
-	)
-	testcontainers.CleanupContainer(t, ctr)
-	require.NoError(t, err)
-
-	err = ctr.Snapshot(ctx)
-	require.NoError(t, err)
-
-	dbURL, err := ctr.ConnectionString(ctx)
-	require.NoError(t, err)
-
-	db, err := gorm.Open(gormpostgres.Open(dbURL), &gorm.Config{})
-	require.NoError(t, err)
-
-	err = db.AutoMigrate(&model.Wallet{})
-	require.NoError(t, err)
+	db := testdb.New(t, "repositoryTests", &model.Wallet{})
 
 	d := DatabaseWalletRepository{}
 
@@ -73,16 +44,90 @@ func TestDatabaseWalletRepository(t *testing.T) {
 		require.Error(t, err)
 	})
 
-	t.Run("update wallet", func(t *testing.T) {
+	t.Run("query wallet addresses and lock wallets", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 20)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000000", 0)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 10)
+
+		addresses, err := d.GetWalletAddresses(ctx, db, "0x0000000000000000000000000000000000000000", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+		}, addresses)
+
+		addresses, err = d.GetWalletAddresses(ctx, db, "", 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"0x0000000000000000000000000000000000000000"}, addresses)
+
+		wallets, err := d.GetWalletsByAddressesForShare(ctx, db, []string{
+			"0x0000000000000000000000000000000000000002",
+			"0x0000000000000000000000000000000000000001",
+		})
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", wallets[0].Address)
+		require.Equal(t, 20, wallets[1].Tokens)
+	})
+
+	t.Run("query wallets by addresses", func(t *testing.T) {
 		db.Exec("TRUNCATE TABLE Wallets")
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000000", 1_000_000)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 50)
 
-		err := d.UpdateWalletTokensByAddress(ctx, db, "0x0000000000000000000000000000000000000000", 150)
+		wallets, err := d.GetWalletsByAddresses(ctx, db, []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+		})
 		require.NoError(t, err)
+		require.Len(t, wallets, 1)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", wallets[0].Address)
+		require.Equal(t, 50, wallets[0].Tokens)
+	})
+
+	t.Run("update wallet status", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000000", 1_000_000)
 
 		wallet, err := d.GetWalletByAddress(ctx, db, "0x0000000000000000000000000000000000000000")
 		require.NoError(t, err)
-		require.Equal(t, "0x0000000000000000000000000000000000000000", wallet.Address)
-		require.Equal(t, 150, wallet.Tokens)
+		require.Equal(t, model.WalletStatusActive, wallet.Status)
+
+		err = d.UpdateWalletStatusByAddress(ctx, db, "0x0000000000000000000000000000000000000000", model.WalletStatusFrozen, "suspicious activity")
+		require.NoError(t, err)
+
+		wallet, err = d.GetWalletByAddress(ctx, db, "0x0000000000000000000000000000000000000000")
+		require.NoError(t, err)
+		require.Equal(t, model.WalletStatusFrozen, wallet.Status)
+		require.Equal(t, "suspicious activity", *wallet.StatusReason)
+	})
+
+	t.Run("list wallets", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000000", 1_000_000)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 50)
+		db.Exec("INSERT INTO Wallets(Address, Tokens, Status) VALUES ($1, $2, $3)", "0x0000000000000000000000000000000000000002", 50, model.WalletStatusFrozen)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000003", 10)
+
+		wallets, err := d.GetWallets(ctx, db, nil, model.WalletOrderBalanceDesc, 2, nil)
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		require.Equal(t, "0x0000000000000000000000000000000000000000", wallets[0].Address)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", wallets[1].Address)
+
+		wallets, err = d.GetWallets(ctx, db, nil, model.WalletOrderBalanceDesc, 2, wallets[1])
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		require.Equal(t, "0x0000000000000000000000000000000000000002", wallets[0].Address)
+		require.Equal(t, "0x0000000000000000000000000000000000000003", wallets[1].Address)
+
+		minBalance, maxBalance := 10, 100
+		active := model.WalletStatusActive
+		wallets, err = d.GetWallets(ctx, db, &model.WalletFilter{MinBalance: &minBalance, MaxBalance: &maxBalance, Status: &active}, model.WalletOrderBalanceAsc, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		require.Equal(t, "0x0000000000000000000000000000000000000003", wallets[0].Address)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", wallets[1].Address)
 	})
 }
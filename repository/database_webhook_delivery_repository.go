@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DatabaseWebhookDeliveryRepository struct {
+}
+
+// GetDeliveriesByWebhookID returns up to limit most recent deliveries, newest first.
+func (d *DatabaseWebhookDeliveryRepository) GetDeliveriesByWebhookID(ctx context.Context, tx *gorm.DB, webhookID uint, status *model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	query := gorm.G[*model.WebhookDelivery](tx).Where("Webhook_ID = ?", webhookID)
+	if status != nil {
+		query = query.Where("Status = ?", *status)
+	}
+	return query.Order("ID DESC").Limit(limit).Find(ctx)
+}
+
+// ClaimDueDelivery claims the pending delivery that is due the longest by postponing it
+// to claimedUntil, so that it isn't attempted by other instances of the service until
+// then, also after the transaction ends. Deliveries locked by other transactions are
+// skipped. Returns gorm.ErrRecordNotFound if no delivery is due.
+func (d *DatabaseWebhookDeliveryRepository) ClaimDueDelivery(ctx context.Context, tx *gorm.DB, now time.Time, claimedUntil time.Time) (*model.WebhookDelivery, error) {
+	delivery, err := gorm.G[model.WebhookDelivery](tx, clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("Status = ? AND Next_Attempt_At <= ?", model.WebhookDeliveryStatusPending, now).
+		Order("Next_Attempt_At, ID").
+		First(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gorm.G[model.WebhookDelivery](tx).Where("ID = ?", delivery.ID).Update(ctx, "Next_Attempt_At", claimedUntil)
+	if err != nil {
+		return nil, err
+	}
+	delivery.NextAttemptAt = &claimedUntil
+	return &delivery, nil
+}
+
+// AddDeliveries adds the deliveries, skipping the ones of events already delivered to the
+// same webhook.
+func (d *DatabaseWebhookDeliveryRepository) AddDeliveries(ctx context.Context, tx *gorm.DB, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := gorm.G[model.WebhookDelivery](tx, clause.OnConflict{
+		Columns:   []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).CreateInBatches(ctx, &deliveries, 100)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("webhook deliveries created", "count", len(deliveries))
+	return nil
+}
+
+// UpdateDelivery saves the outcome of the last attempt of the delivery.
+func (d *DatabaseWebhookDeliveryRepository) UpdateDelivery(ctx context.Context, tx *gorm.DB, delivery *model.WebhookDelivery) error {
+	rows, err := gorm.G[model.WebhookDelivery](tx).Where("ID = ?", delivery.ID).
+		Select("Status", "Attempts", "NextAttemptAt", "LastAttemptAt", "ResponseStatus", "Error", "DeliveredAt").
+		Updates(ctx, *delivery)
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return errors.New(fmt.Sprintf("affected %d rows, expected 1", rows))
+	}
+	logging.FromContext(ctx).Debug("webhook delivery updated", "delivery_id", delivery.ID, "status", delivery.Status)
+	return nil
+}
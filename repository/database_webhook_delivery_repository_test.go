@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseWebhookDeliveryRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "webhookDeliveryRepositoryTests", &model.WebhookDelivery{})
+
+	d := DatabaseWebhookDeliveryRepository{}
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	t.Run("add deliveries once per event", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhook_Deliveries")
+		deliveries := []model.WebhookDelivery{
+			{WebhookID: 1, EventID: 10, Event: model.WebhookEventIncomingTransfer, Payload: "{}", NextAttemptAt: &now},
+			{WebhookID: 2, EventID: 10, Event: model.WebhookEventIncomingTransfer, Payload: "{}", NextAttemptAt: &now},
+		}
+		require.NoError(t, d.AddDeliveries(ctx, db, deliveries))
+		require.NoError(t, d.AddDeliveries(ctx, db, []model.WebhookDelivery{
+			{WebhookID: 1, EventID: 10, Event: model.WebhookEventIncomingTransfer, Payload: "{}", NextAttemptAt: &now},
+		}))
+
+		result, err := d.GetDeliveriesByWebhookID(ctx, db, 1, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, model.WebhookDeliveryStatusPending, result[0].Status)
+	})
+
+	t.Run("claim due delivery", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhook_Deliveries")
+		require.NoError(t, d.AddDeliveries(ctx, db, []model.WebhookDelivery{
+			{WebhookID: 1, EventID: 10, Event: model.WebhookEventIncomingTransfer, Payload: "{}", NextAttemptAt: &later},
+			{WebhookID: 1, EventID: 11, Event: model.WebhookEventIncomingTransfer, Payload: "{}", NextAttemptAt: &now},
+		}))
+
+		delivery, err := d.ClaimDueDelivery(ctx, db, now, later)
+		require.NoError(t, err)
+		require.Equal(t, uint(11), delivery.EventID)
+
+		// The claimed delivery isn't due any more.
+		_, err = d.ClaimDueDelivery(ctx, db, now, later)
+		require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+		delivery.Status = model.WebhookDeliveryStatusDelivered
+		delivery.NextAttemptAt = nil
+		require.NoError(t, d.UpdateDelivery(ctx, db, delivery))
+
+		_, err = d.ClaimDueDelivery(ctx, db, later, later)
+		require.NoError(t, err)
+
+		delivered := model.WebhookDeliveryStatusDelivered
+		result, err := d.GetDeliveriesByWebhookID(ctx, db, 1, &delivered, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, uint(11), result[0].EventID)
+	})
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"gorm.io/gorm"
+)
+
+type DatabaseWebhookRepository struct {
+}
+
+func (d *DatabaseWebhookRepository) GetWebhookByID(ctx context.Context, tx *gorm.DB, id uint) (*model.Webhook, error) {
+	webhook, err := gorm.G[model.Webhook](tx).Where("ID = ?", id).First(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetWebhooks returns the webhooks registered by the owner, or all webhooks if owner is nil.
+func (d *DatabaseWebhookRepository) GetWebhooks(ctx context.Context, tx *gorm.DB, owner *string) ([]*model.Webhook, error) {
+	query := gorm.G[*model.Webhook](tx).Order("ID")
+	if owner != nil {
+		query = query.Where("Owner = ?", *owner)
+	}
+	return query.Find(ctx)
+}
+
+// GetWebhooksForEvent returns the webhooks subscribed to the event of the wallet with the
+// given address.
+func (d *DatabaseWebhookRepository) GetWebhooksForEvent(ctx context.Context, tx *gorm.DB, event model.WebhookEvent, address string) ([]model.Webhook, error) {
+	events, err := json.Marshal([]model.WebhookEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := json.Marshal([]string{address})
+	if err != nil {
+		return nil, err
+	}
+
+	return gorm.G[model.Webhook](tx).
+		Where("Events @> ? AND Addresses @> ?", string(events), string(addresses)).
+		Order("ID").
+		Find(ctx)
+}
+
+func (d *DatabaseWebhookRepository) AddWebhook(ctx context.Context, tx *gorm.DB, webhook *model.Webhook) error {
+	err := gorm.G[model.Webhook](tx).Create(ctx, webhook)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("webhook created", "webhook_id", webhook.ID)
+	return nil
+}
+
+func (d *DatabaseWebhookRepository) DeleteWebhookByID(ctx context.Context, tx *gorm.DB, id uint) (bool, error) {
+	rows, err := gorm.G[model.Webhook](tx).Scopes(func(s *gorm.Statement) {
+		s.Unscoped = true
+	}).Where("ID = ?", id).Delete(ctx)
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDatabaseWebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "webhookRepositoryTests", &model.Webhook{})
+
+	d := DatabaseWebhookRepository{}
+
+	t.Run("query webhooks for event", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhooks")
+		incoming := &model.Webhook{
+			Owner:     "alice",
+			URL:       "https://example.com/incoming",
+			Events:    []model.WebhookEvent{model.WebhookEventIncomingTransfer},
+			Addresses: []string{"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"},
+		}
+		both := &model.Webhook{
+			Owner:     "bob",
+			URL:       "https://example.com/both",
+			Events:    []model.WebhookEvent{model.WebhookEventIncomingTransfer, model.WebhookEventOutgoingTransfer},
+			Addresses: []string{"0x0000000000000000000000000000000000000002"},
+		}
+		require.NoError(t, d.AddWebhook(ctx, db, incoming))
+		require.NoError(t, d.AddWebhook(ctx, db, both))
+
+		webhooks, err := d.GetWebhooksForEvent(ctx, db, model.WebhookEventIncomingTransfer, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+
+		webhooks, err = d.GetWebhooksForEvent(ctx, db, model.WebhookEventOutgoingTransfer, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		require.Equal(t, both.ID, webhooks[0].ID)
+
+		webhooks, err = d.GetWebhooksForEvent(ctx, db, model.WebhookEventIncomingTransfer, "0x0000000000000000000000000000000000000003")
+		require.NoError(t, err)
+		require.Empty(t, webhooks)
+	})
+
+	t.Run("query webhooks by owner", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhooks")
+		db.Exec("INSERT INTO Webhooks(Owner, URL, Events, Addresses, Secret) VALUES ($1, $2, '[]', '[]', $3), ($4, $2, '[]', '[]', $3)",
+			"alice", "https://example.com", "secret", "bob")
+
+		owner := "alice"
+		webhooks, err := d.GetWebhooks(ctx, db, &owner)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		require.Equal(t, "alice", webhooks[0].Owner)
+
+		webhooks, err = d.GetWebhooks(ctx, db, nil)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+	})
+
+	t.Run("delete webhook", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhooks")
+		webhook := &model.Webhook{Owner: "alice", URL: "https://example.com", Secret: "secret"}
+		require.NoError(t, d.AddWebhook(ctx, db, webhook))
+
+		deleted, err := d.DeleteWebhookByID(ctx, db, webhook.ID)
+		require.NoError(t, err)
+		require.True(t, deleted)
+
+		_, err = d.GetWebhookByID(ctx, db, webhook.ID)
+		require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+		deleted, err = d.DeleteWebhookByID(ctx, db, webhook.ID)
+		require.NoError(t, err)
+		require.False(t, deleted)
+	})
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type HoldRepositorier interface {
+	GetHoldByID(ctx context.Context, tx *gorm.DB, id uint) (*model.Hold, error)
+	GetHoldByIDForUpdate(ctx context.Context, tx *gorm.DB, id uint) (*model.Hold, error)
+	GetExpiredHoldsForUpdate(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]model.Hold, error)
+	AddHold(ctx context.Context, tx *gorm.DB, hold *model.Hold) error
+	UpdateHoldStatus(ctx context.Context, tx *gorm.DB, hold *model.Hold) error
+}
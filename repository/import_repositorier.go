@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type ImportRepositorier interface {
+	// CopyImportRows loads the rows into a temporary table dropped at the end of the
+	// transaction. conn must be the connection tx runs on.
+	CopyImportRows(ctx context.Context, tx *gorm.DB, conn *sql.Conn, rows []model.ImportRow) error
+	CreateImportWallets(ctx context.Context, tx *gorm.DB) (int, error)
+	LockImportWallets(ctx context.Context, tx *gorm.DB) ([]model.Wallet, error)
+	ApplyImportRows(ctx context.Context, tx *gorm.DB) error
+	GetImportTransfers(ctx context.Context, tx *gorm.DB) ([]model.Transfer, error)
+	GetOverdrawnImportWallets(ctx context.Context, tx *gorm.DB) ([]model.Wallet, error)
+}
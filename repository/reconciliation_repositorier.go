@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepositorier interface {
+	SumWalletTokens(ctx context.Context, tx *gorm.DB) (int, error)
+	GetInconsistentWallets(ctx context.Context, tx *gorm.DB, limit int) ([]model.WalletBalanceCheck, error)
+	GetInconsistentTransfers(ctx context.Context, tx *gorm.DB, limit int) ([]model.TransferEntryCheck, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type ScheduledTransferRepositorier interface {
+	GetScheduledTransferByID(ctx context.Context, tx *gorm.DB, id uint) (*model.ScheduledTransfer, error)
+	GetScheduledTransferByIDForUpdate(ctx context.Context, tx *gorm.DB, id uint) (*model.ScheduledTransfer, error)
+	GetScheduledTransfersByAddress(ctx context.Context, tx *gorm.DB, address string, status *model.ScheduleStatus) ([]*model.ScheduledTransfer, error)
+	ClaimDueScheduledTransfer(ctx context.Context, tx *gorm.DB, now time.Time) (*model.ScheduledTransfer, error)
+	AddScheduledTransfer(ctx context.Context, tx *gorm.DB, schedule *model.ScheduledTransfer) error
+	UpdateScheduledTransfer(ctx context.Context, tx *gorm.DB, schedule *model.ScheduledTransfer) error
+}
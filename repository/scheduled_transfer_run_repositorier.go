@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type ScheduledTransferRunRepositorier interface {
+	GetRunsByScheduledTransferID(ctx context.Context, tx *gorm.DB, scheduledTransferID uint, limit int) ([]*model.ScheduledTransferRun, error)
+	AddRun(ctx context.Context, tx *gorm.DB, run *model.ScheduledTransferRun) error
+}
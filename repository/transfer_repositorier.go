@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type TransferRepositorier interface {
+	GetTransferByID(ctx context.Context, tx *gorm.DB, id uint) (*model.Transfer, error)
+	GetTransferByIDForUpdate(ctx context.Context, tx *gorm.DB, id uint) (*model.Transfer, error)
+	GetTransfersByAddress(ctx context.Context, tx *gorm.DB, address string, filter *model.TransferFilter, limit int, before *uint) ([]*model.Transfer, error)
+	GetTransfersByAddressBetween(ctx context.Context, tx *gorm.DB, address string, from time.Time, to time.Time) ([]model.Transfer, error)
+	SumNetIncomingBetween(ctx context.Context, tx *gorm.DB, address string, from time.Time, to time.Time) (int, error)
+	StreamTransfers(ctx context.Context, tx *gorm.DB, address *string, from *time.Time, to *time.Time, batchSize int, handle func(batch []model.Transfer) error) error
+	GetReversalOf(ctx context.Context, tx *gorm.DB, id uint) (*model.Transfer, error)
+	GetTransferByIdempotencyKey(ctx context.Context, tx *gorm.DB, fromAddress string, key string) (*model.Transfer, error)
+	GetActivityByAddresses(ctx context.Context, tx *gorm.DB, addresses []string) ([]model.WalletActivity, error)
+	AddTransfer(ctx context.Context, tx *gorm.DB, transfer *model.Transfer) error
+	SumOutgoingSince(ctx context.Context, tx *gorm.DB, address string, since time.Time) (int, error)
+}
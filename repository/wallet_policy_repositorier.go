@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type WalletPolicyRepositorier interface {
+	GetPolicyByAddress(ctx context.Context, tx *gorm.DB, address string) (*model.WalletPolicy, error)
+	UpsertPolicy(ctx context.Context, tx *gorm.DB, policy *model.WalletPolicy) error
+	DeletePolicyByAddress(ctx context.Context, tx *gorm.DB, address string) (bool, error)
+}
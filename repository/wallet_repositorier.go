@@ -9,7 +9,12 @@ import (
 
 type WalletRepositorier interface { // Strange interface naming convention in Go
 	GetWalletByAddress(ctx context.Context, tx *gorm.DB, address string) (*model.Wallet, error)
+	GetWalletsByAddresses(ctx context.Context, tx *gorm.DB, addresses []string) ([]*model.Wallet, error)
+	GetWallets(ctx context.Context, tx *gorm.DB, filter *model.WalletFilter, order model.WalletOrder, limit int, after *model.Wallet) ([]*model.Wallet, error)
+	GetWalletAddresses(ctx context.Context, tx *gorm.DB, afterAddress string, limit int) ([]string, error)
+	GetWalletsByAddressesForShare(ctx context.Context, tx *gorm.DB, addresses []string) ([]model.Wallet, error)
 	GetWalletByAddressForUpdate(ctx context.Context, tx *gorm.DB, address string) (*model.Wallet, error)
-	UpdateWalletTokensByAddress(ctx context.Context, tx *gorm.DB, address string, tokens int) error
+	UpdateWalletReservedByAddress(ctx context.Context, tx *gorm.DB, address string, reserved int) error
 	AddWallet(ctx context.Context, tx *gorm.DB, wallet *model.Wallet) error
+	UpdateWalletStatusByAddress(ctx context.Context, tx *gorm.DB, address string, status model.WalletStatus, reason string) error
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryRepositorier interface {
+	GetDeliveriesByWebhookID(ctx context.Context, tx *gorm.DB, webhookID uint, status *model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error)
+	ClaimDueDelivery(ctx context.Context, tx *gorm.DB, now time.Time, claimedUntil time.Time) (*model.WebhookDelivery, error)
+	AddDeliveries(ctx context.Context, tx *gorm.DB, deliveries []model.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, tx *gorm.DB, delivery *model.WebhookDelivery) error
+}
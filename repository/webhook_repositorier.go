@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"gorm.io/gorm"
+)
+
+type WebhookRepositorier interface {
+	GetWebhookByID(ctx context.Context, tx *gorm.DB, id uint) (*model.Webhook, error)
+	GetWebhooks(ctx context.Context, tx *gorm.DB, owner *string) ([]*model.Webhook, error)
+	GetWebhooksForEvent(ctx context.Context, tx *gorm.DB, event model.WebhookEvent, address string) ([]model.Webhook, error)
+	AddWebhook(ctx context.Context, tx *gorm.DB, webhook *model.Webhook) error
+	DeleteWebhookByID(ctx context.Context, tx *gorm.DB, id uint) (bool, error)
+}
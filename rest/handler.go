@@ -0,0 +1,319 @@
+// Package rest serves a small REST/JSON API for clients that can't use GraphQL. It is
+// built on the same services as the GraphQL API, and its resources have the same fields
+// as the corresponding GraphQL types.
+package rest
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
+	"github.com/kamil7430/TokenTransferAPI/service"
+)
+
+// The OpenAPI 3 description of the API, served at /v1/openapi.yaml.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// Maximum size of request bodies.
+const maxBodySize = 64 << 10
+
+// Handler serves the REST API under /v1/:
+//
+//	GET  /v1/wallets/{address}  the wallet, for callers with the viewer role
+//	POST /v1/transfers          transfers tokens, for callers with the operator role
+//	GET  /v1/openapi.yaml       the OpenAPI description of the API
+type Handler struct {
+	WalletService service.WalletServicer
+	// Store of the token buckets of transfers, shared with the GraphQL API so that both
+	// APIs count against the same limits; nil disables rate limiting.
+	RateLimitStore ratelimit.Store
+	ClientLimit    ratelimit.Limit
+	WalletLimit    ratelimit.Limit
+
+	once sync.Once
+	mux  *http.ServeMux
+}
+
+// wallet is the representation of a wallet, with the fields of the GraphQL Wallet type.
+type wallet struct {
+	Address           string             `json:"address"`
+	CreatedAt         string             `json:"createdAt"`
+	UpdatedAt         string             `json:"updatedAt"`
+	Tokens            int                `json:"tokens"`
+	Available         int                `json:"available"`
+	Reserved          int                `json:"reserved"`
+	Status            model.WalletStatus `json:"status"`
+	StatusReason      *string            `json:"statusReason"`
+	LastActivityAt    *string            `json:"lastActivityAt"`
+	IncomingTransfers int                `json:"incomingTransfers"`
+	OutgoingTransfers int                `json:"outgoingTransfers"`
+	TotalReceived     int                `json:"totalReceived"`
+	TotalSent         int                `json:"totalSent"`
+}
+
+// transfer is the representation of a transfer, with the fields of the GraphQL Transfer type.
+type transfer struct {
+	ID          string         `json:"id"`
+	FromAddress string         `json:"fromAddress"`
+	ToAddress   string         `json:"toAddress"`
+	Amount      int            `json:"amount"`
+	CreatedAt   string         `json:"createdAt"`
+	Memo        *string        `json:"memo"`
+	Metadata    map[string]any `json:"metadata"`
+}
+
+// transferResult is the response to a transfer, with the fields of the GraphQL
+// TransferResult type.
+type transferResult struct {
+	Transfer         transfer `json:"transfer"`
+	FromWallet       wallet   `json:"fromWallet"`
+	ToWallet         wallet   `json:"toWallet"`
+	RecipientCreated bool     `json:"recipientCreated"`
+}
+
+// transferRequest is the body of POST /v1/transfers, with the arguments of the
+// transferTokens mutation.
+type transferRequest struct {
+	FromAddress string         `json:"fromAddress"`
+	ToAddress   string         `json:"toAddress"`
+	Amount      int            `json:"amount"`
+	Memo        *string        `json:"memo"`
+	Metadata    map[string]any `json:"metadata"`
+}
+
+func newWallet(w *model.Wallet, activity *model.WalletActivity) wallet {
+	result := wallet{
+		Address:      w.Address,
+		CreatedAt:    model.FormatTime(w.CreatedAt),
+		UpdatedAt:    model.FormatTime(w.UpdatedAt),
+		Tokens:       w.Tokens,
+		Available:    w.Available(),
+		Reserved:     w.Reserved,
+		Status:       w.Status,
+		StatusReason: w.StatusReason,
+	}
+	if activity != nil {
+		result.IncomingTransfers = activity.IncomingTransfers
+		result.OutgoingTransfers = activity.OutgoingTransfers
+		result.TotalReceived = activity.TotalReceived
+		result.TotalSent = activity.TotalSent
+		if activity.LastActivityAt != nil {
+			lastActivityAt := model.FormatTime(*activity.LastActivityAt)
+			result.LastActivityAt = &lastActivityAt
+		}
+	}
+	return result
+}
+
+func newTransfer(t *model.Transfer) transfer {
+	return transfer{
+		ID:          strconv.FormatUint(uint64(t.ID), 10),
+		FromAddress: t.FromAddress,
+		ToAddress:   t.ToAddress,
+		Amount:      t.Amount,
+		CreatedAt:   model.FormatTime(t.CreatedAt),
+		Memo:        t.Memo,
+		Metadata:    t.Metadata,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.mux = http.NewServeMux()
+		h.mux.HandleFunc("GET /v1/wallets/{address}", h.getWallet)
+		h.mux.HandleFunc("POST /v1/transfers", h.postTransfer)
+		h.mux.HandleFunc("GET /v1/openapi.yaml", h.getSpec)
+	})
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) getWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := logging.With(r.Context(), "route", "GET /v1/wallets/{address}")
+	if !checkRole(w, r, auth.RoleViewer) {
+		return
+	}
+
+	address := r.PathValue("address")
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("address: %s", err))
+		return
+	}
+
+	found, err := h.WalletService.GetWallet(ctx, address)
+	if err != nil {
+		writeServiceError(w, r.WithContext(ctx), err)
+		return
+	}
+	if found == nil {
+		writeError(w, http.StatusNotFound, apperror.CodeWalletNotFound, "wallet does not exist")
+		return
+	}
+
+	activities, err := h.WalletService.GetWalletActivities(ctx, []string{address})
+	if err != nil {
+		writeServiceError(w, r.WithContext(ctx), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newWallet(found, activities[address]))
+}
+
+func (h *Handler) postTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := logging.With(r.Context(), "route", "POST /v1/transfers")
+	if !checkRole(w, r, auth.RoleOperator) {
+		return
+	}
+
+	var request transferRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("invalid request body: %s", err))
+		return
+	}
+	err = address_helper.CheckAddress(request.FromAddress)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("fromAddress: %s", err))
+		return
+	}
+	err = address_helper.CheckAddress(request.ToAddress)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("toAddress: %s", err))
+		return
+	}
+
+	err = h.takeRateLimit(ctx, w, request.FromAddress)
+	if err != nil {
+		writeServiceError(w, r.WithContext(ctx), err)
+		return
+	}
+
+	result, err := h.WalletService.TransferTokens(ctx, request.FromAddress, request.ToAddress, request.Amount, request.Memo, request.Metadata, nil)
+	if err != nil {
+		writeServiceError(w, r.WithContext(ctx), err)
+		return
+	}
+
+	// The transfer has been made, so failing to fetch the activity of the wallets only
+	// leaves those fields empty.
+	activities, err := h.WalletService.GetWalletActivities(ctx, []string{request.FromAddress, request.ToAddress})
+	if err != nil {
+		logging.FromContext(ctx).Warn("fetching wallet activity failed", "error", err)
+		activities = nil
+	}
+
+	writeJSON(w, http.StatusCreated, transferResult{
+		Transfer:         newTransfer(result.Transfer),
+		FromWallet:       newWallet(result.FromWallet, activities[request.FromAddress]),
+		ToWallet:         newWallet(result.ToWallet, activities[request.ToAddress]),
+		RecipientCreated: result.RecipientCreated,
+	})
+}
+
+func (h *Handler) getSpec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(openAPISpec)
+}
+
+// takeRateLimit takes a token from the caller's and the sender's buckets, the same ones
+// as the transfer mutations of the GraphQL API take from.
+func (h *Handler) takeRateLimit(ctx context.Context, w http.ResponseWriter, fromAddress string) error {
+	if h.RateLimitStore == nil {
+		return nil
+	}
+
+	result, key, err := ratelimit.TakeTransfer(ctx, h.RateLimitStore, h.ClientLimit, h.WalletLimit,
+		caller_helper.FromContext(ctx), fromAddress, time.Now())
+	if err != nil {
+		return fmt.Errorf("rate limit store failed: %w", err)
+	}
+	if !result.Allowed {
+		logging.FromContext(ctx).Warn("rate limit exceeded", "key", key)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+		return ratelimit.NewError(result.RetryAfter)
+	}
+	return nil
+}
+
+// checkRole responds with an error and returns false unless the caller has at least
+// the role, like the @hasRole directive does.
+func checkRole(w http.ResponseWriter, r *http.Request, role auth.Role) bool {
+	principal := auth.FromContext(r.Context())
+	if principal == nil {
+		writeError(w, http.StatusUnauthorized, apperror.CodeUnauthenticated, "authentication required")
+		return false
+	}
+	if !principal.Role.Includes(role) {
+		writeError(w, http.StatusForbidden, apperror.CodeForbidden, fmt.Sprintf("role %s required", role))
+		return false
+	}
+	return true
+}
+
+// Statuses of responses to application errors with the given codes.
+var codeStatuses = map[apperror.Code]int{
+	apperror.CodeRateLimited:     http.StatusTooManyRequests,
+	apperror.CodePolicyViolation: http.StatusUnprocessableEntity,
+	apperror.CodeWalletFrozen:    http.StatusUnprocessableEntity,
+	apperror.CodeWalletClosed:    http.StatusUnprocessableEntity,
+	apperror.CodeWalletNotFound:  http.StatusUnprocessableEntity,
+	apperror.CodeUnauthenticated: http.StatusUnauthorized,
+	apperror.CodeForbidden:       http.StatusForbidden,
+}
+
+// writeServiceError responds with an error returned by a service. Errors without a code
+// are failures of the server, e.g. of the database, so their messages are only logged and
+// the client gets a generic message with the 500 status.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		logging.FromContext(r.Context()).Error("rest request failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "", "internal server error")
+		return
+	}
+	logging.FromContext(r.Context()).Warn("rest request failed", "error", err)
+
+	status, ok := codeStatuses[appErr.Code]
+	if !ok {
+		status = http.StatusUnprocessableEntity
+	}
+	extensions := make(map[string]any, len(appErr.Extensions)+1)
+	for key, value := range appErr.Extensions {
+		extensions[key] = value
+	}
+	extensions["code"] = appErr.Code
+	writeJSON(w, status, map[string]any{"errors": []map[string]any{{"message": appErr.Message, "extensions": extensions}}})
+}
+
+// writeError responds with an error in the same format as GraphQL errors.
+func writeError(w http.ResponseWriter, status int, code apperror.Code, message string) {
+	body := map[string]any{"message": message}
+	if code != "" {
+		body["extensions"] = map[string]any{"code": code}
+	}
+	writeJSON(w, status, map[string]any{"errors": []map[string]any{body}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
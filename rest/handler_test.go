@@ -0,0 +1,317 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
+	"github.com/kamil7430/TokenTransferAPI/service"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const (
+	alice = "0x1111111111111111111111111111111111111111"
+	bob   = "0x2222222222222222222222222222222222222222"
+	carol = "0x3333333333333333333333333333333333333333"
+)
+
+type fakeWalletService struct {
+	service.WalletServicer
+
+	transferErr error
+}
+
+var createdAt = time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+
+func (f *fakeWalletService) GetWallet(_ context.Context, address string) (*model.Wallet, error) {
+	if address == carol {
+		return nil, nil
+	}
+	reason := "audit"
+	return &model.Wallet{
+		Model:        gorm.Model{ID: 1, CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour)},
+		Address:      address,
+		Tokens:       100,
+		Reserved:     30,
+		Status:       model.WalletStatusFrozen,
+		StatusReason: &reason,
+	}, nil
+}
+
+func (f *fakeWalletService) GetWalletsByAddresses(ctx context.Context, addresses []string) (map[string]*model.Wallet, error) {
+	wallets := make(map[string]*model.Wallet, len(addresses))
+	for _, address := range addresses {
+		wallets[address], _ = f.GetWallet(ctx, address)
+	}
+	return wallets, nil
+}
+
+func (f *fakeWalletService) GetWalletActivities(_ context.Context, addresses []string) (map[string]*model.WalletActivity, error) {
+	lastActivityAt := createdAt.Add(2 * time.Hour)
+	activities := make(map[string]*model.WalletActivity, len(addresses))
+	for _, address := range addresses {
+		activities[address] = &model.WalletActivity{
+			Address:           address,
+			IncomingTransfers: 2,
+			OutgoingTransfers: 1,
+			TotalReceived:     130,
+			TotalSent:         30,
+			LastActivityAt:    &lastActivityAt,
+		}
+	}
+	return activities, nil
+}
+
+func (f *fakeWalletService) TransferTokens(_ context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any, _ *string) (*model.TransferResult, error) {
+	if f.transferErr != nil {
+		return nil, f.transferErr
+	}
+	return &model.TransferResult{
+		Transfer: &model.Transfer{
+			Model:       gorm.Model{ID: 7, CreatedAt: createdAt},
+			FromAddress: fromAddress,
+			ToAddress:   toAddress,
+			Amount:      amount,
+			Memo:        memo,
+			Metadata:    metadata,
+		},
+		FromWallet:       &model.Wallet{Model: gorm.Model{CreatedAt: createdAt}, Address: fromAddress, Tokens: 100 - amount, Status: model.WalletStatusActive},
+		ToWallet:         &model.Wallet{Model: gorm.Model{CreatedAt: createdAt}, Address: toAddress, Tokens: amount, Status: model.WalletStatusActive},
+		RecipientCreated: true,
+	}, nil
+}
+
+// walletFields selects the fields of the GraphQL Wallet type served by the REST API.
+const walletFields = `address createdAt updatedAt tokens available reserved status statusReason
+	lastActivityAt incomingTransfers outgoingTransfers totalReceived totalSent`
+
+func serve(h http.Handler, role auth.Role, method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if role != "" {
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Subject: "script", Role: role}))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// newGraphQLClient returns a client of the GraphQL API backed by the same service.
+func newGraphQLClient(walletService service.WalletServicer, role auth.Role) *client.Client {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers:  &graph.Resolver{WalletService: walletService},
+		Directives: graph.NewDirectiveRoot(),
+		Complexity: graph.NewComplexityRoot(),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(graph.PresentError)
+	srv.Use(graph.DataLoaders{WalletService: walletService})
+	return client.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := auth.NewContext(r.Context(), &auth.Principal{Subject: "script", Role: role})
+		srv.ServeHTTP(w, r.WithContext(ctx))
+	}))
+}
+
+// decode returns the JSON body of the response as generic values, to be compared with
+// GraphQL responses.
+func decode(t *testing.T, data []byte) any {
+	var value any
+	require.NoError(t, json.Unmarshal(data, &value))
+	return value
+}
+
+func TestHandler_ParityWithGraphQL(t *testing.T) {
+	walletService := &fakeWalletService{}
+	h := &Handler{WalletService: walletService}
+	c := newGraphQLClient(walletService, auth.RoleOperator)
+
+	t.Run("get wallet", func(t *testing.T) {
+		rec := serve(h, auth.RoleViewer, http.MethodGet, "/v1/wallets/"+alice, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		resp, err := c.RawPost(`query($address: Address!) { wallet(address: $address) { `+walletFields+` } }`,
+			client.Var("address", alice))
+		require.NoError(t, err)
+		require.Empty(t, resp.Errors)
+
+		expected := resp.Data.(map[string]any)["wallet"]
+		require.Equal(t, expected, decode(t, rec.Body.Bytes()))
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		rec := serve(h, auth.RoleOperator, http.MethodPost, "/v1/transfers",
+			`{"fromAddress": "`+alice+`", "toAddress": "`+bob+`", "amount": 10, "memo": "invoice", "metadata": {"order": "A-1"}}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		resp, err := c.RawPost(`mutation($from: Address!, $to: Address!) {
+			transferTokens(from_address: $from, to_address: $to, amount: 10, memo: "invoice", metadata: {order: "A-1"}) {
+				transfer { id fromAddress toAddress amount createdAt memo metadata }
+				fromWallet { `+walletFields+` }
+				toWallet { `+walletFields+` }
+				recipientCreated
+			}
+		}`, client.Var("from", alice), client.Var("to", bob))
+		require.NoError(t, err)
+		require.Empty(t, resp.Errors)
+
+		expected := resp.Data.(map[string]any)["transferTokens"]
+		require.Equal(t, expected, decode(t, rec.Body.Bytes()))
+	})
+
+	t.Run("refused transfer", func(t *testing.T) {
+		walletService.transferErr = apperror.New(apperror.CodeWalletFrozen, "sender's wallet is frozen")
+		defer func() { walletService.transferErr = nil }()
+
+		rec := serve(h, auth.RoleOperator, http.MethodPost, "/v1/transfers",
+			`{"fromAddress": "`+alice+`", "toAddress": "`+bob+`", "amount": 10}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		resp, err := c.RawPost(`mutation($from: Address!, $to: Address!) {
+			transferTokens(from_address: $from, to_address: $to, amount: 10) { recipientCreated }
+		}`, client.Var("from", alice), client.Var("to", bob))
+		require.NoError(t, err)
+
+		var graphQLErrors []map[string]any
+		require.NoError(t, json.Unmarshal(resp.Errors, &graphQLErrors))
+		restErrors := decode(t, rec.Body.Bytes()).(map[string]any)["errors"].([]any)
+		require.Len(t, restErrors, 1)
+		require.Equal(t, graphQLErrors[0]["message"], restErrors[0].(map[string]any)["message"])
+		require.Equal(t, graphQLErrors[0]["extensions"], restErrors[0].(map[string]any)["extensions"])
+	})
+}
+
+func TestHandler(t *testing.T) {
+	h := &Handler{WalletService: &fakeWalletService{}}
+
+	t.Run("internal error", func(t *testing.T) {
+		h := &Handler{WalletService: &fakeWalletService{
+			transferErr: errors.New("failed to connect to `host=db user=postgres`: dial error"),
+		}}
+
+		rec := serve(h, auth.RoleOperator, http.MethodPost, "/v1/transfers",
+			`{"fromAddress": "`+alice+`", "toAddress": "`+bob+`", "amount": 10}`)
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.NotContains(t, rec.Body.String(), "postgres")
+		require.Contains(t, rec.Body.String(), "internal server error")
+	})
+
+	t.Run("invalid transfer", func(t *testing.T) {
+		h := &Handler{WalletService: &fakeWalletService{
+			transferErr: apperror.New(apperror.CodeInsufficientBalance, "insufficient balance"),
+		}}
+
+		rec := serve(h, auth.RoleOperator, http.MethodPost, "/v1/transfers",
+			`{"fromAddress": "`+alice+`", "toAddress": "`+bob+`", "amount": 10}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		require.Contains(t, rec.Body.String(), string(apperror.CodeInsufficientBalance))
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		rec := serve(h, auth.RoleViewer, http.MethodGet, "/v1/wallets/"+carol, "")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.Contains(t, rec.Body.String(), string(apperror.CodeWalletNotFound))
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		rec := serve(h, auth.RoleViewer, http.MethodGet, "/v1/wallets/0x1", "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		rec := serve(h, auth.RoleOperator, http.MethodPost, "/v1/transfers", `{"from": "`+alice+`"}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("requires role", func(t *testing.T) {
+		rec := serve(h, "", http.MethodGet, "/v1/wallets/"+alice, "")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		require.Contains(t, rec.Body.String(), string(apperror.CodeUnauthenticated))
+
+		rec = serve(h, auth.RoleViewer, http.MethodPost, "/v1/transfers", `{"fromAddress": "`+alice+`", "toAddress": "`+bob+`", "amount": 10}`)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Contains(t, rec.Body.String(), string(apperror.CodeForbidden))
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		h := &Handler{
+			WalletService:  &fakeWalletService{},
+			RateLimitStore: ratelimit.NewMemoryStore(),
+			ClientLimit:    ratelimit.Limit{Rate: 1, Burst: 10},
+			WalletLimit:    ratelimit.Limit{Rate: 0.5, Burst: 1},
+		}
+		body := `{"fromAddress": "` + alice + `", "toAddress": "` + bob + `", "amount": 10}`
+
+		rec := serve(h, auth.RoleOperator, http.MethodPost, "/v1/transfers", body)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		rec = serve(h, auth.RoleOperator, http.MethodPost, "/v1/transfers", body)
+		require.Equal(t, http.StatusTooManyRequests, rec.Code)
+		require.Equal(t, "2", rec.Header().Get("Retry-After"))
+		require.Contains(t, rec.Body.String(), string(apperror.CodeRateLimited))
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		rec := serve(h, auth.RoleOperator, http.MethodDelete, "/v1/transfers", "")
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	var spec struct {
+		Paths      map[string]map[string]any `yaml:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `yaml:"properties"`
+			} `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	require.NoError(t, yaml.Unmarshal(openAPISpec, &spec))
+
+	t.Run("describes served routes", func(t *testing.T) {
+		h := &Handler{WalletService: &fakeWalletService{}}
+		rec := serve(h, "", http.MethodGet, "/v1/openapi.yaml", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, openAPISpec, rec.Body.Bytes())
+
+		require.Contains(t, spec.Paths["/v1/wallets/{address}"], "get")
+		require.Contains(t, spec.Paths["/v1/transfers"], "post")
+		require.Contains(t, spec.Paths["/v1/openapi.yaml"], "get")
+	})
+
+	t.Run("schemas match representations", func(t *testing.T) {
+		representations := map[string]any{
+			"Wallet":          wallet{},
+			"Transfer":        transfer{},
+			"TransferResult":  transferResult{},
+			"TransferRequest": transferRequest{},
+		}
+		for name, representation := range representations {
+			var fields []string
+			typ := reflect.TypeOf(representation)
+			for i := range typ.NumField() {
+				fields = append(fields, typ.Field(i).Tag.Get("json"))
+			}
+
+			var properties []string
+			for property := range spec.Components.Schemas[name].Properties {
+				properties = append(properties, property)
+			}
+			require.ElementsMatch(t, fields, properties, name)
+		}
+	})
+}
@@ -1,83 +1,348 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/99designs/gqlgen/graphql/handler"
-	"github.com/99designs/gqlgen/graphql/handler/extension"
-	"github.com/99designs/gqlgen/graphql/handler/lru"
-	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/config"
+	"github.com/kamil7430/TokenTransferAPI/export"
 	"github.com/kamil7430/TokenTransferAPI/graph"
 	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/grpcapi"
+	"github.com/kamil7430/TokenTransferAPI/helper/caller_helper"
+	"github.com/kamil7430/TokenTransferAPI/ledger"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/outbox"
+	"github.com/kamil7430/TokenTransferAPI/ratelimit"
 	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/kamil7430/TokenTransferAPI/rest"
 	"github.com/kamil7430/TokenTransferAPI/service"
-	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/kamil7430/TokenTransferAPI/webhook"
+	"github.com/kamil7430/TokenTransferAPI/worker"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/segmentio/kafka-go"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-const port = "8080"
-
 func fatalIfError(err error) {
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("fatal error", "error", err)
+		os.Exit(1)
+	}
+}
+
+func newAuthenticator(cfg config.AuthConfig) (*auth.Authenticator, error) {
+	authenticator := &auth.Authenticator{
+		AnonymousRole: cfg.AnonymousRole,
+	}
+
+	if cfg.APIKeysFile != "" {
+		file, err := os.Open(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		authenticator.APIKeys, err = auth.ParseAPIKeys(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.APIKeysFile, err)
+		}
+	}
+
+	if cfg.JWTSecretFile != "" || cfg.JWKSFile != "" {
+		authenticator.JWT = &auth.JWTAuthenticator{
+			Issuer:   cfg.JWTIssuer,
+			Audience: cfg.JWTAudience,
+		}
+	}
+	if cfg.JWTSecretFile != "" {
+		secret, err := os.ReadFile(cfg.JWTSecretFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.JWT.HMACSecret = []byte(strings.TrimSpace(string(secret)))
+	}
+	if cfg.JWKSFile != "" {
+		file, err := os.Open(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		authenticator.JWT.RSAKeys, err = auth.ParseJWKS(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.JWKSFile, err)
+		}
+	}
+
+	return authenticator, nil
+}
+
+// newSink returns the sink events of transfers are delivered to, or nil if they aren't
+// delivered.
+func newSink(cfg config.OutboxConfig) (outbox.Sink, error) {
+	switch cfg.Sink {
+	case config.OutboxSinkStdout:
+		return &outbox.WriterSink{Writer: os.Stdout}, nil
+	case config.OutboxSinkHTTP:
+		return &outbox.HTTPSink{URL: cfg.HTTPURL, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case config.OutboxSinkNATS:
+		conn, err := nats.Connect(cfg.NATSURL)
+		if err != nil {
+			return nil, fmt.Errorf("OUTBOX_NATS_URL: %w", err)
+		}
+		jetStream, err := jetstream.New(conn)
+		if err != nil {
+			return nil, err
+		}
+		return &outbox.NATSSink{JetStream: jetStream, Subject: cfg.NATSSubject}, nil
+	case config.OutboxSinkKafka:
+		return &outbox.KafkaSink{Writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.KafkaBrokers...),
+			Topic:        cfg.KafkaTopic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		}}, nil
 	}
+	return nil, nil
 }
 
 func main() {
-	dbUser := os.Getenv("POSTGRES_USER")
-	passwordFile, err := os.ReadFile(os.Getenv("POSTGRES_PASSWORD_FILE"))
+	cfg, err := config.Load()
 	fatalIfError(err)
-	dbPassword := strings.TrimSpace(string(passwordFile))
-	dbDb := os.Getenv("POSTGRES_DB")
-	dbPort := os.Getenv("POSTGRES_DB_PORT")
 
-	dsn := fmt.Sprintf("host=db user=%s password=%s dbname=%s port=%s sslmode=disable",
-		dbUser, dbPassword, dbDb, dbPort)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.LogLevel}))
+	slog.SetDefault(logger)
+
+	db, err := gorm.Open(postgres.Open(cfg.Database.DSN()), &gorm.Config{
 		TranslateError: true,
 	})
 	fatalIfError(err)
 
-	err = db.AutoMigrate(&model.Wallet{})
+	err = db.AutoMigrate(&model.Wallet{}, &model.Transfer{}, &model.WalletPolicy{}, &model.Hold{},
+		&model.ScheduledTransfer{}, &model.ScheduledTransferRun{}, &model.BalanceSnapshot{}, &outbox.Event{},
+		&model.Webhook{}, &model.WebhookDelivery{})
+	fatalIfError(err)
+	// Idempotency keys used to be unique across all senders.
+	if db.Migrator().HasIndex(&model.Transfer{}, "idx_transfers_idempotency_key") {
+		err = db.Migrator().DropIndex(&model.Transfer{}, "idx_transfers_idempotency_key")
+		fatalIfError(err)
+	}
+	// Scheduled transfers created before the first run was recorded recur from their next run.
+	err = db.Exec("UPDATE scheduled_transfers SET first_run_at = next_run_at WHERE first_run_at IS NULL").Error
 	fatalIfError(err)
 
-	// Add initial wallet with 1 000 000 tokens (once)
-	err = db.Where(model.Wallet{Address: "0x0000000000000000000000000000000000000000"}).
-		FirstOrCreate(&model.Wallet{
-			Address: "0x0000000000000000000000000000000000000000",
-			Tokens:  1_000_000,
-		}).Error
+	err = ledger.Migrate(db)
+	fatalIfError(err)
+	err = ledger.Backfill(context.Background(), db)
 	fatalIfError(err)
 
-	srv := handler.New(graph.NewExecutableSchema(graph.Config{
-		Resolvers: &graph.Resolver{
-			WalletService: &service.WalletService{
-				WalletRepository: &repository.DatabaseWalletRepository{},
+	databaseLedger := &ledger.DatabaseLedger{}
+
+	// Add initial wallet with 1 000 000 tokens issued by the ledger (once)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where(model.Wallet{Address: service.TreasuryAddress}).
+			FirstOrCreate(&model.Wallet{
+				Address: service.TreasuryAddress,
+				Tokens:  0,
+				Status:  model.WalletStatusActive,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return databaseLedger.Post(context.Background(), tx, ledger.NewIssuanceEntry(service.TreasuryAddress, service.InitialSupply))
+	})
+	fatalIfError(err)
+
+	reconciliationService := &service.ReconciliationService{
+		ReconciliationRepository: &repository.DatabaseReconciliationRepository{},
+		Ledger:                   databaseLedger,
+		Database:                 db,
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile":
+			os.Exit(runReconcile(context.Background(), reconciliationService, os.Stdout))
+		case "import":
+			importService := &service.ImportService{
+				ImportRepository: &repository.DatabaseImportRepository{},
+				Outbox:           &outbox.DatabaseOutbox{},
 				Database:         db,
+			}
+			os.Exit(runImport(context.Background(), importService, os.Args[2:], os.Stdin, os.Stdout))
+		default:
+			fatalIfError(fmt.Errorf("unknown command %q", os.Args[1]))
+		}
+	}
+
+	walletService := &service.WalletService{
+		WalletRepository:                &repository.DatabaseWalletRepository{},
+		TransferRepository:              &repository.DatabaseTransferRepository{},
+		WalletPolicyRepository:          &repository.DatabaseWalletPolicyRepository{},
+		HoldRepository:                  &repository.DatabaseHoldRepository{},
+		Ledger:                          databaseLedger,
+		Database:                        db,
+		BlockFrozenRecipients:           cfg.Wallets.BlockFrozenRecipients,
+		ForbidImplicitRecipientCreation: cfg.Wallets.ForbidImplicitRecipientCreation,
+	}
+
+	webhookService := &service.WebhookService{
+		WebhookRepository:         &repository.DatabaseWebhookRepository{},
+		WebhookDeliveryRepository: &repository.DatabaseWebhookDeliveryRepository{},
+		Database:                  db,
+		Client:                    webhook.NewClient(10*time.Second, cfg.Webhooks.AllowPrivateAddresses),
+		RetryDelay:                10 * time.Second,
+		MaxRetryDelay:             time.Hour,
+		MaxAttempts:               cfg.Webhooks.MaxAttempts,
+	}
+
+	go worker.Run(context.Background(), "webhook-dispatcher", cfg.Webhooks.PollInterval, func(ctx context.Context) error {
+		_, err := webhookService.DeliverDueWebhooks(ctx, time.Now())
+		return err
+	})
+
+	// Events are always relayed to webhooks, and to the configured sink if there is one.
+	sinks := outbox.MultiSink{webhookService}
+	sink, err := newSink(cfg.Outbox)
+	fatalIfError(err)
+	if sink != nil {
+		sinks = append(sinks, sink)
+	}
+	databaseOutbox := &outbox.DatabaseOutbox{}
+	walletService.Outbox = databaseOutbox
+	relay := &outbox.Relay{
+		Outbox:        databaseOutbox,
+		Sink:          sinks,
+		Database:      db,
+		BatchSize:     100,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: 5 * time.Minute,
+	}
+	go worker.Run(context.Background(), "outbox-relay", cfg.Outbox.RelayInterval, func(ctx context.Context) error {
+		_, err := relay.DeliverEvents(ctx)
+		return err
+	})
+
+	go worker.Run(context.Background(), "hold-expirer", cfg.Wallets.HoldExpiryInterval, func(ctx context.Context) error {
+		_, err := walletService.ExpireHolds(ctx, time.Now())
+		return err
+	})
+
+	scheduledTransferService := &service.ScheduledTransferService{
+		ScheduledTransferRepository:    &repository.DatabaseScheduledTransferRepository{},
+		ScheduledTransferRunRepository: &repository.DatabaseScheduledTransferRunRepository{},
+		WalletService:                  walletService,
+		Database:                       db,
+	}
+
+	go worker.Run(context.Background(), "transfer-scheduler", cfg.Wallets.SchedulePollInterval, func(ctx context.Context) error {
+		_, err := scheduledTransferService.RunDueTransfers(ctx, time.Now())
+		return err
+	})
+
+	balanceHistoryService := &service.BalanceHistoryService{
+		WalletRepository:          &repository.DatabaseWalletRepository{},
+		TransferRepository:        &repository.DatabaseTransferRepository{},
+		BalanceSnapshotRepository: &repository.DatabaseBalanceSnapshotRepository{},
+		Database:                  db,
+	}
+
+	go worker.Run(context.Background(), "balance-snapshotter", cfg.Wallets.BalanceSnapshotInterval, func(ctx context.Context) error {
+		_, err := balanceHistoryService.SnapshotBalances(ctx)
+		return err
+	})
+
+	if cfg.Wallets.ReconcileInterval > 0 {
+		go worker.Run(context.Background(), "reconciler", cfg.Wallets.ReconcileInterval, func(ctx context.Context) error {
+			report, err := reconciliationService.Reconcile(ctx)
+			if err != nil {
+				return err
+			}
+			if !report.OK {
+				return fmt.Errorf("reconciliation found %d problems", len(report.Problems))
+			}
+			return nil
+		})
+	}
+
+	clientLimit := ratelimit.Limit{Rate: cfg.RateLimit.ClientRate, Burst: cfg.RateLimit.ClientBurst}
+	walletLimit := ratelimit.Limit{Rate: cfg.RateLimit.WalletRate, Burst: cfg.RateLimit.WalletBurst}
+	var rateLimitStore ratelimit.Store
+	var rateLimit *graph.RateLimit
+	if cfg.RateLimit.Enabled {
+		rateLimitStore = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Store == config.RateLimitStorePostgres {
+			err = db.AutoMigrate(&ratelimit.Bucket{})
+			fatalIfError(err)
+			rateLimitStore = &ratelimit.PostgresStore{Database: db}
+		}
+		rateLimit = &graph.RateLimit{
+			Store:       rateLimitStore,
+			ClientLimit: clientLimit,
+			WalletLimit: walletLimit,
+		}
+	}
+
+	srv := graph.NewHandler(graph.HandlerConfig{
+		Resolvers: &graph.Resolver{
+			WalletService: walletService,
+			WalletPolicyService: &service.WalletPolicyService{
+				WalletPolicyRepository: &repository.DatabaseWalletPolicyRepository{},
+				Database:               db,
 			},
+			ScheduledTransferService: scheduledTransferService,
+			BalanceHistoryService:    balanceHistoryService,
+			WebhookService:           webhookService,
+			SubscriptionInterval:     cfg.GraphQL.SubscriptionInterval,
 		},
-	}))
+		ComplexityLimit: cfg.GraphQL.ComplexityLimit,
+		MaxDepth:        cfg.GraphQL.MaxDepth,
+		Introspection:   cfg.GraphQL.Introspection,
+		RateLimit:       rateLimit,
+	})
 
-	srv.AddTransport(transport.Options{})
-	srv.AddTransport(transport.GET{})
-	srv.AddTransport(transport.POST{})
+	authenticator, err := newAuthenticator(cfg.Auth)
+	fatalIfError(err)
 
-	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	if cfg.GraphQL.Playground {
+		http.Handle("/", playground.Handler("GraphQL playground", "/query"))
+		logger.Info("graphql playground enabled", "url", "http://localhost:"+cfg.Port+"/")
+	}
+	http.Handle("/query", caller_helper.Middleware(logging.Middleware(logger, authenticator.Middleware(srv))))
+	exportHandler := &export.TransfersHandler{WalletService: walletService}
+	http.Handle("/export/transfers", caller_helper.Middleware(logging.Middleware(logger, authenticator.Middleware(exportHandler))))
+	restHandler := &rest.Handler{
+		WalletService:  walletService,
+		RateLimitStore: rateLimitStore,
+		ClientLimit:    clientLimit,
+		WalletLimit:    walletLimit,
+	}
+	http.Handle("/v1/", caller_helper.Middleware(logging.Middleware(logger, authenticator.Middleware(restHandler))))
 
-	srv.Use(extension.Introspection{})
-	srv.Use(extension.AutomaticPersistedQuery{
-		Cache: lru.New[string](100),
+	grpcServer := grpcapi.NewServer(logger, authenticator, &grpcapi.WalletServer{
+		WalletService:  walletService,
+		RateLimitStore: rateLimitStore,
+		ClientLimit:    clientLimit,
+		WalletLimit:    walletLimit,
+		WatchInterval:  cfg.GRPC.WatchInterval,
 	})
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+	fatalIfError(err)
+	go func() {
+		fatalIfError(grpcServer.Serve(grpcListener))
+	}()
 
-	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", srv)
-
-	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	logger.Info("server started", "port", cfg.Port, "grpc_port", cfg.GRPC.Port, "environment", cfg.Environment)
+	fatalIfError(http.ListenAndServe(":"+cfg.Port, nil))
 }
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"gorm.io/gorm"
+)
+
+// BalanceHistoryService computes past balances of wallets from the ledger of transfers,
+// starting from the nearest balance snapshot.
+type BalanceHistoryService struct {
+	WalletRepository          repository.WalletRepositorier
+	TransferRepository        repository.TransferRepositorier
+	BalanceSnapshotRepository repository.BalanceSnapshotRepositorier
+	Database                  *gorm.DB
+}
+
+// Number of wallets whose balances are recorded in a single transaction by SnapshotBalances.
+const snapshotBatchSize = 1000
+
+// Maximum number of points returned by GetBalanceHistory.
+const maxBalanceHistoryPoints = 1000
+
+// readOnlySnapshot makes all queries of a transaction see the same state of the database,
+// so that balances and transfers read by separate queries are consistent.
+var readOnlySnapshot = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+func (d *BalanceHistoryService) GetBalanceAt(ctx context.Context, address string, at time.Time) (int, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return 0, err
+	}
+	if at.After(time.Now()) {
+		return 0, errors.New("time must not be in the future")
+	}
+
+	var balance int
+	err = d.Database.Transaction(func(tx *gorm.DB) error {
+		wallet, err := d.WalletRepository.GetWalletByAddress(ctx, tx, address)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		balance, err = d.balanceAt(ctx, tx, wallet, at)
+		return err
+	}, readOnlySnapshot)
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+func (d *BalanceHistoryService) GetBalanceHistory(ctx context.Context, address string, from time.Time, to time.Time, interval model.BalanceInterval) ([]*model.BalancePoint, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if to.Before(from) {
+		return nil, errors.New("from must not be after to")
+	}
+	if to.After(time.Now()) {
+		return nil, errors.New("time must not be in the future")
+	}
+
+	var points []*model.BalancePoint
+	for i := 0; ; i++ {
+		at, err := nthInterval(from, interval, i)
+		if err != nil {
+			return nil, err
+		}
+		if at.After(to) {
+			break
+		}
+		if i == maxBalanceHistoryPoints {
+			return nil, fmt.Errorf("history must not have more than %d points", maxBalanceHistoryPoints)
+		}
+		points = append(points, &model.BalancePoint{At: at})
+	}
+
+	err = d.Database.Transaction(func(tx *gorm.DB) error {
+		wallet, err := d.WalletRepository.GetWalletByAddress(ctx, tx, address)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		// Balances before the wallet was created are zero. The first balance after that
+		// can't be computed from transfers alone, since the wallet may have been created
+		// with some tokens.
+		i := 0
+		for i < len(points) && points[i].At.Before(wallet.CreatedAt) {
+			i++
+		}
+		if i == len(points) {
+			return nil
+		}
+
+		points[i].Balance, err = d.balanceAt(ctx, tx, wallet, points[i].At)
+		if err != nil {
+			return err
+		}
+
+		transfers, err := d.TransferRepository.GetTransfersByAddressBetween(ctx, tx, address, points[i].At, to)
+		if err != nil {
+			return err
+		}
+
+		balance := points[i].Balance
+		for _, point := range points[i+1:] {
+			for len(transfers) > 0 && !transfers[0].CreatedAt.After(point.At) {
+				if transfers[0].ToAddress == address {
+					balance += transfers[0].Amount
+				} else {
+					balance -= transfers[0].Amount
+				}
+				transfers = transfers[1:]
+			}
+			point.Balance = balance
+		}
+		return nil
+	}, readOnlySnapshot)
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// balanceAt computes the balance of the wallet at the given time from the nearest
+// snapshot, or from its current balance if it has no snapshots.
+func (d *BalanceHistoryService) balanceAt(ctx context.Context, tx *gorm.DB, wallet *model.Wallet, at time.Time) (int, error) {
+	if at.Before(wallet.CreatedAt) {
+		return 0, nil
+	}
+
+	snapshot, err := d.BalanceSnapshotRepository.GetLatestSnapshotAtOrBefore(ctx, tx, wallet.Address, at)
+	if err == nil {
+		change, err := d.TransferRepository.SumNetIncomingBetween(ctx, tx, wallet.Address, snapshot.TakenAt, at)
+		if err != nil {
+			return 0, err
+		}
+		return snapshot.Tokens + change, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	// Without an earlier snapshot, the transfers made since are undone from a later
+	// snapshot or from the current balance.
+	balance, since := wallet.Tokens, time.Now()
+	snapshot, err = d.BalanceSnapshotRepository.GetEarliestSnapshotAfter(ctx, tx, wallet.Address, at)
+	if err == nil {
+		balance, since = snapshot.Tokens, snapshot.TakenAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	change, err := d.TransferRepository.SumNetIncomingBetween(ctx, tx, wallet.Address, at, since)
+	if err != nil {
+		return 0, err
+	}
+	return balance - change, nil
+}
+
+// nthInterval returns the time n intervals after start. Months are added to the date of
+// start, so that all points of a history fall on the same day of the month.
+func nthInterval(start time.Time, interval model.BalanceInterval, n int) (time.Time, error) {
+	switch interval {
+	case model.BalanceIntervalHour:
+		return start.Add(time.Duration(n) * time.Hour), nil
+	case model.BalanceIntervalDay:
+		return start.AddDate(0, 0, n), nil
+	case model.BalanceIntervalWeek:
+		return start.AddDate(0, 0, 7*n), nil
+	case model.BalanceIntervalMonth:
+		return start.AddDate(0, n, 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown interval %q", interval)
+	}
+}
+
+// SnapshotBalances records the current balances of wallets that sent or received tokens
+// since their latest snapshot and returns the number of recorded snapshots. Other wallets
+// are skipped, as their balances can be computed from the snapshot they already have.
+// Only the wallets being recorded are locked, in the order of addresses like by transfers,
+// while their balances are read, so that every transfer recorded before a snapshot is
+// included in it.
+func (d *BalanceHistoryService) SnapshotBalances(ctx context.Context) (int, error) {
+	count := 0
+	afterAddress := ""
+	for {
+		addresses, err := d.WalletRepository.GetWalletAddresses(ctx, d.Database, afterAddress, snapshotBatchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(addresses) == 0 {
+			break
+		}
+
+		recorded := 0
+		err = d.Database.Transaction(func(tx *gorm.DB) error {
+			changed, err := d.BalanceSnapshotRepository.GetAddressesChangedSinceSnapshot(ctx, tx, addresses)
+			if err != nil || len(changed) == 0 {
+				return err
+			}
+
+			wallets, err := d.WalletRepository.GetWalletsByAddressesForShare(ctx, tx, changed)
+			if err != nil {
+				return err
+			}
+
+			takenAt := time.Now()
+			snapshots := make([]model.BalanceSnapshot, 0, len(wallets))
+			for _, wallet := range wallets {
+				snapshots = append(snapshots, model.BalanceSnapshot{
+					Address: wallet.Address,
+					Tokens:  wallet.Tokens,
+					TakenAt: takenAt,
+				})
+			}
+			recorded = len(snapshots)
+			return d.BalanceSnapshotRepository.AddSnapshots(ctx, tx, snapshots)
+		})
+		if err != nil {
+			return count, err
+		}
+		count += recorded
+
+		if len(addresses) < snapshotBatchSize {
+			break
+		}
+		afterAddress = addresses[len(addresses)-1]
+	}
+
+	if count > 0 {
+		logging.FromContext(ctx).Info("balance snapshots recorded", "count", count)
+	}
+	return count, nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceHistoryService(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "balanceHistoryServiceTests", &model.Wallet{}, &model.Transfer{}, &model.BalanceSnapshot{})
+
+	d := BalanceHistoryService{
+		WalletRepository:          &repository.DatabaseWalletRepository{},
+		TransferRepository:        &repository.DatabaseTransferRepository{},
+		BalanceSnapshotRepository: &repository.DatabaseBalanceSnapshotRepository{},
+		Database:                  db,
+	}
+	created := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+
+	// The first wallet is created with 100 tokens and sends 20 tokens after an hour and
+	// 10 more after three hours.
+	prepare := func() {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Balance_Snapshots")
+		db.Exec("INSERT INTO Wallets(Address, Tokens, Created_At) VALUES ($1, $2, $3)", "0x0000000000000000000000000000000000000001", 70, created)
+		db.Exec("INSERT INTO Wallets(Address, Tokens, Created_At) VALUES ($1, $2, $3)", "0x0000000000000000000000000000000000000002", 30, created)
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 20, created.Add(time.Hour))
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, created.Add(3*time.Hour))
+	}
+
+	balanceAt := func(t *testing.T, address string, at time.Time) int {
+		balance, err := d.GetBalanceAt(ctx, address, at)
+		require.NoError(t, err)
+		return balance
+	}
+
+	t.Run("balance at without snapshots", func(t *testing.T) {
+		prepare()
+
+		require.Equal(t, 0, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(-time.Minute)))
+		require.Equal(t, 100, balanceAt(t, "0x0000000000000000000000000000000000000001", created))
+		require.Equal(t, 80, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(2*time.Hour)))
+		require.Equal(t, 70, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(4*time.Hour)))
+		require.Equal(t, 20, balanceAt(t, "0x0000000000000000000000000000000000000002", created.Add(2*time.Hour)))
+		require.Equal(t, 0, balanceAt(t, "0x0000000000000000000000000000000000000003", created))
+
+		_, err := d.GetBalanceAt(ctx, "0x0000000000000000000000000000000000000001", time.Now().Add(time.Hour))
+		require.Error(t, err)
+	})
+
+	t.Run("balance at with snapshots", func(t *testing.T) {
+		prepare()
+		// A wrong balance in the snapshot shows that it is used.
+		db.Exec("INSERT INTO Balance_Snapshots(Address, Tokens, Taken_At) VALUES ($1, $2, $3)",
+			"0x0000000000000000000000000000000000000001", 1080, created.Add(2*time.Hour+30*time.Minute))
+
+		require.Equal(t, 1080, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(2*time.Hour+30*time.Minute)))
+		require.Equal(t, 1070, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(4*time.Hour)))
+		require.Equal(t, 1080, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(90*time.Minute)))
+		require.Equal(t, 1100, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(30*time.Minute)))
+	})
+
+	t.Run("balance history", func(t *testing.T) {
+		prepare()
+
+		points, err := d.GetBalanceHistory(ctx, "0x0000000000000000000000000000000000000001",
+			created.Add(-time.Hour), created.Add(3*time.Hour), model.BalanceIntervalHour)
+		require.NoError(t, err)
+
+		balances := make([]int, 0, len(points))
+		for _, point := range points {
+			balances = append(balances, point.Balance)
+		}
+		require.Equal(t, []int{0, 100, 80, 80, 70}, balances)
+		require.True(t, created.Add(-time.Hour).Equal(points[0].At))
+
+		_, err = d.GetBalanceHistory(ctx, "0x0000000000000000000000000000000000000001",
+			created.Add(-2000*time.Hour), created, model.BalanceIntervalHour)
+		require.Error(t, err)
+	})
+
+	t.Run("snapshot balances", func(t *testing.T) {
+		prepare()
+		db.Exec("INSERT INTO Wallets(Address, Tokens, Created_At) VALUES ($1, $2, $3)", "0x0000000000000000000000000000000000000003", 5, created)
+
+		count, err := d.SnapshotBalances(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		require.Equal(t, 70, balanceAt(t, "0x0000000000000000000000000000000000000001", time.Now()))
+		require.Equal(t, 80, balanceAt(t, "0x0000000000000000000000000000000000000001", created.Add(2*time.Hour)))
+		require.Equal(t, 5, balanceAt(t, "0x0000000000000000000000000000000000000003", time.Now()))
+
+		// Only wallets with transfers since their latest snapshot are recorded again.
+		count, err = d.SnapshotBalances(ctx)
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		time.Sleep(time.Millisecond)
+		db.Exec("UPDATE Wallets SET Tokens = Tokens + 5 WHERE Address = $1", "0x0000000000000000000000000000000000000002")
+		db.Exec("INSERT INTO Transfers(From_Address, To_Address, Amount, Created_At) VALUES ($1, $2, $3, $4)",
+			"0x0000000000000000000000000000000000000003", "0x0000000000000000000000000000000000000002", 5, time.Now())
+		db.Exec("UPDATE Wallets SET Tokens = Tokens - 5 WHERE Address = $1", "0x0000000000000000000000000000000000000003")
+
+		count, err = d.SnapshotBalances(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+		require.Equal(t, 35, balanceAt(t, "0x0000000000000000000000000000000000000002", time.Now()))
+		require.Equal(t, 70, balanceAt(t, "0x0000000000000000000000000000000000000001", time.Now()))
+	})
+}
+
+func TestNthInterval(t *testing.T) {
+	start := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		interval model.BalanceInterval
+		expected time.Time
+	}{
+		{model.BalanceIntervalHour, time.Date(2026, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{model.BalanceIntervalDay, time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)},
+		{model.BalanceIntervalWeek, time.Date(2026, 2, 14, 9, 0, 0, 0, time.UTC)},
+		{model.BalanceIntervalMonth, time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.interval), func(t *testing.T) {
+			at, err := nthInterval(start, c.interval, 2)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, at)
+		})
+	}
+
+	_, err := nthInterval(start, model.BalanceInterval("YEAR"), 1)
+	require.Error(t, err)
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+type BalanceHistoryServicer interface {
+	GetBalanceAt(ctx context.Context, address string, at time.Time) (int, error)
+	GetBalanceHistory(ctx context.Context, address string, from time.Time, to time.Time, interval model.BalanceInterval) ([]*model.BalancePoint, error)
+}
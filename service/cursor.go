@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+// walletCursor is the position of a wallet in a listing, encoded in opaque cursors.
+// The order is included, so that a cursor isn't used with a different order by mistake.
+type walletCursor struct {
+	Order     model.WalletOrder `json:"o"`
+	ID        uint              `json:"i"`
+	Tokens    int               `json:"t"`
+	CreatedAt time.Time         `json:"c"`
+}
+
+func encodeWalletCursor(order model.WalletOrder, wallet *model.Wallet) string {
+	encoded, _ := json.Marshal(walletCursor{
+		Order:     order,
+		ID:        wallet.ID,
+		Tokens:    wallet.Tokens,
+		CreatedAt: wallet.CreatedAt,
+	})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeWalletCursor returns a wallet with the fields needed to continue the listing in
+// the given order after the wallet the cursor was created for.
+func decodeWalletCursor(order model.WalletOrder, cursor string) (*model.Wallet, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c walletCursor
+	err = json.Unmarshal(decoded, &c)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	if c.Order != order {
+		return nil, errors.New("cursor was created for a different order")
+	}
+
+	wallet := &model.Wallet{Tokens: c.Tokens}
+	wallet.ID = c.ID
+	wallet.CreatedAt = c.CreatedAt
+	return wallet, nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletCursor(t *testing.T) {
+	wallet := &model.Wallet{Tokens: 250}
+	wallet.ID = 7
+	wallet.CreatedAt = time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	cursor := encodeWalletCursor(model.WalletOrderBalanceDesc, wallet)
+
+	decoded, err := decodeWalletCursor(model.WalletOrderBalanceDesc, cursor)
+	require.NoError(t, err)
+	require.Equal(t, uint(7), decoded.ID)
+	require.Equal(t, 250, decoded.Tokens)
+	require.True(t, wallet.CreatedAt.Equal(decoded.CreatedAt))
+
+	_, err = decodeWalletCursor(model.WalletOrderCreatedAtAsc, cursor)
+	require.Error(t, err)
+
+	_, err = decodeWalletCursor(model.WalletOrderBalanceDesc, "not a cursor")
+	require.Error(t, err)
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/outbox"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"gorm.io/gorm"
+)
+
+// errImportRolledBack rolls back the transaction of an import that is a dry run or failed
+// validation.
+var errImportRolledBack = errors.New("import rolled back")
+
+// ImportService loads opening balances and transfers from CSV files, e.g. when migrating
+// from another ledger.
+type ImportService struct {
+	ImportRepository repository.ImportRepositorier
+	// Outbox records the events of imported transfers, like for transfers made by
+	// WalletService. Nil disables them.
+	Outbox   outbox.Outbox
+	Database *gorm.DB
+}
+
+type ImportReport struct {
+	DryRun bool `json:"dryRun"`
+	// Whether the rows were recorded, which happens only if there are no errors and it's
+	// not a dry run.
+	Applied        bool          `json:"applied"`
+	Rows           int           `json:"rows"`
+	TotalAmount    int           `json:"totalAmount"`
+	WalletsCreated int           `json:"walletsCreated"`
+	Errors         []ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Line    int    `json:"line,omitempty"`
+	Address string `json:"address,omitempty"`
+	Message string `json:"message"`
+}
+
+// Import reads rows from a CSV file with the columns address,amount (opening balances,
+// imported as transfers from the treasury) or from,to,amount (transfers), optionally
+// preceded by a header, and records them in a single transaction. Nothing is recorded if
+// any row is invalid, is sent from a frozen or closed wallet, or would leave a wallet with
+// less tokens than it has reserved, which is reported in ImportReport.Errors. A dry run
+// makes all checks without recording rows.
+func (d *ImportService) Import(ctx context.Context, r io.Reader, dryRun bool) (*ImportReport, error) {
+	rows, rowErrors, err := ParseImport(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{
+		DryRun: dryRun,
+		Rows:   len(rows),
+		Errors: rowErrors,
+	}
+	for _, row := range rows {
+		report.TotalAmount += row.Amount
+	}
+	if len(rows) == 0 && len(report.Errors) == 0 {
+		report.Errors = append(report.Errors, ImportError{Message: "no rows to import"})
+	}
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	// Frozen wallets can receive tokens, but not send them.
+	senders := make(map[string]bool)
+	for _, row := range rows {
+		senders[row.FromAddress] = true
+	}
+
+	// COPY needs the connection the transaction runs on, so the transaction is started
+	// on a dedicated one.
+	sqlDB, err := d.Database.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	db := d.Database.WithContext(ctx)
+	db.Statement.ConnPool = conn
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := d.ImportRepository.CopyImportRows(ctx, tx, conn, rows)
+		if err != nil {
+			return err
+		}
+
+		report.WalletsCreated, err = d.ImportRepository.CreateImportWallets(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		wallets, err := d.ImportRepository.LockImportWallets(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, wallet := range wallets {
+			switch {
+			case wallet.Status == model.WalletStatusClosed:
+				report.Errors = append(report.Errors, ImportError{Address: wallet.Address, Message: "wallet is closed"})
+			case wallet.Status == model.WalletStatusFrozen && senders[wallet.Address]:
+				report.Errors = append(report.Errors, ImportError{Address: wallet.Address, Message: "sender's wallet is frozen"})
+			}
+		}
+
+		err = d.ImportRepository.ApplyImportRows(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		overdrawn, err := d.ImportRepository.GetOverdrawnImportWallets(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, wallet := range overdrawn {
+			report.Errors = append(report.Errors, ImportError{
+				Address: wallet.Address,
+				Message: fmt.Sprintf("balance would be %d with %d reserved", wallet.Tokens, wallet.Reserved),
+			})
+		}
+
+		if dryRun || len(report.Errors) > 0 {
+			return errImportRolledBack
+		}
+		return d.addImportEvents(ctx, tx)
+	})
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		return nil, err
+	}
+
+	report.Applied = err == nil
+	if report.Applied {
+		logging.FromContext(ctx).Info("rows imported", "rows", report.Rows, "total_amount", report.TotalAmount,
+			"wallets_created", report.WalletsCreated)
+	}
+	return report, nil
+}
+
+// addImportEvents records the outbox events of the imported transfers.
+func (d *ImportService) addImportEvents(ctx context.Context, tx *gorm.DB) error {
+	if d.Outbox == nil {
+		return nil
+	}
+
+	transfers, err := d.ImportRepository.GetImportTransfers(ctx, tx)
+	if err != nil {
+		return err
+	}
+	events := make([]*outbox.Event, 0, 2*len(transfers))
+	for i := range transfers {
+		transferEvents, err := outbox.NewTransferEvents(&transfers[i])
+		if err != nil {
+			return err
+		}
+		events = append(events, transferEvents...)
+	}
+	return d.Outbox.Add(ctx, tx, events...)
+}
+
+// ParseImport reads the rows of an imported CSV file, described in ImportService.Import,
+// and returns them together with the errors of invalid rows. It fails only if the file
+// can't be read as CSV.
+func ParseImport(r io.Reader) ([]model.ImportRow, []ImportError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	var rows []model.ImportRow
+	var rowErrors []ImportError
+	columns := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if errors.Is(err, csv.ErrFieldCount) {
+			rowErrors = append(rowErrors, ImportError{Line: line, Message: fmt.Sprintf("expected %d columns", columns)})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if columns == 0 {
+			columns = len(record)
+			if columns != 2 && columns != 3 {
+				return nil, nil, fmt.Errorf("line %d: expected columns address,amount or from,to,amount", line)
+			}
+			if strings.EqualFold(strings.TrimSpace(record[columns-1]), "amount") {
+				continue
+			}
+		}
+
+		row, err := parseImportRecord(record)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportError{Line: line, Message: err.Error()})
+			continue
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+func parseImportRecord(record []string) (model.ImportRow, error) {
+	row := model.ImportRow{FromAddress: TreasuryAddress}
+	if len(record) == 3 {
+		row.FromAddress = strings.TrimSpace(record[0])
+	}
+	row.ToAddress = strings.TrimSpace(record[len(record)-2])
+
+	for _, address := range []string{row.FromAddress, row.ToAddress} {
+		err := address_helper.CheckAddress(address)
+		if err != nil {
+			return row, fmt.Errorf("%s: %w", address, err)
+		}
+	}
+	if row.FromAddress == row.ToAddress {
+		return row, errors.New("from and to addresses cannot be equal")
+	}
+
+	amount, err := strconv.Atoi(strings.TrimSpace(record[len(record)-1]))
+	if err != nil {
+		return row, fmt.Errorf("invalid amount %q", record[len(record)-1])
+	}
+	if amount <= 0 {
+		return row, errors.New("amount must be greater than zero")
+	}
+	row.Amount = amount
+	return row, nil
+}
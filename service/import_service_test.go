@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/kamil7430/TokenTransferAPI/ledger"
+	"github.com/kamil7430/TokenTransferAPI/outbox"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImport(t *testing.T) {
+	t.Run("opening balances with header", func(t *testing.T) {
+		rows, rowErrors, err := ParseImport(strings.NewReader("address,amount\n" +
+			"0x0000000000000000000000000000000000000001,100\n" +
+			"0x0000000000000000000000000000000000000002, 20\n"))
+		require.NoError(t, err)
+		require.Empty(t, rowErrors)
+		require.Equal(t, []model.ImportRow{
+			{Line: 2, FromAddress: TreasuryAddress, ToAddress: "0x0000000000000000000000000000000000000001", Amount: 100},
+			{Line: 3, FromAddress: TreasuryAddress, ToAddress: "0x0000000000000000000000000000000000000002", Amount: 20},
+		}, rows)
+	})
+
+	t.Run("transfers without header", func(t *testing.T) {
+		rows, rowErrors, err := ParseImport(strings.NewReader(
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,5\n"))
+		require.NoError(t, err)
+		require.Empty(t, rowErrors)
+		require.Equal(t, []model.ImportRow{
+			{Line: 1, FromAddress: "0x0000000000000000000000000000000000000001", ToAddress: "0x0000000000000000000000000000000000000002", Amount: 5},
+		}, rows)
+	})
+
+	t.Run("invalid rows", func(t *testing.T) {
+		rows, rowErrors, err := ParseImport(strings.NewReader("from,to,amount\n" +
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,5\n" +
+			"0x01,0x0000000000000000000000000000000000000002,5\n" +
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000001,5\n" +
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,-5\n" +
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,five\n" +
+			"0x0000000000000000000000000000000000000001,5\n"))
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, []ImportError{
+			{Line: 3, Message: "0x01: invalid address length"},
+			{Line: 4, Message: "from and to addresses cannot be equal"},
+			{Line: 5, Message: "amount must be greater than zero"},
+			{Line: 6, Message: `invalid amount "five"`},
+			{Line: 7, Message: "expected 3 columns"},
+		}, rowErrors)
+	})
+
+	t.Run("unexpected columns", func(t *testing.T) {
+		_, _, err := ParseImport(strings.NewReader("0x0000000000000000000000000000000000000001\n"))
+		require.Error(t, err)
+	})
+}
+
+func TestImportService(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "importServiceTests", &model.Wallet{}, &model.Transfer{}, &ledger.JournalEntry{}, &ledger.Posting{}, &outbox.Event{})
+
+	d := ImportService{
+		ImportRepository: &repository.DatabaseImportRepository{},
+		Outbox:           &outbox.DatabaseOutbox{},
+		Database:         db,
+	}
+	reconciliationService := ReconciliationService{
+		ReconciliationRepository: &repository.DatabaseReconciliationRepository{},
+		Ledger:                   &ledger.DatabaseLedger{},
+		Database:                 db,
+	}
+
+	prepare := func() {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Journal_Entries, Postings, Outbox_Events")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", TreasuryAddress, 0)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 0)
+		require.NoError(t, (&ledger.DatabaseLedger{}).Post(ctx, db, ledger.NewIssuanceEntry(TreasuryAddress, InitialSupply)))
+	}
+	tokensOf := func(address string) int {
+		var tokens int
+		db.Raw("SELECT Tokens FROM Wallets WHERE Address = $1", address).Scan(&tokens)
+		return tokens
+	}
+	const balances = "address,amount\n" +
+		"0x0000000000000000000000000000000000000001,300\n" +
+		"0x0000000000000000000000000000000000000002,200\n"
+
+	t.Run("import opening balances", func(t *testing.T) {
+		prepare()
+
+		report, err := d.Import(ctx, strings.NewReader(balances), false)
+		require.NoError(t, err)
+		require.Empty(t, report.Errors)
+		require.True(t, report.Applied)
+		require.Equal(t, 2, report.Rows)
+		require.Equal(t, 500, report.TotalAmount)
+		require.Equal(t, 1, report.WalletsCreated)
+
+		require.Equal(t, InitialSupply-500, tokensOf(TreasuryAddress))
+		require.Equal(t, 300, tokensOf("0x0000000000000000000000000000000000000001"))
+		require.Equal(t, 200, tokensOf("0x0000000000000000000000000000000000000002"))
+
+		var transfers, postings int64
+		db.Model(&model.Transfer{}).Count(&transfers)
+		db.Model(&ledger.Posting{}).Count(&postings)
+		require.Equal(t, int64(2), transfers)
+		require.Equal(t, int64(2+4), postings)
+
+		var events []outbox.Event
+		db.Order("ID").Find(&events)
+		require.Len(t, events, 4)
+		require.Equal(t, outbox.EventTransferOutgoing, events[0].Type)
+		require.Equal(t, TreasuryAddress, events[0].Address)
+		require.Equal(t, outbox.EventTransferIncoming, events[1].Type)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", events[1].Address)
+		require.Equal(t, "0x0000000000000000000000000000000000000002", events[3].Address)
+
+		reconciliation, err := reconciliationService.Reconcile(ctx)
+		require.NoError(t, err)
+		require.True(t, reconciliation.OK)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		prepare()
+
+		report, err := d.Import(ctx, strings.NewReader(balances), true)
+		require.NoError(t, err)
+		require.Empty(t, report.Errors)
+		require.False(t, report.Applied)
+		require.Equal(t, 1, report.WalletsCreated)
+
+		require.Equal(t, InitialSupply, tokensOf(TreasuryAddress))
+		var wallets, events int64
+		db.Model(&model.Wallet{}).Count(&wallets)
+		db.Model(&outbox.Event{}).Count(&events)
+		require.Equal(t, int64(2), wallets)
+		require.Zero(t, events)
+	})
+
+	t.Run("overdrawn wallet", func(t *testing.T) {
+		prepare()
+
+		report, err := d.Import(ctx, strings.NewReader("from,to,amount\n"+
+			"0x0000000000000000000000000000000000000000,0x0000000000000000000000000000000000000001,10\n"+
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,15\n"), false)
+		require.NoError(t, err)
+		require.False(t, report.Applied)
+		require.Equal(t, []ImportError{
+			{Address: "0x0000000000000000000000000000000000000001", Message: "balance would be -5 with 0 reserved"},
+		}, report.Errors)
+
+		require.Equal(t, InitialSupply, tokensOf(TreasuryAddress))
+		require.Equal(t, 0, tokensOf("0x0000000000000000000000000000000000000001"))
+	})
+
+	t.Run("closed wallet", func(t *testing.T) {
+		prepare()
+		db.Exec("UPDATE Wallets SET Status = $1 WHERE Address = $2", model.WalletStatusClosed, "0x0000000000000000000000000000000000000001")
+
+		report, err := d.Import(ctx, strings.NewReader(balances), false)
+		require.NoError(t, err)
+		require.False(t, report.Applied)
+		require.Equal(t, []ImportError{
+			{Address: "0x0000000000000000000000000000000000000001", Message: "wallet is closed"},
+		}, report.Errors)
+	})
+
+	t.Run("frozen sender", func(t *testing.T) {
+		prepare()
+		db.Exec("UPDATE Wallets SET Status = $1 WHERE Address = $2", model.WalletStatusFrozen, "0x0000000000000000000000000000000000000001")
+
+		// Frozen wallets can still receive tokens.
+		report, err := d.Import(ctx, strings.NewReader(balances), false)
+		require.NoError(t, err)
+		require.True(t, report.Applied)
+
+		report, err = d.Import(ctx, strings.NewReader("from,to,amount\n"+
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,10\n"), false)
+		require.NoError(t, err)
+		require.False(t, report.Applied)
+		require.Equal(t, []ImportError{
+			{Address: "0x0000000000000000000000000000000000000001", Message: "sender's wallet is frozen"},
+		}, report.Errors)
+		require.Equal(t, 300, tokensOf("0x0000000000000000000000000000000000000001"))
+	})
+
+	t.Run("invalid rows are not imported", func(t *testing.T) {
+		prepare()
+
+		report, err := d.Import(ctx, strings.NewReader(balances+"0x0000000000000000000000000000000000000003,0\n"), false)
+		require.NoError(t, err)
+		require.False(t, report.Applied)
+		require.Equal(t, []ImportError{{Line: 4, Message: "amount must be greater than zero"}}, report.Errors)
+		require.Equal(t, InitialSupply, tokensOf(TreasuryAddress))
+	})
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/ledger"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"gorm.io/gorm"
+)
+
+// Checks made by Reconcile, reported in ReconciliationProblem.Check.
+const (
+	CheckTotalSupply     = "total_supply"
+	CheckLedgerBalance   = "ledger_balance"
+	CheckTransferEntry   = "transfer_entry"
+	CheckReservedBalance = "reserved_balance"
+	CheckNegativeBalance = "negative_balance"
+)
+
+// Maximum number of inconsistent wallets, and of inconsistent transfers, included in a
+// report.
+const maxReconciliationWallets = 1000
+
+// ReconciliationService verifies the invariants of balances, which hold as long as tokens
+// are moved only by WalletService.
+type ReconciliationService struct {
+	ReconciliationRepository repository.ReconciliationRepositorier
+	Ledger                   ledger.Ledger
+	Database                 *gorm.DB
+}
+
+type ReconciliationReport struct {
+	CheckedAt    time.Time               `json:"checkedAt"`
+	OK           bool                    `json:"ok"`
+	TotalSupply  int                     `json:"totalSupply"`
+	WalletTokens int                     `json:"walletTokens"`
+	Problems     []ReconciliationProblem `json:"problems"`
+	// Whether there were more inconsistent wallets or transfers than included in Problems.
+	Truncated bool `json:"truncated"`
+}
+
+type ReconciliationProblem struct {
+	Check      string `json:"check"`
+	Address    string `json:"address,omitempty"`
+	TransferID uint   `json:"transferId,omitempty"`
+	Expected   int    `json:"expected"`
+	Actual     int    `json:"actual"`
+}
+
+// Reconcile verifies the balances against the journal: that the balances of all wallets
+// sum up to the tokens issued by the issuance account, that every balance equals the sum
+// of the postings to the wallet's account, and that every transfer is recorded by a
+// journal entry moving its amount. It also verifies that reserved balances equal the
+// amounts of active holds, and that no balance is negative.
+func (d *ReconciliationService) Reconcile(ctx context.Context) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{
+		CheckedAt: time.Now(),
+		Problems:  []ReconciliationProblem{},
+	}
+
+	// All checks see the same state of the database, so that transfers made meanwhile
+	// don't show up as drift.
+	err := d.Database.Transaction(func(tx *gorm.DB) error {
+		issued, err := d.Ledger.Balance(ctx, tx, ledger.IssuanceAccount)
+		if err != nil {
+			return err
+		}
+		report.TotalSupply = -issued
+
+		report.WalletTokens, err = d.ReconciliationRepository.SumWalletTokens(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if report.WalletTokens != report.TotalSupply {
+			report.Problems = append(report.Problems, ReconciliationProblem{
+				Check:    CheckTotalSupply,
+				Expected: report.TotalSupply,
+				Actual:   report.WalletTokens,
+			})
+		}
+
+		wallets, err := d.ReconciliationRepository.GetInconsistentWallets(ctx, tx, maxReconciliationWallets+1)
+		if err != nil {
+			return err
+		}
+		if len(wallets) > maxReconciliationWallets {
+			wallets = wallets[:maxReconciliationWallets]
+			report.Truncated = true
+		}
+
+		for _, wallet := range wallets {
+			if wallet.Tokens != wallet.LedgerBalance {
+				report.Problems = append(report.Problems, ReconciliationProblem{
+					Check:    CheckLedgerBalance,
+					Address:  wallet.Address,
+					Expected: wallet.LedgerBalance,
+					Actual:   wallet.Tokens,
+				})
+			}
+			if wallet.Reserved != wallet.HeldAmount {
+				report.Problems = append(report.Problems, ReconciliationProblem{
+					Check:    CheckReservedBalance,
+					Address:  wallet.Address,
+					Expected: wallet.HeldAmount,
+					Actual:   wallet.Reserved,
+				})
+			}
+			if wallet.Tokens < 0 || wallet.Reserved < 0 || wallet.Reserved > wallet.Tokens {
+				report.Problems = append(report.Problems, ReconciliationProblem{
+					Check:    CheckNegativeBalance,
+					Address:  wallet.Address,
+					Expected: 0,
+					Actual:   min(wallet.Tokens, wallet.Reserved, wallet.Tokens-wallet.Reserved),
+				})
+			}
+		}
+
+		transfers, err := d.ReconciliationRepository.GetInconsistentTransfers(ctx, tx, maxReconciliationWallets+1)
+		if err != nil {
+			return err
+		}
+		if len(transfers) > maxReconciliationWallets {
+			transfers = transfers[:maxReconciliationWallets]
+			report.Truncated = true
+		}
+
+		for _, transfer := range transfers {
+			report.Problems = append(report.Problems, ReconciliationProblem{
+				Check:      CheckTransferEntry,
+				TransferID: transfer.TransferID,
+				Expected:   transfer.Amount,
+				Actual:     transfer.PostedAmount,
+			})
+		}
+		return nil
+	}, readOnlySnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	report.OK = len(report.Problems) == 0
+	return report, nil
+}
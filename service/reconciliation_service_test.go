@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/kamil7430/TokenTransferAPI/ledger"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciliationService(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "reconciliationServiceTests", &model.Wallet{}, &model.Transfer{}, &ledger.JournalEntry{}, &ledger.Posting{}, &model.WalletPolicy{}, &model.Hold{})
+
+	walletService := WalletService{
+		WalletRepository:       &repository.DatabaseWalletRepository{},
+		TransferRepository:     &repository.DatabaseTransferRepository{},
+		WalletPolicyRepository: &repository.DatabaseWalletPolicyRepository{},
+		HoldRepository:         &repository.DatabaseHoldRepository{},
+		Ledger:                 &ledger.DatabaseLedger{},
+		Database:               db,
+	}
+	d := ReconciliationService{
+		ReconciliationRepository: &repository.DatabaseReconciliationRepository{},
+		Ledger:                   &ledger.DatabaseLedger{},
+		Database:                 db,
+	}
+
+	// The treasury is issued the initial supply, sends some tokens and holds some more,
+	// as the server would.
+	prepare := func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Holds, Journal_Entries, Postings")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", TreasuryAddress, 0)
+		require.NoError(t, walletService.Ledger.Post(ctx, db, ledger.NewIssuanceEntry(TreasuryAddress, InitialSupply)))
+
+		_, err := walletService.Transfer(ctx, TreasuryAddress, "0x0000000000000000000000000000000000000001", 300, nil, nil)
+		require.NoError(t, err)
+		_, err = walletService.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 100, nil, nil)
+		require.NoError(t, err)
+		_, err = walletService.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 50, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+	}
+
+	t.Run("consistent balances", func(t *testing.T) {
+		prepare(t)
+
+		report, err := d.Reconcile(ctx)
+		require.NoError(t, err)
+		require.True(t, report.OK)
+		require.Empty(t, report.Problems)
+		require.Equal(t, InitialSupply, report.WalletTokens)
+	})
+
+	t.Run("manually edited balance", func(t *testing.T) {
+		prepare(t)
+		db.Exec("UPDATE Wallets SET Tokens = Tokens + 10 WHERE Address = $1", "0x0000000000000000000000000000000000000002")
+
+		report, err := d.Reconcile(ctx)
+		require.NoError(t, err)
+		require.False(t, report.OK)
+		require.ElementsMatch(t, []ReconciliationProblem{
+			{Check: CheckTotalSupply, Expected: InitialSupply, Actual: InitialSupply + 10},
+			{Check: CheckLedgerBalance, Address: "0x0000000000000000000000000000000000000002", Expected: 100, Actual: 110},
+		}, report.Problems)
+	})
+
+	t.Run("transfer differing from its journal entry", func(t *testing.T) {
+		prepare(t)
+		db.Exec("UPDATE Transfers SET Amount = 150 WHERE To_Address = $1", "0x0000000000000000000000000000000000000002")
+
+		report, err := d.Reconcile(ctx)
+		require.NoError(t, err)
+		require.False(t, report.OK)
+		require.Len(t, report.Problems, 1)
+		require.Equal(t, CheckTransferEntry, report.Problems[0].Check)
+		require.NotZero(t, report.Problems[0].TransferID)
+		require.Equal(t, 150, report.Problems[0].Expected)
+		require.Equal(t, 100, report.Problems[0].Actual)
+	})
+
+	t.Run("tokens issued outside of the journal", func(t *testing.T) {
+		prepare(t)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000003", 10)
+
+		report, err := d.Reconcile(ctx)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []ReconciliationProblem{
+			{Check: CheckTotalSupply, Expected: InitialSupply, Actual: InitialSupply + 10},
+			{Check: CheckLedgerBalance, Address: "0x0000000000000000000000000000000000000003", Expected: 0, Actual: 10},
+		}, report.Problems)
+	})
+
+	t.Run("negative and over-reserved balances", func(t *testing.T) {
+		prepare(t)
+		db.Exec("UPDATE Wallets SET Tokens = -5 WHERE Address = $1", "0x0000000000000000000000000000000000000002")
+		db.Exec("UPDATE Wallets SET Reserved = 60 WHERE Address = $1", "0x0000000000000000000000000000000000000001")
+
+		report, err := d.Reconcile(ctx)
+		require.NoError(t, err)
+		require.False(t, report.OK)
+
+		checks := map[string][]string{}
+		for _, problem := range report.Problems {
+			checks[problem.Address] = append(checks[problem.Address], problem.Check)
+		}
+		require.ElementsMatch(t, []string{CheckLedgerBalance, CheckNegativeBalance}, checks["0x0000000000000000000000000000000000000002"])
+		require.ElementsMatch(t, []string{CheckReservedBalance}, checks["0x0000000000000000000000000000000000000001"])
+	})
+}
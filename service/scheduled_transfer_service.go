@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"gorm.io/gorm"
+)
+
+type ScheduledTransferService struct {
+	ScheduledTransferRepository    repository.ScheduledTransferRepositorier
+	ScheduledTransferRunRepository repository.ScheduledTransferRunRepositorier
+	// Executes the transfers. It is copied with the database replaced by the transaction
+	// holding the lock of the scheduled transfer, so it must be the concrete service.
+	WalletService *WalletService
+	Database      *gorm.DB
+}
+
+// Maximum number of scheduled transfers executed by a single RunDueTransfers call.
+const runDueTransfersBatchSize = 100
+
+// Maximum number of runs returned by GetRuns.
+const maxRunsLimit = 100
+
+func (d *ScheduledTransferService) GetScheduledTransfer(ctx context.Context, id uint) (*model.ScheduledTransfer, error) {
+	return d.ScheduledTransferRepository.GetScheduledTransferByID(ctx, d.Database, id)
+}
+
+func (d *ScheduledTransferService) GetScheduledTransfers(ctx context.Context, address string, status *model.ScheduleStatus) ([]*model.ScheduledTransfer, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return d.ScheduledTransferRepository.GetScheduledTransfersByAddress(ctx, d.Database, address, status)
+}
+
+func (d *ScheduledTransferService) GetRuns(ctx context.Context, scheduledTransferID uint, limit int) ([]*model.ScheduledTransferRun, error) {
+	if limit <= 0 || limit > maxRunsLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxRunsLimit)
+	}
+	return d.ScheduledTransferRunRepository.GetRunsByScheduledTransferID(ctx, d.Database, scheduledTransferID, limit)
+}
+
+func (d *ScheduledTransferService) ScheduleTransfer(ctx context.Context, fromAddress string, toAddress string, amount int, runAt time.Time, recurrence *model.Recurrence) (*model.ScheduledTransfer, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if fromAddress == toAddress {
+		return nil, errors.New("from and to addresses cannot be equal")
+	}
+	if recurrence != nil && !recurrence.IsValid() {
+		return nil, errors.New("unknown recurrence")
+	}
+
+	err := address_helper.CheckAddress(fromAddress)
+	if err != nil {
+		return nil, err
+	}
+	err = address_helper.CheckAddress(toAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &model.ScheduledTransfer{
+		FromAddress: fromAddress,
+		ToAddress:   toAddress,
+		Amount:      amount,
+		Recurrence:  recurrence,
+		Status:      model.ScheduleStatusActive,
+		NextRunAt:   runAt,
+		FirstRunAt:  runAt,
+	}
+	err = d.ScheduledTransferRepository.AddScheduledTransfer(ctx, d.Database, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("transfer scheduled", "schedule_id", schedule.ID,
+		"from_address", fromAddress, "to_address", toAddress, "amount", amount, "run_at", runAt)
+	return schedule, nil
+}
+
+func (d *ScheduledTransferService) CancelScheduledTransfer(ctx context.Context, id uint) (*model.ScheduledTransfer, error) {
+	var schedule *model.ScheduledTransfer
+	err := d.Database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		// Waits for the run of the transfer, if it is being executed right now.
+		schedule, err = d.ScheduledTransferRepository.GetScheduledTransferByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if schedule.Status != model.ScheduleStatusActive {
+			return fmt.Errorf("scheduled transfer is already %s", strings.ToLower(string(schedule.Status)))
+		}
+
+		schedule.Status = model.ScheduleStatusCancelled
+		return d.ScheduledTransferRepository.UpdateScheduledTransfer(ctx, tx, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("scheduled transfer cancelled", "schedule_id", id)
+	return schedule, nil
+}
+
+// RunDueTransfers executes a batch of scheduled transfers that are due at now and returns
+// how many were executed, successfully or not. It can run concurrently in many instances
+// of the service, as every transfer is claimed by exactly one of them.
+func (d *ScheduledTransferService) RunDueTransfers(ctx context.Context, now time.Time) (int, error) {
+	for executed := 0; executed < runDueTransfersBatchSize; executed++ {
+		err := d.Database.Transaction(func(tx *gorm.DB) error {
+			schedule, err := d.ScheduledTransferRepository.ClaimDueScheduledTransfer(ctx, tx, now)
+			if err != nil {
+				return err
+			}
+			return d.runScheduledTransfer(ctx, tx, schedule, now)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return executed, nil
+		}
+		if err != nil {
+			return executed, err
+		}
+	}
+	return runDueTransfersBatchSize, nil
+}
+
+// runScheduledTransfer executes the claimed transfer in the transaction holding its lock,
+// records the outcome and moves the schedule to its next run. The transfer runs in a
+// nested transaction, so its failure is recorded instead of rolling back the claim, and
+// its success is committed together with the new schedule, so it is never repeated.
+func (d *ScheduledTransferService) runScheduledTransfer(ctx context.Context, tx *gorm.DB, schedule *model.ScheduledTransfer, now time.Time) error {
+	ctx = logging.With(ctx, "schedule_id", schedule.ID)
+
+	walletService := *d.WalletService
+	walletService.Database = tx
+	_, transferErr := walletService.Transfer(ctx, schedule.FromAddress, schedule.ToAddress, schedule.Amount, nil, nil)
+
+	run := &model.ScheduledTransferRun{
+		ScheduledTransferID: schedule.ID,
+		ScheduledAt:         schedule.NextRunAt,
+		ExecutedAt:          now,
+		Outcome:             model.RunOutcomeSucceeded,
+	}
+	if transferErr != nil {
+		message := transferErr.Error()
+		run.Outcome = model.RunOutcomeFailed
+		run.Error = &message
+	}
+	err := d.ScheduledTransferRunRepository.AddRun(ctx, tx, run)
+	if err != nil {
+		return err
+	}
+
+	if schedule.Recurrence == nil {
+		schedule.Status = model.ScheduleStatusCompleted
+	} else {
+		schedule.NextRunAt, err = nextRunAt(schedule.FirstRunAt, *schedule.Recurrence, now)
+		if err != nil {
+			return err
+		}
+	}
+	err = d.ScheduledTransferRepository.UpdateScheduledTransfer(ctx, tx, schedule)
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("scheduled transfer executed", "outcome", run.Outcome, "next_run_at", schedule.NextRunAt)
+	return nil
+}
+
+// nextRunAt returns the first time after now at which a transfer first run at first recurs.
+// Every run is computed from first rather than from the previous run, so monthly runs keep
+// the day of the month of first, moved to the last day of shorter months.
+func nextRunAt(first time.Time, recurrence model.Recurrence, now time.Time) (time.Time, error) {
+	next := first
+	for i := 1; !next.After(now); i++ {
+		switch recurrence {
+		case model.RecurrenceDaily:
+			next = first.AddDate(0, 0, i)
+		case model.RecurrenceWeekly:
+			next = first.AddDate(0, 0, 7*i)
+		case model.RecurrenceMonthly:
+			next = addMonths(first, i)
+		default:
+			return time.Time{}, fmt.Errorf("unknown recurrence %q", recurrence)
+		}
+	}
+	return next, nil
+}
+
+// addMonths returns t moved by the given number of months, with the day clamped to the
+// last day of the resulting month.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	// Day 0 of the following month is the last day of the month.
+	lastDay := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	return time.Date(year, month+time.Month(months), min(day, lastDay), hour, minute, second, t.Nanosecond(), t.Location())
+}
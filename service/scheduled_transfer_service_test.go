@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/kamil7430/TokenTransferAPI/ledger"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledTransferService(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "scheduledTransferServiceTests",
+		&model.Wallet{}, &model.Transfer{}, &ledger.JournalEntry{}, &ledger.Posting{}, &model.WalletPolicy{}, &model.ScheduledTransfer{}, &model.ScheduledTransferRun{})
+
+	d := ScheduledTransferService{
+		ScheduledTransferRepository:    &repository.DatabaseScheduledTransferRepository{},
+		ScheduledTransferRunRepository: &repository.DatabaseScheduledTransferRunRepository{},
+		WalletService: &WalletService{
+			WalletRepository:       &repository.DatabaseWalletRepository{},
+			TransferRepository:     &repository.DatabaseTransferRepository{},
+			WalletPolicyRepository: &repository.DatabaseWalletPolicyRepository{},
+			Ledger:                 &ledger.DatabaseLedger{},
+			Database:               db,
+		},
+		Database: db,
+	}
+	weekly := model.RecurrenceWeekly
+	now := time.Now()
+
+	t.Run("run one-off transfer", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Scheduled_Transfers, Scheduled_Transfer_Runs")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		schedule, err := d.ScheduleTransfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, now.Add(time.Hour), nil)
+		require.NoError(t, err)
+
+		executed, err := d.RunDueTransfers(ctx, now)
+		require.NoError(t, err)
+		require.Equal(t, 0, executed)
+
+		executed, err = d.RunDueTransfers(ctx, now.Add(2*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 1, executed)
+
+		wallet, err := d.WalletService.GetWallet(ctx, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		require.Equal(t, 30, wallet.Tokens)
+
+		schedule, err = d.GetScheduledTransfer(ctx, schedule.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.ScheduleStatusCompleted, schedule.Status)
+
+		runs, err := d.GetRuns(ctx, schedule.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		require.Equal(t, model.RunOutcomeSucceeded, runs[0].Outcome)
+
+		executed, err = d.RunDueTransfers(ctx, now.Add(3*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 0, executed)
+	})
+
+	t.Run("run recurring transfer", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Scheduled_Transfers, Scheduled_Transfer_Runs")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 50)
+
+		schedule, err := d.ScheduleTransfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, now, &weekly)
+		require.NoError(t, err)
+
+		_, err = d.RunDueTransfers(ctx, now)
+		require.NoError(t, err)
+		_, err = d.RunDueTransfers(ctx, now.AddDate(0, 0, 7))
+		require.NoError(t, err)
+
+		schedule, err = d.GetScheduledTransfer(ctx, schedule.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.ScheduleStatusActive, schedule.Status)
+		require.WithinDuration(t, now.AddDate(0, 0, 14), schedule.NextRunAt, time.Millisecond)
+
+		runs, err := d.GetRuns(ctx, schedule.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		require.Equal(t, model.RunOutcomeFailed, runs[0].Outcome)
+		require.Equal(t, "insufficient balance", *runs[0].Error)
+		require.Equal(t, model.RunOutcomeSucceeded, runs[1].Outcome)
+	})
+
+	t.Run("cancel scheduled transfer", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Scheduled_Transfers, Scheduled_Transfer_Runs")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		schedule, err := d.ScheduleTransfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, now, &weekly)
+		require.NoError(t, err)
+
+		schedule, err = d.CancelScheduledTransfer(ctx, schedule.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.ScheduleStatusCancelled, schedule.Status)
+
+		_, err = d.CancelScheduledTransfer(ctx, schedule.ID)
+		require.Error(t, err)
+
+		executed, err := d.RunDueTransfers(ctx, now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 0, executed)
+
+		active := model.ScheduleStatusActive
+		schedules, err := d.GetScheduledTransfers(ctx, "0x0000000000000000000000000000000000000001", &active)
+		require.NoError(t, err)
+		require.Empty(t, schedules)
+
+		schedules, err = d.GetScheduledTransfers(ctx, "0x0000000000000000000000000000000000000001", nil)
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+	})
+}
+
+func TestNextRunAt(t *testing.T) {
+	first := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name       string
+		recurrence model.Recurrence
+		now        time.Time
+		expected   time.Time
+	}{
+		{"daily", model.RecurrenceDaily, first, time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"weekly", model.RecurrenceWeekly, first, time.Date(2026, 2, 7, 9, 0, 0, 0, time.UTC)},
+		{"monthly", model.RecurrenceMonthly, first, time.Date(2026, 2, 28, 9, 0, 0, 0, time.UTC)},
+		{"skips missed runs", model.RecurrenceWeekly, first.AddDate(0, 0, 20), time.Date(2026, 2, 21, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			next, err := nextRunAt(first, c.recurrence, c.now)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, next)
+		})
+	}
+
+	_, err := nextRunAt(first, model.Recurrence("YEARLY"), first)
+	require.Error(t, err)
+}
+
+func TestNextRunAtMonthly(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name     string
+		first    time.Time
+		expected []time.Time
+	}{
+		{"29th", date(2026, 1, 29), []time.Time{date(2026, 2, 28), date(2026, 3, 29), date(2026, 4, 29)}},
+		{"29th in a leap year", date(2028, 1, 29), []time.Time{date(2028, 2, 29), date(2028, 3, 29), date(2028, 4, 29)}},
+		{"30th", date(2026, 1, 30), []time.Time{date(2026, 2, 28), date(2026, 3, 30), date(2026, 4, 30)}},
+		{"31st", date(2026, 1, 31), []time.Time{date(2026, 2, 28), date(2026, 3, 31), date(2026, 4, 30), date(2026, 5, 31)}},
+		{"31st across a year", date(2026, 12, 31), []time.Time{date(2027, 1, 31), date(2027, 2, 28), date(2027, 3, 31)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			previous := c.first
+			for _, expected := range c.expected {
+				next, err := nextRunAt(c.first, model.RecurrenceMonthly, previous)
+				require.NoError(t, err)
+				require.Equal(t, expected, next)
+				previous = next
+			}
+		})
+	}
+}
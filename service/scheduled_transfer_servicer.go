@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+type ScheduledTransferServicer interface {
+	GetScheduledTransfer(ctx context.Context, id uint) (*model.ScheduledTransfer, error)
+	GetScheduledTransfers(ctx context.Context, address string, status *model.ScheduleStatus) ([]*model.ScheduledTransfer, error)
+	GetRuns(ctx context.Context, scheduledTransferID uint, limit int) ([]*model.ScheduledTransferRun, error)
+	ScheduleTransfer(ctx context.Context, fromAddress string, toAddress string, amount int, runAt time.Time, recurrence *model.Recurrence) (*model.ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, id uint) (*model.ScheduledTransfer, error)
+}
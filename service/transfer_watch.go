@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+// Number of transfers fetched at once by TransfersAfter.
+const watchPageSize = 100
+
+// LatestTransferID returns the ID of the newest transfer sent or received by the wallet,
+// or 0 if there is none. Together with TransfersAfter, it lets callers poll the wallet
+// service for new transfers.
+func LatestTransferID(ctx context.Context, walletService WalletServicer, address string) (uint, error) {
+	latest, err := walletService.GetTransfers(ctx, address, nil, 1, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(latest) == 0 {
+		return 0, nil
+	}
+	return latest[0].ID, nil
+}
+
+// TransfersAfter returns the transfers sent or received by the wallet with IDs greater
+// than afterID, oldest first.
+func TransfersAfter(ctx context.Context, walletService WalletServicer, address string, afterID uint) ([]*model.Transfer, error) {
+	var transfers []*model.Transfer
+	var before *uint
+	for {
+		page, err := walletService.GetTransfers(ctx, address, nil, watchPageSize, before)
+		if err != nil {
+			return nil, err
+		}
+		for _, transfer := range page {
+			if transfer.ID <= afterID {
+				slices.Reverse(transfers)
+				return transfers, nil
+			}
+			transfers = append(transfers, transfer)
+		}
+		if len(page) < watchPageSize {
+			slices.Reverse(transfers)
+			return transfers, nil
+		}
+		before = &page[len(page)-1].ID
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"gorm.io/gorm"
+)
+
+type WalletPolicyService struct {
+	WalletPolicyRepository repository.WalletPolicyRepositorier
+	Database               *gorm.DB
+}
+
+// GetWalletPolicy returns the policy of the wallet, or nil if it has none.
+func (d *WalletPolicyService) GetWalletPolicy(ctx context.Context, address string) (*model.WalletPolicy, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := d.WalletPolicyRepository.GetPolicyByAddress(ctx, d.Database, address)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (d *WalletPolicyService) SetWalletPolicy(ctx context.Context, address string, input model.WalletPolicyInput) (*model.WalletPolicy, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if input.MaxTransferAmount != nil && *input.MaxTransferAmount <= 0 {
+		return nil, errors.New("max transfer amount must be greater than zero")
+	}
+	if input.MaxDailyOutflow != nil && *input.MaxDailyOutflow <= 0 {
+		return nil, errors.New("max daily outflow must be greater than zero")
+	}
+	for _, counterparty := range input.AllowedCounterparties {
+		err = address_helper.CheckAddress(counterparty)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	policy := &model.WalletPolicy{
+		Address:               address,
+		MaxTransferAmount:     input.MaxTransferAmount,
+		MaxDailyOutflow:       input.MaxDailyOutflow,
+		AllowedCounterparties: input.AllowedCounterparties,
+	}
+	err = d.WalletPolicyRepository.UpsertPolicy(ctx, d.Database, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("wallet policy set", "address", address)
+	return policy, nil
+}
+
+func (d *WalletPolicyService) RemoveWalletPolicy(ctx context.Context, address string) (bool, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return false, err
+	}
+
+	removed, err := d.WalletPolicyRepository.DeletePolicyByAddress(ctx, d.Database, address)
+	if err != nil {
+		return false, err
+	}
+
+	if removed {
+		logging.FromContext(ctx).Info("wallet policy removed", "address", address)
+	}
+	return removed, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletPolicyService(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t, "policyServiceTests", &model.WalletPolicy{})
+
+	d := WalletPolicyService{
+		WalletPolicyRepository: &repository.DatabaseWalletPolicyRepository{},
+		Database:               db,
+	}
+
+	t.Run("get missing policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallet_Policies")
+
+		policy, err := d.GetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Nil(t, policy)
+	})
+
+	t.Run("set and replace policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallet_Policies")
+		maxAmount := 10
+		maxDaily := 100
+
+		_, err := d.SetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001", model.WalletPolicyInput{
+			MaxTransferAmount: &maxAmount,
+		})
+		require.NoError(t, err)
+
+		_, err = d.SetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001", model.WalletPolicyInput{
+			MaxDailyOutflow:       &maxDaily,
+			AllowedCounterparties: []string{"0x0000000000000000000000000000000000000002"},
+		})
+		require.NoError(t, err)
+
+		policy, err := d.GetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Nil(t, policy.MaxTransferAmount)
+		require.Equal(t, 100, *policy.MaxDailyOutflow)
+		require.Equal(t, []string{"0x0000000000000000000000000000000000000002"}, policy.AllowedCounterparties)
+	})
+
+	t.Run("set invalid policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallet_Policies")
+		zero := 0
+
+		_, err := d.SetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001", model.WalletPolicyInput{
+			MaxTransferAmount: &zero,
+		})
+		require.Error(t, err)
+
+		_, err = d.SetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001", model.WalletPolicyInput{
+			AllowedCounterparties: []string{"0x123"},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("remove policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallet_Policies")
+		maxAmount := 10
+
+		_, err := d.SetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001", model.WalletPolicyInput{
+			MaxTransferAmount: &maxAmount,
+		})
+		require.NoError(t, err)
+
+		removed, err := d.RemoveWalletPolicy(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.True(t, removed)
+
+		removed, err = d.RemoveWalletPolicy(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.False(t, removed)
+
+		// A removed policy can be set again.
+		_, err = d.SetWalletPolicy(ctx, "0x0000000000000000000000000000000000000001", model.WalletPolicyInput{
+			MaxTransferAmount: &maxAmount,
+		})
+		require.NoError(t, err)
+	})
+}
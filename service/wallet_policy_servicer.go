@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+type WalletPolicyServicer interface {
+	GetWalletPolicy(ctx context.Context, address string) (*model.WalletPolicy, error)
+	SetWalletPolicy(ctx context.Context, address string, input model.WalletPolicyInput) (*model.WalletPolicy, error)
+	RemoveWalletPolicy(ctx context.Context, address string) (bool, error)
+}
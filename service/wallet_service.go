@@ -2,124 +2,953 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
 	"github.com/kamil7430/TokenTransferAPI/graph/model"
 	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/ledger"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/outbox"
 	"github.com/kamil7430/TokenTransferAPI/repository"
 	"gorm.io/gorm"
 )
 
+// TreasuryAddress is the address of the wallet created with all InitialSupply tokens.
+// Tokens are never created or destroyed afterwards, only moved between wallets.
+const TreasuryAddress = "0x0000000000000000000000000000000000000000"
+
+const InitialSupply = 1_000_000
+
 type WalletService struct {
-	WalletRepository repository.WalletRepositorier
-	Database         *gorm.DB
+	WalletRepository       repository.WalletRepositorier
+	TransferRepository     repository.TransferRepositorier
+	WalletPolicyRepository repository.WalletPolicyRepositorier
+	HoldRepository         repository.HoldRepositorier
+	// Records the transfers as journal entries and keeps the balances of wallets.
+	Ledger ledger.Ledger
+	// Records events of transfers for delivery downstream; no events are recorded if nil.
+	Outbox   outbox.Outbox
+	Database *gorm.DB
+	// Whether frozen wallets are prevented from receiving tokens, not only from sending them.
+	BlockFrozenRecipients bool
+	// Whether transfers to addresses without a wallet are refused, instead of creating
+	// the recipient's wallet. Wallets then have to be created with CreateWallet.
+	ForbidImplicitRecipientCreation bool
 }
 
+// GetWallet returns the wallet with the given address, or nil if it does not exist.
 func (d *WalletService) GetWallet(ctx context.Context, address string) (*model.Wallet, error) {
 	err := address_helper.CheckAddress(address)
 	if err != nil {
 		return nil, err
 	}
-	return d.WalletRepository.GetWalletByAddress(ctx, d.Database, address)
+
+	wallet, err := d.WalletRepository.GetWalletByAddress(ctx, d.Database, address)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return wallet, nil
 }
 
-func (d *WalletService) Transfer(ctx context.Context, fromAddress string, toAddress string, amount int) (int, error) {
-	if amount <= 0 {
-		return -1, errors.New("amount must be greater than zero")
+// CreateWallet creates an empty, active wallet with the given address.
+func (d *WalletService) CreateWallet(ctx context.Context, address string) (*model.Wallet, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return nil, err
 	}
-	if fromAddress == toAddress {
-		return -1, errors.New("from and to addresses cannot be equal")
+
+	wallet := &model.Wallet{
+		Address: address,
+		Tokens:  0,
+		Status:  model.WalletStatusActive,
+	}
+	err = d.WalletRepository.AddWallet(ctx, d.Database, wallet)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errors.New("wallet already exists")
+		}
+		return nil, err
 	}
 
-	err := address_helper.CheckAddress(fromAddress)
+	logging.FromContext(ctx).Info("wallet created", "address", address)
+	return wallet, nil
+}
+
+// GetWalletsByAddresses returns the wallets with the given addresses in a single query.
+// Every address is included in the result, mapped to nil if it has no wallet.
+func (d *WalletService) GetWalletsByAddresses(ctx context.Context, addresses []string) (map[string]*model.Wallet, error) {
+	for _, address := range addresses {
+		err := address_helper.CheckAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", address, err)
+		}
+	}
+
+	wallets, err := d.WalletRepository.GetWalletsByAddresses(ctx, d.Database, addresses)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
-	err = address_helper.CheckAddress(toAddress)
+
+	result := make(map[string]*model.Wallet, len(addresses))
+	for _, address := range addresses {
+		result[address] = nil
+	}
+	for _, wallet := range wallets {
+		result[wallet.Address] = wallet
+	}
+	return result, nil
+}
+
+// Maximum number of wallets returned by GetWallets and GetRichList.
+const maxWalletsLimit = 100
+
+// GetWallets returns a page of first wallets matching the filter, following the wallet
+// of the after cursor, if it is set.
+func (d *WalletService) GetWallets(ctx context.Context, filter *model.WalletFilter, order model.WalletOrder, first int, after *string) (*model.WalletConnection, error) {
+	if first <= 0 || first > maxWalletsLimit {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxWalletsLimit)
+	}
+	if filter != nil && filter.MinBalance != nil && filter.MaxBalance != nil && *filter.MinBalance > *filter.MaxBalance {
+		return nil, errors.New("min balance must not be greater than max balance")
+	}
+
+	var afterWallet *model.Wallet
+	if after != nil {
+		var err error
+		afterWallet, err = decodeWalletCursor(order, *after)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// One wallet more is fetched to find out if there is a next page.
+	wallets, err := d.WalletRepository.GetWallets(ctx, d.Database, filter, order, first+1, afterWallet)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &model.WalletConnection{
+		Edges:    make([]*model.WalletEdge, 0, first),
+		PageInfo: &model.PageInfo{HasNextPage: len(wallets) > first},
+	}
+	for _, wallet := range wallets[:min(first, len(wallets))] {
+		connection.Edges = append(connection.Edges, &model.WalletEdge{
+			Cursor: encodeWalletCursor(order, wallet),
+			Node:   wallet,
+		})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
+
+// GetRichList returns limit wallets with the highest balances.
+func (d *WalletService) GetRichList(ctx context.Context, limit int) ([]*model.Wallet, error) {
+	if limit <= 0 || limit > maxWalletsLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxWalletsLimit)
+	}
+	return d.WalletRepository.GetWallets(ctx, d.Database, nil, model.WalletOrderBalanceDesc, limit, nil)
+}
+
+// GetWalletActivities summarizes the transfers of the given wallets in a single query.
+// Every address is included in the result, also if its wallet has no transfers.
+func (d *WalletService) GetWalletActivities(ctx context.Context, addresses []string) (map[string]*model.WalletActivity, error) {
+	activities, err := d.TransferRepository.GetActivityByAddresses(ctx, d.Database, addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*model.WalletActivity, len(addresses))
+	for _, address := range addresses {
+		result[address] = &model.WalletActivity{Address: address}
+	}
+	for i := range activities {
+		result[activities[i].Address] = &activities[i]
+	}
+	return result, nil
+}
+
+// Transfer moves amount tokens and returns the new balance of the sender's wallet.
+func (d *WalletService) Transfer(ctx context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any) (int, error) {
+	result, err := d.TransferTokens(ctx, fromAddress, toAddress, amount, memo, metadata, nil)
 	if err != nil {
 		return -1, err
 	}
+	return result.FromWallet.Tokens, nil
+}
+
+// TransferTokens moves amount tokens and returns the transfer with both wallets. If the
+// idempotency key is set and the sender already made a transfer with it, that transfer is
+// returned instead of making a new one, with the wallets as they are now rather than as
+// they were after the transfer. Keys are scoped to the sender.
+func (d *WalletService) TransferTokens(ctx context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any, idempotencyKey *string) (*model.TransferResult, error) {
+	ctx = logging.With(ctx,
+		"from_address", fromAddress,
+		"to_address", toAddress,
+		"amount", amount,
+	)
+
+	result, err := d.transfer(ctx, fromAddress, toAddress, amount, memo, metadata, idempotencyKey)
+	if err != nil {
+		logging.FromContext(ctx).Warn("transfer failed", "outcome", "failure", "error", err)
+		return nil, err
+	}
 
-	var newBalance int
+	logging.FromContext(ctx).Info("transfer completed", "outcome", "success",
+		"transfer_id", result.Transfer.ID, "new_balance", result.FromWallet.Tokens)
+	return result, nil
+}
+
+func (d *WalletService) transfer(ctx context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any, idempotencyKey *string) (*model.TransferResult, error) {
+	if amount <= 0 {
+		return nil, apperror.New(apperror.CodeInvalidInput, "amount must be greater than zero")
+	}
+	if fromAddress == toAddress {
+		return nil, apperror.New(apperror.CodeInvalidInput, "from and to addresses cannot be equal")
+	}
+
+	err := checkTransferDetails(memo, metadata, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range []string{fromAddress, toAddress} {
+		err = address_helper.CheckAddress(address)
+		if err != nil {
+			return nil, apperror.New(apperror.CodeInvalidInput, err.Error())
+		}
+	}
+
+	var result *model.TransferResult
 
 	err = d.Database.Transaction(func(tx *gorm.DB) error {
-		var fromWallet *model.Wallet
-		var toWallet *model.Wallet
-		var err error
+		fromWallet, toWallet, recipientCreated, err := d.lockWallets(ctx, tx, fromAddress, toAddress)
+		if err != nil {
+			return err
+		}
 
-		// To avoid deadlocks, the wallets are queried in specific order.
-		// Lexicographically smaller wallet is queried first. This guarantees
-		// that no cycles of dependencies will occur.
-		if fromAddress < toAddress {
-			fromWallet, err = d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, fromAddress)
+		// The sender's row is locked, so a retry of a transfer that is still in progress
+		// waits for it and finds it here.
+		if idempotencyKey != nil {
+			previous, err := d.getIdempotentTransfer(ctx, tx, *idempotencyKey, fromAddress, toAddress, amount)
 			if err != nil {
 				return err
 			}
-
-			toWallet, err = d.getToWallet(ctx, tx, toAddress)
-			if err != nil {
-				return err
+			if previous != nil {
+				logging.FromContext(ctx).Info("transfer already made", "transfer_id", previous.ID)
+				result = &model.TransferResult{
+					Transfer:   previous,
+					FromWallet: fromWallet,
+					ToWallet:   toWallet,
+				}
+				return nil
 			}
-		} else { // toAddress < fromAddress
-			toWallet, err = d.getToWallet(ctx, tx, toAddress)
-			if err != nil {
-				return err
+		}
+
+		err = d.checkWalletStatuses(fromWallet, toWallet)
+		if err != nil {
+			return err
+		}
+
+		if fromWallet.Available() < amount {
+			return apperror.New(apperror.CodeInsufficientBalance, "insufficient balance")
+		}
+
+		// The sender's row is locked, so no other transfer from this wallet can change
+		// its outflow before this transaction commits.
+		err = d.checkWalletPolicy(ctx, tx, fromWallet, toAddress, amount)
+		if err != nil {
+			return err
+		}
+
+		transfer := &model.Transfer{
+			FromAddress:    fromAddress,
+			ToAddress:      toAddress,
+			Amount:         amount,
+			Memo:           memo,
+			Metadata:       metadata,
+			IdempotencyKey: idempotencyKey,
+		}
+		err = d.recordTransfer(ctx, tx, transfer)
+		if err != nil {
+			return err
+		}
+
+		fromWallet.Tokens -= amount
+		toWallet.Tokens += amount
+
+		result = &model.TransferResult{
+			Transfer:         transfer,
+			FromWallet:       fromWallet,
+			ToWallet:         toWallet,
+			RecipientCreated: recipientCreated,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (d *WalletService) GetTransfer(ctx context.Context, id uint) (*model.Transfer, error) {
+	return d.TransferRepository.GetTransferByID(ctx, d.Database, id)
+}
+
+// Maximum number of transfers returned by GetTransfers.
+const maxTransfersLimit = 100
+
+// GetTransfers returns up to limit transfers sent or received by the wallet, newest first,
+// starting after the transfer with id before, if it is set.
+func (d *WalletService) GetTransfers(ctx context.Context, address string, filter *model.TransferFilter, limit int, before *uint) ([]*model.Transfer, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxTransfersLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTransfersLimit)
+	}
+	if filter != nil && filter.MetadataValue != nil && filter.MetadataKey == nil {
+		return nil, errors.New("metadata value can only be filtered together with metadata key")
+	}
+
+	return d.TransferRepository.GetTransfersByAddress(ctx, d.Database, address, filter, limit, before)
+}
+
+// Number of transfers read from the database at once by ExportTransfers.
+const exportBatchSize = 1000
+
+// ExportTransfers passes all transfers sent or received by the wallet (or all transfers,
+// if address is nil) created since from and before to, ordered by ID, to handle in
+// batches. All batches come from the same state of the database.
+func (d *WalletService) ExportTransfers(ctx context.Context, address *string, from *time.Time, to *time.Time, handle func(batch []model.Transfer) error) error {
+	if address != nil {
+		err := address_helper.CheckAddress(*address)
+		if err != nil {
+			return err
+		}
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return errors.New("from must not be after to")
+	}
+
+	return d.Database.Transaction(func(tx *gorm.DB) error {
+		return d.TransferRepository.StreamTransfers(ctx, tx, address, from, to, exportBatchSize, handle)
+	}, readOnlySnapshot)
+}
+
+// GetReversalOf returns the transfer reversing the transfer with the given id, or nil if
+// it wasn't reversed.
+func (d *WalletService) GetReversalOf(ctx context.Context, id uint) (*model.Transfer, error) {
+	reversal, err := d.TransferRepository.GetReversalOf(ctx, d.Database, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// ReverseTransfer moves the tokens of the transfer back to its sender with a new transfer
+// referencing the original. If the recipient doesn't have all the tokens available any
+// longer, the reversal fails, unless it is forced by an admin; then only the available
+// tokens are moved back. Statuses of the wallets and their policies are not checked,
+// except that closed wallets can't take part in a reversal.
+func (d *WalletService) ReverseTransfer(ctx context.Context, transferID uint, reason string, force bool) (*model.Transfer, error) {
+	ctx = logging.With(ctx, "transfer_id", transferID, "force", force)
+
+	reversal, err := d.reverseTransfer(ctx, transferID, reason, force)
+	if err != nil {
+		logging.FromContext(ctx).Warn("transfer reversal failed", "outcome", "failure", "error", err)
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("transfer reversed", "outcome", "success",
+		"reversal_id", reversal.ID, "amount", reversal.Amount, "reason", reason)
+	return reversal, nil
+}
+
+func (d *WalletService) reverseTransfer(ctx context.Context, transferID uint, reason string, force bool) (*model.Transfer, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("reason cannot be empty")
+	}
+	if force && !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, apperror.New(apperror.CodeForbidden, "only admins can force a reversal")
+	}
+
+	var reversal *model.Transfer
+	err := d.Database.Transaction(func(tx *gorm.DB) error {
+		// The original transfer is locked, so it can't be reversed twice concurrently.
+		original, err := d.TransferRepository.GetTransferByIDForUpdate(ctx, tx, transferID)
+		if err != nil {
+			return err
+		}
+		if original.ReversalOfID != nil {
+			return errors.New("reversals cannot be reversed")
+		}
+
+		_, err = d.TransferRepository.GetReversalOf(ctx, tx, transferID)
+		if err == nil {
+			return errors.New("transfer is already reversed")
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// The tokens go back, so the original recipient is the sender now.
+		fromWallet, toWallet, _, err := d.lockWallets(ctx, tx, original.ToAddress, original.FromAddress)
+		if err != nil {
+			return err
+		}
+		if fromWallet.Status == model.WalletStatusClosed || toWallet.Status == model.WalletStatusClosed {
+			return apperror.New(apperror.CodeWalletClosed, "transfers of closed wallets cannot be reversed")
+		}
+
+		amount := original.Amount
+		if fromWallet.Available() < amount {
+			if !force {
+				return errors.New("recipient no longer has enough available tokens to reverse the transfer")
 			}
+			amount = fromWallet.Available()
+		}
+		if amount <= 0 {
+			return errors.New("recipient has no available tokens to reverse the transfer")
+		}
+
+		reversal = &model.Transfer{
+			FromAddress:  fromWallet.Address,
+			ToAddress:    toWallet.Address,
+			Amount:       amount,
+			ReversalOfID: &original.ID,
+			Reason:       &reason,
+		}
+		return d.recordTransfer(ctx, tx, reversal)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+func (d *WalletService) GetHold(ctx context.Context, id uint) (*model.Hold, error) {
+	return d.HoldRepository.GetHoldByID(ctx, d.Database, id)
+}
+
+// HoldTokens reserves amount tokens of the sender for a transfer to toAddress. The same
+// checks as in Transfer are made, but the tokens only move from the available to the
+// reserved balance of the sender.
+func (d *WalletService) HoldTokens(ctx context.Context, fromAddress string, toAddress string, amount int, expiresAt time.Time) (*model.Hold, error) {
+	ctx = logging.With(ctx,
+		"from_address", fromAddress,
+		"to_address", toAddress,
+		"amount", amount,
+	)
+
+	hold, err := d.holdTokens(ctx, fromAddress, toAddress, amount, expiresAt)
+	if err != nil {
+		logging.FromContext(ctx).Warn("hold failed", "outcome", "failure", "error", err)
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("tokens held", "outcome", "success", "hold_id", hold.ID, "expires_at", hold.ExpiresAt)
+	return hold, nil
+}
+
+func (d *WalletService) holdTokens(ctx context.Context, fromAddress string, toAddress string, amount int, expiresAt time.Time) (*model.Hold, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if fromAddress == toAddress {
+		return nil, errors.New("from and to addresses cannot be equal")
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, errors.New("expiry time must be in the future")
+	}
+
+	err := address_helper.CheckAddress(fromAddress)
+	if err != nil {
+		return nil, err
+	}
+	err = address_helper.CheckAddress(toAddress)
+	if err != nil {
+		return nil, err
+	}
 
-			fromWallet, err = d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, fromAddress)
+	hold := &model.Hold{
+		FromAddress: fromAddress,
+		ToAddress:   toAddress,
+		Amount:      amount,
+		Status:      model.HoldStatusHeld,
+		ExpiresAt:   expiresAt,
+	}
+
+	err = d.Database.Transaction(func(tx *gorm.DB) error {
+		fromWallet, toWallet, _, err := d.lockWallets(ctx, tx, fromAddress, toAddress)
+		if err != nil {
+			return err
+		}
+
+		err = d.checkWalletStatuses(fromWallet, toWallet)
+		if err != nil {
+			return err
+		}
+
+		if fromWallet.Available() < amount {
+			return apperror.New(apperror.CodeInsufficientBalance, "insufficient balance")
+		}
+
+		err = d.checkWalletPolicy(ctx, tx, fromWallet, toAddress, amount)
+		if err != nil {
+			return err
+		}
+
+		err = d.WalletRepository.UpdateWalletReservedByAddress(ctx, tx, fromAddress, fromWallet.Reserved+amount)
+		if err != nil {
+			return err
+		}
+
+		return d.HoldRepository.AddHold(ctx, tx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// CaptureHold transfers the tokens reserved by the hold to its recipient.
+func (d *WalletService) CaptureHold(ctx context.Context, id uint) (*model.Hold, error) {
+	ctx = logging.With(ctx, "hold_id", id)
+
+	var hold *model.Hold
+	err := d.Database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		// The hold is always locked before the wallets, in every operation on holds.
+		hold, err = d.lockHeldHold(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !hold.ExpiresAt.After(time.Now()) {
+			return errors.New("hold has expired")
+		}
+
+		fromWallet, toWallet, _, err := d.lockWallets(ctx, tx, hold.FromAddress, hold.ToAddress)
+		if err != nil {
+			return err
+		}
+
+		err = d.checkWalletStatuses(fromWallet, toWallet)
+		if err != nil {
+			return err
+		}
+
+		err = d.WalletRepository.UpdateWalletReservedByAddress(ctx, tx, hold.FromAddress, fromWallet.Reserved-hold.Amount)
+		if err != nil {
+			return err
+		}
+
+		transfer := &model.Transfer{
+			FromAddress: hold.FromAddress,
+			ToAddress:   hold.ToAddress,
+			Amount:      hold.Amount,
+		}
+		err = d.recordTransfer(ctx, tx, transfer)
+		if err != nil {
+			return err
+		}
+
+		hold.Status = model.HoldStatusCaptured
+		hold.TransferID = &transfer.ID
+		return d.HoldRepository.UpdateHoldStatus(ctx, tx, hold)
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("hold capture failed", "outcome", "failure", "error", err)
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("hold captured", "outcome", "success", "transfer_id", *hold.TransferID)
+	return hold, nil
+}
+
+// VoidHold cancels the hold, releasing the reserved tokens back to the sender.
+func (d *WalletService) VoidHold(ctx context.Context, id uint) (*model.Hold, error) {
+	ctx = logging.With(ctx, "hold_id", id)
+
+	var hold *model.Hold
+	err := d.Database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		hold, err = d.lockHeldHold(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return d.releaseHold(ctx, tx, hold, model.HoldStatusVoided)
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("hold void failed", "outcome", "failure", "error", err)
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("hold voided", "outcome", "success")
+	return hold, nil
+}
+
+// Maximum number of holds released by a single ExpireHolds transaction.
+const expireHoldsBatchSize = 100
+
+// ExpireHolds releases a batch of holds that expired before now and returns how many
+// were released. Holds locked by other transactions are left for the next call, so it
+// can run concurrently in many instances of the service.
+func (d *WalletService) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	var expired int
+	err := d.Database.Transaction(func(tx *gorm.DB) error {
+		holds, err := d.HoldRepository.GetExpiredHoldsForUpdate(ctx, tx, now, expireHoldsBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range holds {
+			err = d.releaseHold(ctx, tx, &holds[i], model.HoldStatusExpired)
 			if err != nil {
 				return err
 			}
 		}
 
-		if fromWallet.Tokens < amount {
-			return errors.New("insufficient balance")
+		expired = len(holds)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if expired > 0 {
+		logging.FromContext(ctx).Info("holds expired", "count", expired)
+	}
+	return expired, nil
+}
+
+// lockHeldHold locks the hold and verifies that it can still be captured or voided.
+func (d *WalletService) lockHeldHold(ctx context.Context, tx *gorm.DB, id uint) (*model.Hold, error) {
+	hold, err := d.HoldRepository.GetHoldByIDForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != model.HoldStatusHeld {
+		return nil, fmt.Errorf("hold is already %s", strings.ToLower(string(hold.Status)))
+	}
+	return hold, nil
+}
+
+// releaseHold returns the tokens reserved by a locked hold to the available balance of
+// the sender and sets the final status of the hold.
+func (d *WalletService) releaseHold(ctx context.Context, tx *gorm.DB, hold *model.Hold, status model.HoldStatus) error {
+	fromWallet, err := d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, hold.FromAddress)
+	if err != nil {
+		return err
+	}
+
+	err = d.WalletRepository.UpdateWalletReservedByAddress(ctx, tx, hold.FromAddress, fromWallet.Reserved-hold.Amount)
+	if err != nil {
+		return err
+	}
+
+	hold.Status = status
+	return d.HoldRepository.UpdateHoldStatus(ctx, tx, hold)
+}
+
+// Maximum length of a memo, in characters.
+const maxMemoLength = 256
+
+// Maximum size of the metadata of a transfer, in bytes of JSON.
+const maxMetadataSize = 4096
+
+// Maximum length of an idempotency key, in characters.
+const maxIdempotencyKeyLength = 128
+
+// checkTransferDetails verifies that the memo, the metadata and the idempotency key of
+// a transfer are not too large.
+func checkTransferDetails(memo *string, metadata map[string]any, idempotencyKey *string) error {
+	if memo != nil && utf8.RuneCountInString(*memo) > maxMemoLength {
+		return apperror.Newf(apperror.CodeInvalidInput, "memo cannot be longer than %d characters", maxMemoLength)
+	}
+	if idempotencyKey != nil {
+		length := utf8.RuneCountInString(*idempotencyKey)
+		if length == 0 || length > maxIdempotencyKeyLength {
+			return apperror.Newf(apperror.CodeInvalidInput, "idempotency key must have between 1 and %d characters", maxIdempotencyKeyLength)
+		}
+	}
+
+	if metadata != nil {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		if len(encoded) > maxMetadataSize {
+			return apperror.Newf(apperror.CodeInvalidInput, "metadata cannot be larger than %d bytes", maxMetadataSize)
+		}
+	}
+
+	return nil
+}
+
+// getIdempotentTransfer returns the transfer made by the sender with the idempotency key,
+// or nil if there is none. It fails if the key was used for a different transfer.
+func (d *WalletService) getIdempotentTransfer(ctx context.Context, tx *gorm.DB, key string, fromAddress string, toAddress string, amount int) (*model.Transfer, error) {
+	transfer, err := d.TransferRepository.GetTransferByIdempotencyKey(ctx, tx, fromAddress, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if transfer.ToAddress != toAddress || transfer.Amount != amount {
+		return nil, apperror.New(apperror.CodeInvalidInput, "idempotency key was already used for a different transfer")
+	}
+	return transfer, nil
+}
+
+// recordTransfer adds the transfer to the history, posts its journal entry, which moves
+// the tokens between the wallets, and records its events in the outbox. Both wallets must
+// be locked.
+func (d *WalletService) recordTransfer(ctx context.Context, tx *gorm.DB, transfer *model.Transfer) error {
+	err := d.TransferRepository.AddTransfer(ctx, tx, transfer)
+	if err != nil {
+		return err
+	}
+	err = d.Ledger.Post(ctx, tx, ledger.NewTransferEntry(transfer))
+	if err != nil {
+		return err
+	}
+
+	if d.Outbox == nil {
+		return nil
+	}
+	events, err := outbox.NewTransferEvents(transfer)
+	if err != nil {
+		return err
+	}
+	return d.Outbox.Add(ctx, tx, events...)
+}
+
+// lockWallets locks the wallets of the sender and the recipient, creating the latter if it
+// does not exist. Reports whether the recipient's wallet was created.
+func (d *WalletService) lockWallets(ctx context.Context, tx *gorm.DB, fromAddress string, toAddress string) (*model.Wallet, *model.Wallet, bool, error) {
+	var fromWallet *model.Wallet
+	var toWallet *model.Wallet
+	var recipientCreated bool
+	var err error
+
+	// To avoid deadlocks, the wallets are queried in specific order.
+	// Lexicographically smaller wallet is queried first. This guarantees
+	// that no cycles of dependencies will occur.
+	if fromAddress < toAddress {
+		fromWallet, err = d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, fromAddress)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		toWallet, recipientCreated, err = d.getToWallet(ctx, tx, toAddress)
+		if err != nil {
+			return nil, nil, false, err
+		}
+	} else { // toAddress < fromAddress
+		toWallet, recipientCreated, err = d.getToWallet(ctx, tx, toAddress)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		fromWallet, err = d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, fromAddress)
+		if err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	return fromWallet, toWallet, recipientCreated, nil
+}
+
+func (d *WalletService) checkWalletStatuses(fromWallet *model.Wallet, toWallet *model.Wallet) error {
+	switch fromWallet.Status {
+	case model.WalletStatusFrozen:
+		return apperror.New(apperror.CodeWalletFrozen, "sender's wallet is frozen")
+	case model.WalletStatusClosed:
+		return apperror.New(apperror.CodeWalletClosed, "sender's wallet is closed")
+	}
+
+	switch toWallet.Status {
+	case model.WalletStatusFrozen:
+		if d.BlockFrozenRecipients {
+			return apperror.New(apperror.CodeWalletFrozen, "recipient's wallet is frozen")
+		}
+	case model.WalletStatusClosed:
+		return apperror.New(apperror.CodeWalletClosed, "recipient's wallet is closed")
+	}
+
+	return nil
+}
+
+func (d *WalletService) FreezeWallet(ctx context.Context, address string, reason string) (*model.Wallet, error) {
+	return d.changeWalletStatus(ctx, address, reason, model.WalletStatusFrozen, func(wallet *model.Wallet) error {
+		if wallet.Status != model.WalletStatusActive {
+			return errors.New("only active wallets can be frozen")
+		}
+		return nil
+	})
+}
+
+func (d *WalletService) UnfreezeWallet(ctx context.Context, address string, reason string) (*model.Wallet, error) {
+	return d.changeWalletStatus(ctx, address, reason, model.WalletStatusActive, func(wallet *model.Wallet) error {
+		if wallet.Status != model.WalletStatusFrozen {
+			return errors.New("only frozen wallets can be unfrozen")
 		}
+		return nil
+	})
+}
+
+func (d *WalletService) CloseWallet(ctx context.Context, address string, reason string) (*model.Wallet, error) {
+	return d.changeWalletStatus(ctx, address, reason, model.WalletStatusClosed, func(wallet *model.Wallet) error {
+		if wallet.Status == model.WalletStatusClosed {
+			return errors.New("wallet is already closed")
+		}
+		if wallet.Tokens != 0 {
+			return errors.New("only empty wallets can be closed")
+		}
+		return nil
+	})
+}
 
-		newFromWalletBalance := fromWallet.Tokens - amount
-		newToWalletBalance := toWallet.Tokens + amount
+// changeWalletStatus sets the status of the wallet if check accepts its current state.
+// The wallet is locked, so the status can't change in the middle of a transfer.
+func (d *WalletService) changeWalletStatus(ctx context.Context, address string, reason string, status model.WalletStatus, check func(wallet *model.Wallet) error) (*model.Wallet, error) {
+	err := address_helper.CheckAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("reason cannot be empty")
+	}
+
+	var wallet *model.Wallet
+	err = d.Database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		wallet, err = d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, address)
+		if err != nil {
+			return err
+		}
 
-		// Since both records are locked, there is no need to stick to the order any longer.
-		err = d.WalletRepository.UpdateWalletTokensByAddress(ctx, tx, fromAddress, newFromWalletBalance)
+		err = check(wallet)
 		if err != nil {
 			return err
 		}
 
-		err = d.WalletRepository.UpdateWalletTokensByAddress(ctx, tx, toAddress, newToWalletBalance)
+		err = d.WalletRepository.UpdateWalletStatusByAddress(ctx, tx, address, status, reason)
 		if err != nil {
 			return err
 		}
 
-		newBalance = newFromWalletBalance
+		wallet.Status = status
+		wallet.StatusReason = &reason
 		return nil
 	})
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
 
-	return newBalance, nil
+	logging.FromContext(ctx).Info("wallet status changed", "address", address, "status", status, "reason", reason)
+	return wallet, nil
 }
 
-func (d *WalletService) getToWallet(ctx context.Context, tx *gorm.DB, toAddress string) (*model.Wallet, error) {
+// checkWalletPolicy verifies that a transfer or hold of amount tokens from the locked
+// fromWallet satisfies its policy. Tokens reserved by held holds count towards the daily
+// outflow, as they are already promised to their recipients and are not checked again
+// when the hold is captured.
+func (d *WalletService) checkWalletPolicy(ctx context.Context, tx *gorm.DB, fromWallet *model.Wallet, toAddress string, amount int) error {
+	policy, err := d.WalletPolicyRepository.GetPolicyByAddress(ctx, tx, fromWallet.Address)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	dailyOutflow := 0
+	if policy.MaxDailyOutflow != nil {
+		dailyOutflow, err = d.TransferRepository.SumOutgoingSince(ctx, tx, fromWallet.Address, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+		dailyOutflow += fromWallet.Reserved
+	}
+
+	return checkPolicyRules(policy, toAddress, amount, dailyOutflow)
+}
+
+// checkPolicyRules verifies that a transfer of amount tokens to toAddress, made after
+// dailyOutflow tokens were sent within the last 24 hours, satisfies the policy.
+func checkPolicyRules(policy *model.WalletPolicy, toAddress string, amount int, dailyOutflow int) error {
+	if policy.MaxTransferAmount != nil && amount > *policy.MaxTransferAmount {
+		return apperror.Newf(apperror.CodePolicyViolation,
+			"amount exceeds the limit of %d tokens per transfer", *policy.MaxTransferAmount).
+			WithExtension("rule", "MAX_TRANSFER_AMOUNT")
+	}
+
+	if policy.MaxDailyOutflow != nil && dailyOutflow+amount > *policy.MaxDailyOutflow {
+		return apperror.Newf(apperror.CodePolicyViolation,
+			"transfer exceeds the limit of %d tokens sent within 24 hours", *policy.MaxDailyOutflow).
+			WithExtension("rule", "MAX_DAILY_OUTFLOW")
+	}
+
+	if len(policy.AllowedCounterparties) > 0 && !slices.Contains(policy.AllowedCounterparties, toAddress) {
+		return apperror.New(apperror.CodePolicyViolation, "recipient is not an allowed counterparty").
+			WithExtension("rule", "COUNTERPARTY_NOT_ALLOWED")
+	}
+
+	return nil
+}
+
+// getToWallet locks the recipient's wallet, creating it if it does not exist (unless
+// ForbidImplicitRecipientCreation is set). Reports
+// whether the wallet was created by this call.
+func (d *WalletService) getToWallet(ctx context.Context, tx *gorm.DB, toAddress string) (*model.Wallet, bool, error) {
+	created := false
 	toWallet, err := d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, toAddress)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if d.ForbidImplicitRecipientCreation {
+				return nil, false, apperror.New(apperror.CodeWalletNotFound, "recipient's wallet does not exist")
+			}
+
 			err = d.WalletRepository.AddWallet(ctx, tx, &model.Wallet{
 				Address: toAddress,
 				Tokens:  0,
+				Status:  model.WalletStatusActive,
 			})
 			if err != nil && !errors.Is(err, gorm.ErrDuplicatedKey) {
-				return nil, err
+				return nil, false, err
 			}
+			// A duplicate key means that a concurrent transfer created the wallet first.
+			created = err == nil
 
 			toWallet, err = d.WalletRepository.GetWalletByAddressForUpdate(ctx, tx, toAddress)
 			if err != nil {
-				return nil, err
+				return nil, false, err
 			}
 		} else {
-			return nil, err
+			return nil, false, err
 		}
 	}
 
-	return toWallet, err
+	return toWallet, created, err
 }
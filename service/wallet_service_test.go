@@ -2,51 +2,35 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
 	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/kamil7430/TokenTransferAPI/ledger"
+	"github.com/kamil7430/TokenTransferAPI/outbox"
 	"github.com/kamil7430/TokenTransferAPI/repository"
 	"github.com/stretchr/testify/require"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	gormpostgres "gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 func TestWalletService(t *testing.T) {
 	ctx := context.Background()
-	dbname := "serviceTests"
-	dbuser := "user"
-	dbpassword := "password"
-
-	ctr, err := postgres.Run(
-		ctx,
-		"postgres:16-alpine",
-		postgres.WithDatabase(dbname),
-		postgres.WithUsername(dbuser),
-		postgres.WithPassword(dbpassword),
-		postgres.BasicWaitStrategies(),
-		postgres.WithSQLDriver("pgx"),
-	)
-	testcontainers.CleanupContainer(t, ctr)
-	require.NoError(t, err)
-
-	err = ctr.Snapshot(ctx)
-	require.NoError(t, err)
-
-	dbURL, err := ctr.ConnectionString(ctx)
-	require.NoError(t, err)
-
-	db, err := gorm.Open(gormpostgres.Open(dbURL), &gorm.Config{})
-	require.NoError(t, err)
-
-	err = db.AutoMigrate(&model.Wallet{})
-	require.NoError(t, err)
+	db := testdb.New(t, "serviceTests", &model.Wallet{}, &model.Transfer{}, &ledger.JournalEntry{}, &ledger.Posting{}, &model.WalletPolicy{}, &model.Hold{}, &outbox.Event{})
+	var err error
 
 	d := WalletService{
-		WalletRepository: &repository.DatabaseWalletRepository{},
-		Database:         db,
+		WalletRepository:       &repository.DatabaseWalletRepository{},
+		TransferRepository:     &repository.DatabaseTransferRepository{},
+		WalletPolicyRepository: &repository.DatabaseWalletPolicyRepository{},
+		HoldRepository:         &repository.DatabaseHoldRepository{},
+		Ledger:                 &ledger.DatabaseLedger{},
+		Outbox:                 &outbox.DatabaseOutbox{},
+		Database:               db,
 	}
 
 	t.Run("get wallet", func(t *testing.T) {
@@ -59,12 +43,79 @@ func TestWalletService(t *testing.T) {
 		require.Equal(t, "0x0000000000000000000000000000000000000001", wallet.Address)
 	})
 
+	t.Run("get non-existing wallet", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+
+		wallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Nil(t, wallet)
+	})
+
+	t.Run("create wallet", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+
+		wallet, err := d.CreateWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 0, wallet.Tokens)
+		require.Equal(t, model.WalletStatusActive, wallet.Status)
+
+		_, err = d.CreateWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.Error(t, err)
+
+		_, err = d.CreateWallet(ctx, "0x01")
+		require.Error(t, err)
+	})
+
+	t.Run("get wallets by addresses", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		wallets, err := d.GetWalletsByAddresses(ctx, []string{
+			"0x0000000000000000000000000000000000000001",
+			"0x0000000000000000000000000000000000000002",
+		})
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		require.Equal(t, 100, wallets["0x0000000000000000000000000000000000000001"].Tokens)
+		require.Nil(t, wallets["0x0000000000000000000000000000000000000002"])
+
+		_, err = d.GetWalletsByAddresses(ctx, []string{"0x01"})
+		require.Error(t, err)
+	})
+
+	t.Run("list wallets", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000000", 300)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 200)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 100)
+
+		page, err := d.GetWallets(ctx, nil, model.WalletOrderBalanceDesc, 2, nil)
+		require.NoError(t, err)
+		require.Len(t, page.Edges, 2)
+		require.True(t, page.PageInfo.HasNextPage)
+		require.Equal(t, page.Edges[1].Cursor, *page.PageInfo.EndCursor)
+
+		page, err = d.GetWallets(ctx, nil, model.WalletOrderBalanceDesc, 2, page.PageInfo.EndCursor)
+		require.NoError(t, err)
+		require.Len(t, page.Edges, 1)
+		require.False(t, page.PageInfo.HasNextPage)
+		require.Equal(t, "0x0000000000000000000000000000000000000002", page.Edges[0].Node.Address)
+
+		_, err = d.GetWallets(ctx, nil, model.WalletOrderBalanceDesc, 101, nil)
+		require.Error(t, err)
+
+		richList, err := d.GetRichList(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, richList, 1)
+		require.Equal(t, "0x0000000000000000000000000000000000000000", richList[0].Address)
+	})
+
 	t.Run("transfer", func(t *testing.T) {
 		db.Exec("TRUNCATE TABLE Wallets")
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 200)
 
-		amount, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60)
+		amount, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
 		require.NoError(t, err)
 		require.Equal(t, 40, amount)
 
@@ -84,7 +135,7 @@ func TestWalletService(t *testing.T) {
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 200)
 
-		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", -60)
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", -60, nil, nil)
 		require.Error(t, err)
 	})
 
@@ -93,7 +144,7 @@ func TestWalletService(t *testing.T) {
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 0)
 
-		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 260)
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 260, nil, nil)
 		require.Error(t, err)
 	})
 
@@ -101,7 +152,7 @@ func TestWalletService(t *testing.T) {
 		db.Exec("TRUNCATE TABLE Wallets")
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 100)
 
-		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60)
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
 		require.Error(t, err)
 	})
 
@@ -109,7 +160,7 @@ func TestWalletService(t *testing.T) {
 		db.Exec("TRUNCATE TABLE Wallets")
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
 
-		amount, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60)
+		amount, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
 		require.NoError(t, err)
 		require.Equal(t, 40, amount)
 
@@ -124,14 +175,460 @@ func TestWalletService(t *testing.T) {
 		require.Equal(t, 60, toWallet.Tokens)
 	})
 
+	t.Run("transfer to non-existing wallet with implicit creation forbidden", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		forbidding := d
+		forbidding.ForbidImplicitRecipientCreation = true
+		_, err := forbidding.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodeWalletNotFound, apperror.CodeOf(err))
+
+		toWallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		require.Nil(t, toWallet)
+
+		_, err = forbidding.CreateWallet(ctx, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		_, err = forbidding.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
+		require.NoError(t, err)
+	})
+
 	t.Run("transfer to own wallet", func(t *testing.T) {
 		db.Exec("TRUNCATE TABLE Wallets")
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
 
-		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000001", 60)
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000001", 60, nil, nil)
 		require.Error(t, err)
 	})
 
+	t.Run("transfer is recorded in the ledger", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
+		require.NoError(t, err)
+
+		var transfers []model.Transfer
+		require.NoError(t, db.Find(&transfers).Error)
+		require.Len(t, transfers, 1)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", transfers[0].FromAddress)
+		require.Equal(t, "0x0000000000000000000000000000000000000002", transfers[0].ToAddress)
+		require.Equal(t, 60, transfers[0].Amount)
+	})
+
+	t.Run("transfer posts a journal entry", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Journal_Entries, Postings")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		result, err := d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil, nil)
+		require.NoError(t, err)
+
+		var entries []ledger.JournalEntry
+		require.NoError(t, db.Preload("Postings").Find(&entries).Error)
+		require.Len(t, entries, 1)
+		require.Equal(t, result.Transfer.ID, *entries[0].TransferID)
+		require.NoError(t, ledger.Check(&entries[0]))
+
+		balance, err := d.Ledger.Balance(ctx, db, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		require.Equal(t, 60, balance)
+	})
+
+	t.Run("transfer records outbox events", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Outbox_Events")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		result, err := d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil, nil)
+		require.NoError(t, err)
+
+		var events []outbox.Event
+		require.NoError(t, db.Order("ID").Find(&events).Error)
+		require.Len(t, events, 2)
+		require.Equal(t, outbox.EventTransferOutgoing, events[0].Type)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", events[0].Address)
+		require.Equal(t, outbox.EventTransferIncoming, events[1].Type)
+		require.Equal(t, "0x0000000000000000000000000000000000000002", events[1].Address)
+		require.Nil(t, events[0].DeliveredAt)
+
+		var data outbox.TransferData
+		require.NoError(t, json.Unmarshal(events[1].Data, &data))
+		require.Equal(t, result.Transfer.ID, data.ID)
+		require.Equal(t, 60, data.Amount)
+	})
+
+	t.Run("transfer with idempotency key is made once", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		key := "order-17"
+
+		first, err := d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil, &key)
+		require.NoError(t, err)
+		require.True(t, first.RecipientCreated)
+
+		retry, err := d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil, &key)
+		require.NoError(t, err)
+		require.Equal(t, first.Transfer.ID, retry.Transfer.ID)
+		require.Equal(t, 40, retry.FromWallet.Tokens)
+		require.Equal(t, 60, retry.ToWallet.Tokens)
+		require.False(t, retry.RecipientCreated)
+
+		_, err = d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, nil, nil, &key)
+		require.ErrorContains(t, err, "idempotency key was already used")
+
+		// Another sender can use the same key.
+		other, err := d.TransferTokens(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000001", 10, nil, nil, &key)
+		require.NoError(t, err)
+		require.NotEqual(t, first.Transfer.ID, other.Transfer.ID)
+
+		var count int64
+		db.Model(&model.Transfer{}).Count(&count)
+		require.Equal(t, int64(2), count)
+	})
+
+	t.Run("failed transfer records no outbox events", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Outbox_Events")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 10)
+
+		_, err := d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil, nil)
+		require.Error(t, err)
+
+		var count int64
+		db.Model(&outbox.Event{}).Count(&count)
+		require.Zero(t, count)
+	})
+
+	t.Run("transfer over policy max amount", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Wallet_Policies")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallet_Policies(Address, Max_Transfer_Amount) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 50)
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodePolicyViolation, apperror.CodeOf(err))
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 50, nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("transfers over policy daily outflow", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Wallet_Policies")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallet_Policies(Address, Max_Daily_Outflow) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 50)
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, nil, nil)
+		require.NoError(t, err)
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 30, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodePolicyViolation, apperror.CodeOf(err))
+
+		wallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 70, wallet.Tokens)
+	})
+
+	t.Run("holds count towards policy daily outflow", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Wallet_Policies, Holds")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallet_Policies(Address, Max_Daily_Outflow) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 50)
+
+		hold, err := d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		_, err = d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, time.Now().Add(time.Hour))
+		require.Error(t, err)
+		require.Equal(t, apperror.CodePolicyViolation, apperror.CodeOf(err))
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 30, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodePolicyViolation, apperror.CodeOf(err))
+
+		_, err = d.CaptureHold(ctx, hold.ID)
+		require.NoError(t, err)
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 30, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodePolicyViolation, apperror.CodeOf(err))
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 20, nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("transfer to counterparty not allowed by policy", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Wallet_Policies")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallet_Policies(Address, Allowed_Counterparties) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", `["0x0000000000000000000000000000000000000002"]`)
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 10, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodePolicyViolation, apperror.CodeOf(err))
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("freeze and unfreeze wallet", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		wallet, err := d.FreezeWallet(ctx, "0x0000000000000000000000000000000000000001", "compromised key")
+		require.NoError(t, err)
+		require.Equal(t, model.WalletStatusFrozen, wallet.Status)
+		require.Equal(t, "compromised key", *wallet.StatusReason)
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodeWalletFrozen, apperror.CodeOf(err))
+
+		_, err = d.FreezeWallet(ctx, "0x0000000000000000000000000000000000000001", "again")
+		require.Error(t, err)
+
+		wallet, err = d.UnfreezeWallet(ctx, "0x0000000000000000000000000000000000000001", "key rotated")
+		require.NoError(t, err)
+		require.Equal(t, model.WalletStatusActive, wallet.Status)
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("transfer to frozen wallet", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallets(Address, Tokens, Status) VALUES ($1, $2, $3)", "0x0000000000000000000000000000000000000002", 0, "FROZEN")
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
+		require.NoError(t, err)
+
+		blocking := d
+		blocking.BlockFrozenRecipients = true
+		_, err = blocking.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodeWalletFrozen, apperror.CodeOf(err))
+	})
+
+	t.Run("close wallet", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 0)
+
+		_, err := d.CloseWallet(ctx, "0x0000000000000000000000000000000000000001", "not empty")
+		require.Error(t, err)
+
+		wallet, err := d.CloseWallet(ctx, "0x0000000000000000000000000000000000000002", "owner left")
+		require.NoError(t, err)
+		require.Equal(t, model.WalletStatusClosed, wallet.Status)
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodeWalletClosed, apperror.CodeOf(err))
+	})
+
+	t.Run("transfer tokens result", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		result, err := d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil, nil)
+		require.NoError(t, err)
+		require.NotZero(t, result.Transfer.ID)
+		require.Equal(t, 40, result.FromWallet.Tokens)
+		require.Equal(t, 60, result.ToWallet.Tokens)
+		require.True(t, result.RecipientCreated)
+
+		result, err = d.TransferTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 70, result.ToWallet.Tokens)
+		require.False(t, result.RecipientCreated)
+	})
+
+	t.Run("transfer with memo and metadata", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		memo := "invoice payment"
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10,
+			&memo, map[string]any{"invoice": "INV-1", "lines": 3})
+		require.NoError(t, err)
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 20,
+			nil, map[string]any{"invoice": "INV-2"})
+		require.NoError(t, err)
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000003", 30, nil, nil)
+		require.NoError(t, err)
+
+		transfers, err := d.GetTransfers(ctx, "0x0000000000000000000000000000000000000001", nil, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, transfers, 3)
+		require.Equal(t, 30, transfers[0].Amount)
+
+		transfers, err = d.GetTransfers(ctx, "0x0000000000000000000000000000000000000001", nil, 10, &transfers[0].ID)
+		require.NoError(t, err)
+		require.Len(t, transfers, 2)
+
+		key := "invoice"
+		transfers, err = d.GetTransfers(ctx, "0x0000000000000000000000000000000000000002", &model.TransferFilter{MetadataKey: &key}, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, transfers, 2)
+
+		value := "INV-1"
+		transfers, err = d.GetTransfers(ctx, "0x0000000000000000000000000000000000000002", &model.TransferFilter{MetadataKey: &key, MetadataValue: &value}, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, "invoice payment", *transfers[0].Memo)
+		require.EqualValues(t, 3, transfers[0].Metadata["lines"])
+	})
+
+	t.Run("reverse transfer", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 0)
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
+		require.NoError(t, err)
+		var original model.Transfer
+		require.NoError(t, db.Last(&original).Error)
+
+		reversal, err := d.ReverseTransfer(ctx, original.ID, "wrong recipient", false)
+		require.NoError(t, err)
+		require.Equal(t, "0x0000000000000000000000000000000000000002", reversal.FromAddress)
+		require.Equal(t, "0x0000000000000000000000000000000000000001", reversal.ToAddress)
+		require.Equal(t, 60, reversal.Amount)
+		require.Equal(t, original.ID, *reversal.ReversalOfID)
+
+		reversedBy, err := d.GetReversalOf(ctx, original.ID)
+		require.NoError(t, err)
+		require.Equal(t, reversal.ID, reversedBy.ID)
+
+		wallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 100, wallet.Tokens)
+
+		_, err = d.ReverseTransfer(ctx, original.ID, "again", false)
+		require.Error(t, err)
+		_, err = d.ReverseTransfer(ctx, reversal.ID, "reversal of reversal", false)
+		require.Error(t, err)
+	})
+
+	t.Run("reverse transfer of spent tokens", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000002", 0)
+
+		_, err := d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, nil, nil)
+		require.NoError(t, err)
+		var original model.Transfer
+		require.NoError(t, db.Last(&original).Error)
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003", 40, nil, nil)
+		require.NoError(t, err)
+
+		_, err = d.ReverseTransfer(ctx, original.ID, "wrong recipient", false)
+		require.Error(t, err)
+
+		operatorCtx := auth.NewContext(ctx, &auth.Principal{Subject: "operator", Role: auth.RoleOperator})
+		_, err = d.ReverseTransfer(operatorCtx, original.ID, "wrong recipient", true)
+		require.Error(t, err)
+		require.Equal(t, apperror.CodeForbidden, apperror.CodeOf(err))
+
+		adminCtx := auth.NewContext(ctx, &auth.Principal{Subject: "admin", Role: auth.RoleAdmin})
+		reversal, err := d.ReverseTransfer(adminCtx, original.ID, "wrong recipient", true)
+		require.NoError(t, err)
+		require.Equal(t, 20, reversal.Amount)
+
+		wallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		require.Equal(t, 0, wallet.Tokens)
+	})
+
+	t.Run("hold and capture", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Holds")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		hold, err := d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusHeld, hold.Status)
+
+		fromWallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 100, fromWallet.Tokens)
+		require.Equal(t, 60, fromWallet.Reserved)
+		require.Equal(t, 40, fromWallet.Available())
+
+		_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 50, nil, nil)
+		require.Error(t, err)
+
+		hold, err = d.CaptureHold(ctx, hold.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusCaptured, hold.Status)
+		require.NotNil(t, hold.TransferID)
+
+		fromWallet, err = d.GetWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 40, fromWallet.Tokens)
+		require.Equal(t, 0, fromWallet.Reserved)
+
+		toWallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000002")
+		require.NoError(t, err)
+		require.Equal(t, 60, toWallet.Tokens)
+
+		_, err = d.CaptureHold(ctx, hold.ID)
+		require.Error(t, err)
+		_, err = d.VoidHold(ctx, hold.ID)
+		require.Error(t, err)
+	})
+
+	t.Run("hold more than available", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Holds")
+		db.Exec("INSERT INTO Wallets(Address, Tokens, Reserved) VALUES ($1, $2, $3)", "0x0000000000000000000000000000000000000001", 100, 80)
+
+		_, err := d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, time.Now().Add(time.Hour))
+		require.Error(t, err)
+
+		_, err = d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, time.Now().Add(-time.Hour))
+		require.Error(t, err)
+	})
+
+	t.Run("void hold", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Holds")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		hold, err := d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 60, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		hold, err = d.VoidHold(ctx, hold.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusVoided, hold.Status)
+
+		fromWallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 100, fromWallet.Tokens)
+		require.Equal(t, 0, fromWallet.Reserved)
+
+		_, err = d.CaptureHold(ctx, hold.ID)
+		require.Error(t, err)
+	})
+
+	t.Run("expire holds", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Wallets, Transfers, Holds")
+		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 100)
+
+		stale, err := d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 30, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		_, err = d.HoldTokens(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 20, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		expired, err := d.ExpireHolds(ctx, time.Now().Add(10*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, expired)
+
+		stale, err = d.GetHold(ctx, stale.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusExpired, stale.Status)
+
+		fromWallet, err := d.GetWallet(ctx, "0x0000000000000000000000000000000000000001")
+		require.NoError(t, err)
+		require.Equal(t, 20, fromWallet.Reserved)
+	})
+
 	t.Run("parallel transfers example from task", func(t *testing.T) {
 		db.Exec("TRUNCATE TABLE Wallets")
 		db.Exec("INSERT INTO Wallets(Address, Tokens) VALUES ($1, $2)", "0x0000000000000000000000000000000000000001", 10)
@@ -150,7 +647,7 @@ func TestWalletService(t *testing.T) {
 		go func() {
 			barrierWG.Done() // report readiness to start
 			<-barrier        // wait on barrier
-			_, _ = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 7)
+			_, _ = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 7, nil, nil)
 			// no error checking because it can either succeed or fail
 			workWG.Done()
 		}()
@@ -159,7 +656,7 @@ func TestWalletService(t *testing.T) {
 		go func() {
 			barrierWG.Done()
 			<-barrier
-			_, _ = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 4)
+			_, _ = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 4, nil, nil)
 			workWG.Done()
 		}()
 
@@ -167,7 +664,7 @@ func TestWalletService(t *testing.T) {
 		go func() {
 			barrierWG.Done()
 			<-barrier
-			_, _ = d.Transfer(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000001", 1)
+			_, _ = d.Transfer(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000001", 1, nil, nil)
 			workWG.Done()
 		}()
 
@@ -205,7 +702,7 @@ func TestWalletService(t *testing.T) {
 		go func() {
 			barrierWG.Done()
 			<-barrier
-			_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10)
+			_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
 			workWG.Done()
 			require.NoError(t, err)
 		}()
@@ -214,7 +711,7 @@ func TestWalletService(t *testing.T) {
 		go func() {
 			barrierWG.Done()
 			<-barrier
-			_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000001", 10)
+			_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000001", 10, nil, nil)
 			workWG.Done()
 			require.NoError(t, err)
 		}()
@@ -250,7 +747,7 @@ func TestWalletService(t *testing.T) {
 			go func() {
 				barrierWG.Done()
 				<-barrier
-				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 5)
+				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 5, nil, nil)
 				workWG.Done()
 				require.NoError(t, err)
 			}()
@@ -290,7 +787,7 @@ func TestWalletService(t *testing.T) {
 			go func() {
 				barrierWG.Done()
 				<-barrier
-				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10)
+				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 10, nil, nil)
 				workWG.Done()
 				require.NoError(t, err)
 			}()
@@ -301,7 +798,7 @@ func TestWalletService(t *testing.T) {
 			go func() {
 				barrierWG.Done()
 				<-barrier
-				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003", 10)
+				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003", 10, nil, nil)
 				workWG.Done()
 				require.NoError(t, err)
 			}()
@@ -312,7 +809,7 @@ func TestWalletService(t *testing.T) {
 			go func() {
 				barrierWG.Done()
 				<-barrier
-				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000003", "0x0000000000000000000000000000000000000001", 5)
+				_, err = d.Transfer(ctx, "0x0000000000000000000000000000000000000003", "0x0000000000000000000000000000000000000001", 5, nil, nil)
 				workWG.Done()
 				require.NoError(t, err)
 			}()
@@ -334,3 +831,63 @@ func TestWalletService(t *testing.T) {
 		require.Equal(t, 550, wallet3.Tokens)
 	})
 }
+
+func TestCheckTransferDetails(t *testing.T) {
+	longMemo := strings.Repeat("ą", maxMemoLength+1)
+	maxMemo := strings.Repeat("ą", maxMemoLength)
+
+	emptyKey := ""
+	longKey := strings.Repeat("k", maxIdempotencyKeyLength+1)
+	maxKey := strings.Repeat("k", maxIdempotencyKeyLength)
+
+	require.NoError(t, checkTransferDetails(nil, nil, nil))
+	require.NoError(t, checkTransferDetails(&maxMemo, map[string]any{"order": "A-1"}, &maxKey))
+	require.Equal(t, apperror.CodeInvalidInput, apperror.CodeOf(checkTransferDetails(&longMemo, nil, nil)))
+	require.Error(t, checkTransferDetails(nil, map[string]any{"blob": strings.Repeat("x", maxMetadataSize)}, nil))
+	require.Error(t, checkTransferDetails(nil, nil, &emptyKey))
+	require.Error(t, checkTransferDetails(nil, nil, &longKey))
+}
+
+func TestCheckPolicyRules(t *testing.T) {
+	maxAmount := 50
+	maxDaily := 100
+	policy := &model.WalletPolicy{
+		Address:               "0x0000000000000000000000000000000000000001",
+		MaxTransferAmount:     &maxAmount,
+		MaxDailyOutflow:       &maxDaily,
+		AllowedCounterparties: []string{"0x0000000000000000000000000000000000000002"},
+	}
+
+	cases := []struct {
+		name         string
+		toAddress    string
+		amount       int
+		dailyOutflow int
+		rule         string
+	}{
+		{"within limits", "0x0000000000000000000000000000000000000002", 50, 50, ""},
+		{"amount over limit", "0x0000000000000000000000000000000000000002", 51, 0, "MAX_TRANSFER_AMOUNT"},
+		{"daily outflow over limit", "0x0000000000000000000000000000000000000002", 10, 91, "MAX_DAILY_OUTFLOW"},
+		{"counterparty not allowed", "0x0000000000000000000000000000000000000003", 10, 0, "COUNTERPARTY_NOT_ALLOWED"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkPolicyRules(policy, c.toAddress, c.amount, c.dailyOutflow)
+			if c.rule == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var appErr *apperror.Error
+			require.ErrorAs(t, err, &appErr)
+			require.Equal(t, apperror.CodePolicyViolation, appErr.Code)
+			require.Equal(t, c.rule, appErr.Extensions["rule"])
+		})
+	}
+
+	t.Run("empty policy allows everything", func(t *testing.T) {
+		err := checkPolicyRules(&model.WalletPolicy{}, "0x0000000000000000000000000000000000000003", 1_000_000, 1_000_000)
+		require.NoError(t, err)
+	})
+}
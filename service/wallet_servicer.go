@@ -2,11 +2,30 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/kamil7430/TokenTransferAPI/graph/model"
 )
 
 type WalletServicer interface {
 	GetWallet(ctx context.Context, address string) (*model.Wallet, error)
-	Transfer(ctx context.Context, fromAddress string, toAddress string, amount int) (int, error)
+	Transfer(ctx context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any) (int, error)
+	TransferTokens(ctx context.Context, fromAddress string, toAddress string, amount int, memo *string, metadata map[string]any, idempotencyKey *string) (*model.TransferResult, error)
+	CreateWallet(ctx context.Context, address string) (*model.Wallet, error)
+	GetWalletsByAddresses(ctx context.Context, addresses []string) (map[string]*model.Wallet, error)
+	GetWallets(ctx context.Context, filter *model.WalletFilter, order model.WalletOrder, first int, after *string) (*model.WalletConnection, error)
+	GetRichList(ctx context.Context, limit int) ([]*model.Wallet, error)
+	GetWalletActivities(ctx context.Context, addresses []string) (map[string]*model.WalletActivity, error)
+	GetTransfer(ctx context.Context, id uint) (*model.Transfer, error)
+	GetTransfers(ctx context.Context, address string, filter *model.TransferFilter, limit int, before *uint) ([]*model.Transfer, error)
+	ExportTransfers(ctx context.Context, address *string, from *time.Time, to *time.Time, handle func(batch []model.Transfer) error) error
+	GetReversalOf(ctx context.Context, id uint) (*model.Transfer, error)
+	ReverseTransfer(ctx context.Context, transferID uint, reason string, force bool) (*model.Transfer, error)
+	FreezeWallet(ctx context.Context, address string, reason string) (*model.Wallet, error)
+	UnfreezeWallet(ctx context.Context, address string, reason string) (*model.Wallet, error)
+	CloseWallet(ctx context.Context, address string, reason string) (*model.Wallet, error)
+	GetHold(ctx context.Context, id uint) (*model.Hold, error)
+	HoldTokens(ctx context.Context, fromAddress string, toAddress string, amount int, expiresAt time.Time) (*model.Hold, error)
+	CaptureHold(ctx context.Context, id uint) (*model.Hold, error)
+	VoidHold(ctx context.Context, id uint) (*model.Hold, error)
 }
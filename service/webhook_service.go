@@ -0,0 +1,356 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/apperror"
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/helper/address_helper"
+	"github.com/kamil7430/TokenTransferAPI/logging"
+	"github.com/kamil7430/TokenTransferAPI/outbox"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/kamil7430/TokenTransferAPI/webhook"
+	"gorm.io/gorm"
+)
+
+// WebhookService notifies registered webhooks about events of their wallets. It receives
+// the events from the outbox relay as its sink, and delivers them with DeliverDueWebhooks.
+type WebhookService struct {
+	WebhookRepository         repository.WebhookRepositorier
+	WebhookDeliveryRepository repository.WebhookDeliveryRepositorier
+	Database                  *gorm.DB
+	Client                    *http.Client
+	// Delay before retrying a failed delivery, doubled with every further failure up to
+	// MaxRetryDelay. A delivery fails for good after MaxAttempts attempts.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	MaxAttempts   int
+}
+
+// Maximum number of wallets a single webhook can be notified about.
+const maxWebhookAddresses = 100
+
+// Maximum number of deliveries returned by GetDeliveries.
+const maxDeliveriesLimit = 100
+
+// Maximum number of deliveries attempted by a single DeliverDueWebhooks call.
+const deliverDueWebhooksBatchSize = 100
+
+// Time for which a claimed delivery isn't attempted by other instances of the service,
+// which must be longer than a single attempt. If the instance stops during an attempt,
+// the delivery is attempted again after that time.
+const deliveryClaimDuration = time.Minute
+
+// Events of the outbox webhooks can subscribe to.
+var webhookEvents = map[string]model.WebhookEvent{
+	outbox.EventTransferOutgoing: model.WebhookEventOutgoingTransfer,
+	outbox.EventTransferIncoming: model.WebhookEventIncomingTransfer,
+}
+
+// webhookPayload is the body of requests sent to webhooks.
+type webhookPayload struct {
+	ID        uint               `json:"id"`
+	Type      model.WebhookEvent `json:"type"`
+	Address   string             `json:"address"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Data      json.RawMessage    `json:"data"`
+}
+
+// RegisterWebhook registers a webhook owned by the caller and returns it together with
+// the secret its requests are signed with. Wallets have no owners, so the caller can
+// watch any of them, the same way operators can transfer tokens from any wallet.
+func (d *WebhookService) RegisterWebhook(ctx context.Context, rawURL string, events []model.WebhookEvent, addresses []string) (*model.WebhookRegistration, error) {
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return nil, apperror.New(apperror.CodeUnauthenticated, "authentication required")
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+	if len(events) == 0 {
+		return nil, errors.New("at least one event is required")
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return nil, fmt.Errorf("unknown event %q", event)
+		}
+	}
+	if len(addresses) == 0 || len(addresses) > maxWebhookAddresses {
+		return nil, fmt.Errorf("between 1 and %d addresses are required", maxWebhookAddresses)
+	}
+	for _, address := range addresses {
+		err = address_helper.CheckAddress(address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &model.Webhook{
+		Owner:     principal.Subject,
+		URL:       rawURL,
+		Events:    events,
+		Addresses: addresses,
+		Secret:    hex.EncodeToString(secret),
+	}
+	err = d.WebhookRepository.AddWebhook(ctx, d.Database, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("webhook registered", "webhook_id", hook.ID, "url", rawURL)
+	return &model.WebhookRegistration{Webhook: hook, Secret: hook.Secret}, nil
+}
+
+// GetWebhook returns the webhook with the given id, or nil if it does not exist or the
+// caller may not manage it.
+func (d *WebhookService) GetWebhook(ctx context.Context, id uint) (*model.Webhook, error) {
+	hook, err := d.WebhookRepository.GetWebhookByID(ctx, d.Database, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !canManageWebhook(ctx, hook) {
+		return nil, nil
+	}
+	return hook, nil
+}
+
+// GetWebhooks returns the webhooks registered by the caller, or all webhooks for admins.
+func (d *WebhookService) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	if auth.HasRole(ctx, auth.RoleAdmin) {
+		return d.WebhookRepository.GetWebhooks(ctx, d.Database, nil)
+	}
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return []*model.Webhook{}, nil
+	}
+	return d.WebhookRepository.GetWebhooks(ctx, d.Database, &principal.Subject)
+}
+
+// DeleteWebhook deletes the webhook permanently. Its pending deliveries fail at their next
+// attempt.
+func (d *WebhookService) DeleteWebhook(ctx context.Context, id uint) (bool, error) {
+	hook, err := d.GetWebhook(ctx, id)
+	if err != nil || hook == nil {
+		return false, err
+	}
+
+	deleted, err := d.WebhookRepository.DeleteWebhookByID(ctx, d.Database, id)
+	if err != nil {
+		return false, err
+	}
+
+	if deleted {
+		logging.FromContext(ctx).Info("webhook deleted", "webhook_id", id)
+	}
+	return deleted, nil
+}
+
+func (d *WebhookService) GetDeliveries(ctx context.Context, webhookID uint, status *model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	if limit <= 0 || limit > maxDeliveriesLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxDeliveriesLimit)
+	}
+	hook, err := d.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil {
+		return nil, errors.New("webhook not found")
+	}
+	return d.WebhookDeliveryRepository.GetDeliveriesByWebhookID(ctx, d.Database, webhookID, status, limit)
+}
+
+// Deliver implements outbox.Sink by scheduling deliveries of the event to the webhooks
+// subscribed to it. Events delivered again are not scheduled twice.
+func (d *WebhookService) Deliver(ctx context.Context, event *outbox.Event) error {
+	webhookEvent, ok := webhookEvents[event.Type]
+	if !ok {
+		return nil
+	}
+
+	hooks, err := d.WebhookRepository.GetWebhooksForEvent(ctx, d.Database, webhookEvent, event.Address)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:        event.ID,
+		Type:      webhookEvent,
+		Address:   event.Address,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]model.WebhookDelivery, 0, len(hooks))
+	for _, hook := range hooks {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       event.ID,
+			Event:         webhookEvent,
+			Address:       event.Address,
+			Payload:       string(payload),
+			Status:        model.WebhookDeliveryStatusPending,
+			NextAttemptAt: &now,
+		})
+	}
+	return d.WebhookDeliveryRepository.AddDeliveries(ctx, d.Database, deliveries)
+}
+
+// DeliverDueWebhooks attempts a batch of deliveries that are due at now and returns how
+// many were attempted, successfully or not. It can run concurrently in many instances of
+// the service, as every delivery is claimed by exactly one of them.
+func (d *WebhookService) DeliverDueWebhooks(ctx context.Context, now time.Time) (int, error) {
+	for attempted := 0; attempted < deliverDueWebhooksBatchSize; attempted++ {
+		// The delivery is claimed in its own transaction, so that no transaction stays
+		// open while the webhook is called.
+		var delivery *model.WebhookDelivery
+		err := d.Database.Transaction(func(tx *gorm.DB) error {
+			var err error
+			delivery, err = d.WebhookDeliveryRepository.ClaimDueDelivery(ctx, tx, now, time.Now().Add(deliveryClaimDuration))
+			return err
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return attempted, nil
+		}
+		if err != nil {
+			return attempted, err
+		}
+
+		err = d.attemptDelivery(ctx, delivery, now)
+		if err != nil {
+			return attempted, err
+		}
+	}
+	return deliverDueWebhooksBatchSize, nil
+}
+
+// attemptDelivery sends the claimed delivery to its webhook and records the outcome,
+// scheduling a retry if it failed.
+func (d *WebhookService) attemptDelivery(ctx context.Context, delivery *model.WebhookDelivery, now time.Time) error {
+	ctx = logging.With(ctx, "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID)
+
+	var responseStatus *int32
+	var deliveryErr error
+	hook, err := d.WebhookRepository.GetWebhookByID(ctx, d.Database, delivery.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		delivery.Attempts = int32(d.MaxAttempts)
+		deliveryErr = errors.New("webhook was deleted")
+	} else if err != nil {
+		return err
+	} else {
+		delivery.Attempts++
+		responseStatus, deliveryErr = d.send(ctx, hook, delivery)
+	}
+
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = responseStatus
+	delivery.NextAttemptAt = nil
+	delivery.Error = nil
+	switch {
+	case deliveryErr == nil:
+		delivery.Status = model.WebhookDeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+	case int(delivery.Attempts) >= d.MaxAttempts:
+		message := deliveryErr.Error()
+		delivery.Status = model.WebhookDeliveryStatusFailed
+		delivery.Error = &message
+	default:
+		message := deliveryErr.Error()
+		nextAttemptAt := now.Add(d.retryDelay(int(delivery.Attempts) - 1))
+		delivery.Error = &message
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+
+	err = d.WebhookDeliveryRepository.UpdateDelivery(ctx, d.Database, delivery)
+	if err != nil {
+		return err
+	}
+
+	if deliveryErr != nil {
+		logging.FromContext(ctx).Warn("webhook delivery failed", "status", delivery.Status,
+			"attempts", delivery.Attempts, "error", deliveryErr)
+	} else {
+		logging.FromContext(ctx).Info("webhook delivered", "attempts", delivery.Attempts)
+	}
+	return nil
+}
+
+// send posts the payload of the delivery to the webhook and returns the response status,
+// if there was a response. The request is signed with the time it is sent at, so that
+// receivers checking the signature's age don't reject it.
+func (d *WebhookService) send(ctx context.Context, hook *model.Webhook, delivery *model.WebhookDelivery) (*int32, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(hook.Secret, time.Now(), body))
+	req.Header.Set(webhook.WebhookIDHeader, strconv.FormatUint(uint64(hook.ID), 10))
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(webhook.EventHeader, string(delivery.Event))
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := int32(resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &status, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return &status, nil
+}
+
+// retryDelay returns the delay before the next attempt of a delivery that failed
+// failures+1 times.
+func (d *WebhookService) retryDelay(failures int) time.Duration {
+	delay := d.RetryDelay
+	for range failures {
+		delay *= 2
+		if delay >= d.MaxRetryDelay {
+			return d.MaxRetryDelay
+		}
+	}
+	return min(delay, d.MaxRetryDelay)
+}
+
+func canManageWebhook(ctx context.Context, hook *model.Webhook) bool {
+	if auth.HasRole(ctx, auth.RoleAdmin) {
+		return true
+	}
+	principal := auth.FromContext(ctx)
+	return principal != nil && principal.Subject == hook.Owner
+}
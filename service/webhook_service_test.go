@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/auth"
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+	"github.com/kamil7430/TokenTransferAPI/internal/testdb"
+	"github.com/kamil7430/TokenTransferAPI/outbox"
+	"github.com/kamil7430/TokenTransferAPI/repository"
+	"github.com/kamil7430/TokenTransferAPI/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookService(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "alice", Role: auth.RoleOperator})
+	db := testdb.New(t, "webhookServiceTests", &model.Webhook{}, &model.WebhookDelivery{})
+
+	status := http.StatusOK
+	var request *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	d := WebhookService{
+		WebhookRepository:         &repository.DatabaseWebhookRepository{},
+		WebhookDeliveryRepository: &repository.DatabaseWebhookDeliveryRepository{},
+		Database:                  db,
+		RetryDelay:                time.Minute,
+		MaxRetryDelay:             time.Hour,
+		MaxAttempts:               2,
+	}
+	event := &outbox.Event{
+		ID:        7,
+		CreatedAt: time.Now(),
+		Type:      outbox.EventTransferIncoming,
+		Address:   "0x0000000000000000000000000000000000000002",
+		Data:      json.RawMessage(`{"amount":60}`),
+	}
+
+	t.Run("deliver signed event", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhooks, Webhook_Deliveries")
+		status = http.StatusOK
+
+		registration, err := d.RegisterWebhook(ctx, server.URL, []model.WebhookEvent{model.WebhookEventIncomingTransfer},
+			[]string{"0x0000000000000000000000000000000000000002"})
+		require.NoError(t, err)
+		require.Len(t, registration.Secret, 64)
+
+		require.NoError(t, d.Deliver(ctx, event))
+		require.NoError(t, d.Deliver(ctx, event))
+
+		// The request is signed when it is sent, not when the batch of deliveries started.
+		batchStartedAt := time.Now().Add(-30 * time.Minute)
+		db.Exec("UPDATE Webhook_Deliveries SET Next_Attempt_At = $1", batchStartedAt.Add(-time.Minute))
+		attempted, err := d.DeliverDueWebhooks(ctx, batchStartedAt)
+		require.NoError(t, err)
+		require.Equal(t, 1, attempted)
+
+		require.NoError(t, webhook.Verify(registration.Secret, request.Header.Get(webhook.SignatureHeader), body, time.Now(), time.Minute))
+		require.Equal(t, string(model.WebhookEventIncomingTransfer), request.Header.Get(webhook.EventHeader))
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Equal(t, "INCOMING_TRANSFER", payload["type"])
+		require.Equal(t, map[string]any{"amount": 60.0}, payload["data"])
+
+		deliveries, err := d.GetDeliveries(ctx, registration.Webhook.ID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, model.WebhookDeliveryStatusDelivered, deliveries[0].Status)
+		require.Equal(t, int32(http.StatusOK), *deliveries[0].ResponseStatus)
+	})
+
+	t.Run("ignore events of other wallets", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhooks, Webhook_Deliveries")
+
+		registration, err := d.RegisterWebhook(ctx, server.URL, []model.WebhookEvent{model.WebhookEventOutgoingTransfer},
+			[]string{"0x0000000000000000000000000000000000000002"})
+		require.NoError(t, err)
+
+		require.NoError(t, d.Deliver(ctx, event))
+
+		deliveries, err := d.GetDeliveries(ctx, registration.Webhook.ID, nil, 10)
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+
+	t.Run("retry failed delivery", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhooks, Webhook_Deliveries")
+		status = http.StatusServiceUnavailable
+
+		registration, err := d.RegisterWebhook(ctx, server.URL, []model.WebhookEvent{model.WebhookEventIncomingTransfer},
+			[]string{"0x0000000000000000000000000000000000000002"})
+		require.NoError(t, err)
+		require.NoError(t, d.Deliver(ctx, event))
+
+		now := time.Now()
+		_, err = d.DeliverDueWebhooks(ctx, now)
+		require.NoError(t, err)
+
+		deliveries, err := d.GetDeliveries(ctx, registration.Webhook.ID, nil, 10)
+		require.NoError(t, err)
+		require.Equal(t, model.WebhookDeliveryStatusPending, deliveries[0].Status)
+		require.Equal(t, int32(1), deliveries[0].Attempts)
+		require.NotNil(t, deliveries[0].Error)
+		require.WithinDuration(t, now.Add(time.Minute), *deliveries[0].NextAttemptAt, time.Second)
+
+		attempted, err := d.DeliverDueWebhooks(ctx, now)
+		require.NoError(t, err)
+		require.Equal(t, 0, attempted)
+
+		attempted, err = d.DeliverDueWebhooks(ctx, now.Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, attempted)
+
+		failed := model.WebhookDeliveryStatusFailed
+		deliveries, err = d.GetDeliveries(ctx, registration.Webhook.ID, &failed, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, int32(2), deliveries[0].Attempts)
+		require.Equal(t, int32(http.StatusServiceUnavailable), *deliveries[0].ResponseStatus)
+	})
+
+	t.Run("hide webhooks of other owners", func(t *testing.T) {
+		db.Exec("TRUNCATE TABLE Webhooks, Webhook_Deliveries")
+		bobCtx := auth.NewContext(context.Background(), &auth.Principal{Subject: "bob", Role: auth.RoleOperator})
+		adminCtx := auth.NewContext(context.Background(), &auth.Principal{Subject: "admin", Role: auth.RoleAdmin})
+
+		registration, err := d.RegisterWebhook(ctx, server.URL, []model.WebhookEvent{model.WebhookEventIncomingTransfer},
+			[]string{"0x0000000000000000000000000000000000000002"})
+		require.NoError(t, err)
+
+		hook, err := d.GetWebhook(bobCtx, registration.Webhook.ID)
+		require.NoError(t, err)
+		require.Nil(t, hook)
+		deleted, err := d.DeleteWebhook(bobCtx, registration.Webhook.ID)
+		require.NoError(t, err)
+		require.False(t, deleted)
+
+		webhooks, err := d.GetWebhooks(adminCtx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		deleted, err = d.DeleteWebhook(ctx, registration.Webhook.ID)
+		require.NoError(t, err)
+		require.True(t, deleted)
+	})
+
+	t.Run("register invalid webhook", func(t *testing.T) {
+		events := []model.WebhookEvent{model.WebhookEventIncomingTransfer}
+		addresses := []string{"0x0000000000000000000000000000000000000002"}
+
+		_, err := d.RegisterWebhook(ctx, "ftp://example.com", events, addresses)
+		require.Error(t, err)
+		_, err = d.RegisterWebhook(ctx, server.URL, nil, addresses)
+		require.Error(t, err)
+		_, err = d.RegisterWebhook(ctx, server.URL, events, []string{"0x1"})
+		require.Error(t, err)
+		_, err = d.RegisterWebhook(context.Background(), server.URL, events, addresses)
+		require.Error(t, err)
+	})
+}
+
+func TestWebhookService_RetryDelay(t *testing.T) {
+	d := &WebhookService{RetryDelay: 10 * time.Second, MaxRetryDelay: time.Hour}
+
+	require.Equal(t, 10*time.Second, d.retryDelay(0))
+	require.Equal(t, 20*time.Second, d.retryDelay(1))
+	require.Equal(t, time.Hour, d.retryDelay(9))
+	require.Equal(t, time.Hour, d.retryDelay(1000))
+}
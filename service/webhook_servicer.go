@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kamil7430/TokenTransferAPI/graph/model"
+)
+
+type WebhookServicer interface {
+	RegisterWebhook(ctx context.Context, url string, events []model.WebhookEvent, addresses []string) (*model.WebhookRegistration, error)
+	GetWebhook(ctx context.Context, id uint) (*model.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint) (bool, error)
+	GetDeliveries(ctx context.Context, webhookID uint, status *model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error)
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.215.14":    true,
+		"2606:2800:21f::1": true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"224.0.0.1":        false,
+		"::ffff:127.0.0.1": false,
+		"::ffff:10.0.0.1":  false,
+	}
+
+	for address, public := range cases {
+		t.Run(address, func(t *testing.T) {
+			require.Equal(t, public, IsPublicAddress(netip.MustParseAddr(address)))
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Run("loopback address is refused", func(t *testing.T) {
+		_, err := NewClient(time.Second, false).Get(server.URL)
+		require.ErrorIs(t, err, ErrForbiddenAddress)
+	})
+
+	t.Run("private addresses allowed", func(t *testing.T) {
+		response, err := NewClient(time.Second, true).Get(server.URL)
+		require.NoError(t, err)
+		response.Body.Close()
+		require.Equal(t, http.StatusNoContent, response.StatusCode)
+	})
+}
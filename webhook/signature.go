@@ -0,0 +1,68 @@
+// Package webhook signs the requests sent to webhooks, keeps them away from the internal
+// network and lets their receivers verify the signatures.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of requests sent to webhooks.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	WebhookIDHeader = "X-Webhook-ID"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the value of the SignatureHeader of a request with the body sent at
+// timestamp: "t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<unix timestamp>.<body>">".
+// Including the timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(mac(secret, unix, body)))
+}
+
+// Verify checks that the signature of the body was made with the secret at most
+// tolerance before now.
+func Verify(secret string, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	var unix, digest string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			digest = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(seconds, 0)) > tolerance {
+		return fmt.Errorf("%w: signed too long ago", ErrInvalidSignature)
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil || !hmac.Equal(expected, mac(secret, unix, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mac(secret string, unix string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(unix))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
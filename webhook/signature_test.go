@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	signedAt := time.Unix(1767323045, 0)
+	body := []byte(`{"type":"INCOMING_TRANSFER"}`)
+
+	signature := Sign("secret", signedAt, body)
+	require.Equal(t, "t=1767323045,v1=2eeb938cf32e138ae0b9c1cc56f06f443774e59a603df9732b319eda0a2bdcd6", signature)
+
+	t.Run("valid signature", func(t *testing.T) {
+		require.NoError(t, Verify("secret", signature, body, signedAt.Add(time.Minute), 5*time.Minute))
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		require.ErrorIs(t, Verify("other", signature, body, signedAt, 5*time.Minute), ErrInvalidSignature)
+	})
+
+	t.Run("changed body", func(t *testing.T) {
+		require.ErrorIs(t, Verify("secret", signature, []byte(`{}`), signedAt, 5*time.Minute), ErrInvalidSignature)
+	})
+
+	t.Run("replayed request", func(t *testing.T) {
+		require.ErrorIs(t, Verify("secret", signature, body, signedAt.Add(time.Hour), 5*time.Minute), ErrInvalidSignature)
+	})
+
+	t.Run("malformed signature", func(t *testing.T) {
+		require.ErrorIs(t, Verify("secret", "v1=abc", body, signedAt, 5*time.Minute), ErrInvalidSignature)
+	})
+}
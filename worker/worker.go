@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamil7430/TokenTransferAPI/logging"
+)
+
+// Run calls task every interval until ctx is done. Failures of the task are logged and
+// the task is retried on the next tick.
+func Run(ctx context.Context, name string, interval time.Duration, task func(ctx context.Context) error) {
+	ctx = logging.With(ctx, "worker", name)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logging.FromContext(ctx).Info("worker started", "interval", interval.String())
+	for {
+		select {
+		case <-ctx.Done():
+			logging.FromContext(ctx).Info("worker stopped")
+			return
+		case <-ticker.C:
+			err := task(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("worker task failed", "error", err)
+			}
+		}
+	}
+}